@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"fm-doc-canvas/backend"
+	"fm-doc-canvas/backend/canvas"
 )
 
 // App struct
@@ -15,6 +16,8 @@ type App struct {
 	llmService        *backend.LLMService
 	imageGenService   *backend.ImageGenService
 	imageAssetService *backend.ImageAssetService
+	assetHandler      *backend.AssetHandler
+	autosaveService   *backend.AutosaveService
 }
 
 // NewApp creates a new App application struct
@@ -25,10 +28,14 @@ func NewApp() *App {
 		fmt.Printf("Error initializing ConfigService: %v\n", err)
 	}
 
-	fileService := backend.NewFileService(configService)
+	assetStore := backend.NewAssetStore(configService)
 	llmService := backend.NewLLMService(configService)
-	imageGenService := backend.NewImageGenService(configService)
-	imageAssetService := backend.NewImageAssetService(configService)
+	imageAssetService := backend.NewImageAssetService(configService, assetStore, llmService)
+	fileService := backend.NewFileService(configService, assetStore, imageAssetService)
+	imageAssetService.SetOpenCanvasSource(fileService.CurrentCanvasPath)
+	imageGenService := backend.NewImageGenService(configService, assetStore, llmService, imageAssetService)
+	assetHandler := backend.NewAssetHandler(configService)
+	autosaveService := backend.NewAutosaveService(configService)
 
 	return &App{
 		configService:     configService,
@@ -36,6 +43,8 @@ func NewApp() *App {
 		llmService:        llmService,
 		imageGenService:   imageGenService,
 		imageAssetService: imageAssetService,
+		assetHandler:      assetHandler,
+		autosaveService:   autosaveService,
 	}
 }
 
@@ -44,6 +53,18 @@ func NewApp() *App {
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
 	a.fileService.SetContext(ctx)
+	if err := a.fileService.StartWatching(); err != nil {
+		fmt.Printf("Warning: canvas file changes will not be detected: %v\n", err)
+	}
+}
+
+// shutdown is called when the app is closing. Pending autosave state is written
+// so unsaved work can be recovered on the next start.
+func (a *App) shutdown(ctx context.Context) {
+	a.fileService.StopWatching()
+	if err := a.autosaveService.Flush(); err != nil {
+		fmt.Printf("Warning: failed to write autosave on shutdown: %v\n", err)
+	}
 }
 
 // GetConfig returns the application configuration
@@ -51,19 +72,103 @@ func (a *App) GetConfig() (backend.Config, error) {
 	return a.configService.GetConfig(), nil
 }
 
+// GetConfigWarnings returns problems found while loading the configuration, e.g. recovery from the backup
+func (a *App) GetConfigWarnings() []string {
+	return a.configService.Warnings()
+}
+
 // SaveConfig updates the application configuration
 func (a *App) SaveConfig(cfg backend.Config) error {
-	return a.configService.Save(&cfg)
+	return a.configService.SaveSettings(&cfg)
 }
 
-// SaveCanvasToFile opens a dialog and saves the canvas JSON
-func (a *App) SaveCanvasToFile(jsonData string) (string, error) {
-	return a.fileService.SaveCanvasToFile(jsonData)
+// SaveCanvas saves the canvas to the current file, asking for a path only for a new canvas
+func (a *App) SaveCanvas(c canvas.Canvas) (backend.SaveCanvasResult, error) {
+	result, err := a.fileService.SaveCanvas(c)
+	if err == nil && result.Path != "" {
+		a.autosaveService.MarkSaved(&c)
+	}
+	return result, err
+}
+
+// SaveCanvasAs opens a dialog and saves the validated canvas to a new current file
+func (a *App) SaveCanvasAs(c canvas.Canvas) (backend.SaveCanvasResult, error) {
+	result, err := a.fileService.SaveCanvasAs(c)
+	if err == nil && result.Path != "" {
+		a.autosaveService.MarkSaved(&c)
+	}
+	return result, err
+}
+
+// OverwriteCanvas saves the canvas to the current file, discarding changes made outside the app
+func (a *App) OverwriteCanvas(c canvas.Canvas) (backend.SaveCanvasResult, error) {
+	result, err := a.fileService.OverwriteCanvas(c)
+	if err == nil && result.Path != "" {
+		a.autosaveService.MarkSaved(&c)
+	}
+	return result, err
+}
+
+// MergeCanvasWithFile merges changes made to the current file outside the app into the canvas (not saved)
+func (a *App) MergeCanvasWithFile(c canvas.Canvas) (backend.LoadCanvasResult, error) {
+	return a.fileService.MergeCanvasWithFile(c)
+}
+
+// LoadCanvasFromFile opens a dialog and reads a canvas, migrated to the current version
+func (a *App) LoadCanvasFromFile() (backend.LoadCanvasResult, error) {
+	result, err := a.fileService.LoadCanvasFromFile()
+	if err == nil && result.Canvas != nil {
+		a.autosaveService.MarkSaved(result.Canvas)
+	}
+	return result, err
+}
+
+// OpenCanvasPath reads the canvas file at the given path, e.g. from the recent list
+func (a *App) OpenCanvasPath(path string) (backend.LoadCanvasResult, error) {
+	result, err := a.fileService.OpenCanvasPath(path)
+	if err == nil {
+		a.autosaveService.MarkSaved(result.Canvas)
+	}
+	return result, err
 }
 
-// LoadCanvasFromFile opens a dialog and reads a canvas JSON
-func (a *App) LoadCanvasFromFile() (string, error) {
-	return a.fileService.LoadCanvasFromFile()
+// GetRecentCanvases returns the recently used canvas files, newest first
+func (a *App) GetRecentCanvases() []string {
+	return a.fileService.GetRecentCanvases()
+}
+
+// GetCurrentCanvasPath returns the canvas file being edited, empty for a new canvas
+func (a *App) GetCurrentCanvasPath() string {
+	return a.fileService.CurrentCanvasPath()
+}
+
+// NewCanvas forgets the current file, so the next save asks for a path
+func (a *App) NewCanvas() {
+	a.fileService.SetCurrentCanvasPath("")
+}
+
+// ListCanvasVersions returns the saved versions of a canvas file (empty path: the current file), newest first
+func (a *App) ListCanvasVersions(path string) ([]backend.CanvasVersion, error) {
+	return a.fileService.ListCanvasVersions(path)
+}
+
+// GetCanvasVersion returns a saved version of a canvas file
+func (a *App) GetCanvasVersion(path string, id string) (backend.LoadCanvasResult, error) {
+	return a.fileService.GetCanvasVersion(path, id)
+}
+
+// DiffCanvasVersions compares two versions of a canvas file; an empty toID means the saved file
+func (a *App) DiffCanvasVersions(path string, fromID string, toID string) (canvas.Diff, error) {
+	return a.fileService.DiffCanvasVersions(path, fromID, toID)
+}
+
+// RestoreCanvasVersion writes a saved version back to the canvas file and loads it
+func (a *App) RestoreCanvasVersion(path string, id string) (backend.LoadCanvasResult, error) {
+	result, err := a.fileService.RestoreCanvasVersion(path, id)
+	if err == nil {
+		a.autosaveService.MarkSaved(result.Canvas)
+	}
+	return result, err
 }
 
 // GenerateText calls the LLM service to generate content based on prompt and context
@@ -81,9 +186,19 @@ func (a *App) Greet(name string) string {
 	return fmt.Sprintf("Hello %s, It's show time!", name)
 }
 
-// GenerateImage generates an image based on a prompt and reference images
-func (a *App) GenerateImage(prompt string, contextData string, refImages []string) (string, error) {
-	return a.imageGenService.GenerateImage(prompt, contextData, refImages)
+// GenerateImage generates an image based on a prompt, reference images and an optional style preset
+func (a *App) GenerateImage(prompt string, contextData string, refImages []string, stylePreset string) (backend.ImageGenResult, error) {
+	return a.imageGenService.GenerateImage(prompt, contextData, refImages, stylePreset)
+}
+
+// GetImageProviders returns the available image generation providers and their settings schema
+func (a *App) GetImageProviders() []backend.ImageProviderInfo {
+	return a.imageGenService.GetImageProviders()
+}
+
+// GetImageProviderCapabilities returns the capabilities of an image provider (the configured one when name is empty)
+func (a *App) GetImageProviderCapabilities(name string) (backend.ImageGenCapabilities, error) {
+	return a.imageGenService.GetImageProviderCapabilities(name)
 }
 
 // GetImageDataURL converts a relative image path to a Data URL for display
@@ -124,3 +239,116 @@ func (a *App) ExportImage(src string) (string, error) {
 func (a *App) GetImageFileURL(src string) (string, error) {
 	return a.fileService.GetImageFileURL(src)
 }
+
+// GetImageProvenance returns the generation provenance recorded for an image
+func (a *App) GetImageProvenance(src string) (backend.ImageProvenance, error) {
+	return a.imageAssetService.GetImageProvenance(src)
+}
+
+// RegenerateImage replays the generation request recorded for an image
+func (a *App) RegenerateImage(src string) (backend.ImageGenResult, error) {
+	return a.imageGenService.RegenerateImage(src)
+}
+
+// DescribeImage generates a caption and description of an image with the LLM ("brief" or "detailed")
+func (a *App) DescribeImage(src string, detail string) (backend.ImageDescription, error) {
+	return a.imageAssetService.DescribeImage(src, detail)
+}
+
+// GetImageThumbnail returns a downscaled Data URL of an image for display
+func (a *App) GetImageThumbnail(src string, maxEdge int) (string, error) {
+	return a.imageAssetService.GetImageThumbnail(src, maxEdge)
+}
+
+// GetImageAssetURL returns the AssetServer URL of an image for display
+func (a *App) GetImageAssetURL(src string) (string, error) {
+	return a.imageAssetService.GetImageAssetURL(src)
+}
+
+// SelectCanvasFiles opens a dialog for choosing canvas files to scan
+func (a *App) SelectCanvasFiles() ([]string, error) {
+	return a.fileService.SelectCanvasFiles()
+}
+
+// ScanAssets reports which images in the download directory are referenced by the given canvases
+// and, optionally, the recent canvases
+func (a *App) ScanAssets(canvasPaths []string, includeRecent bool) (backend.AssetReport, error) {
+	// Write pending autosave state so images used only by unsaved work are not reported as orphans
+	if err := a.autosaveService.Flush(); err != nil {
+		fmt.Printf("Warning: failed to write autosave before scanning assets: %v\n", err)
+	}
+	return a.imageAssetService.ScanAssets(canvasPaths, includeRecent)
+}
+
+// CleanupOrphans moves the given images to a restorable trash batch
+func (a *App) CleanupOrphans(paths []string) (backend.TrashBatch, error) {
+	if err := a.autosaveService.Flush(); err != nil {
+		fmt.Printf("Warning: failed to write autosave before cleaning up assets: %v\n", err)
+	}
+	return a.imageAssetService.CleanupOrphans(paths)
+}
+
+// ListTrash returns the trash batches created by CleanupOrphans
+func (a *App) ListTrash() ([]backend.TrashBatch, error) {
+	return a.imageAssetService.ListTrash()
+}
+
+// RestoreTrash moves the files of a trash batch back to their original paths
+func (a *App) RestoreTrash(batchID string) error {
+	return a.imageAssetService.RestoreTrash(batchID)
+}
+
+// PurgeTrash permanently deletes a trash batch
+func (a *App) PurgeTrash(batchID string) error {
+	return a.imageAssetService.PurgeTrash(batchID)
+}
+
+// ListImages returns a filtered page of the images in the download directory
+func (a *App) ListImages(filter backend.ImageListFilter, page backend.ImageListPage) (backend.ImageListResult, error) {
+	return a.imageAssetService.ListImages(filter, page)
+}
+
+// TransformImage applies crop, rotate, flip, resize and convert operations and saves the result as a new image
+func (a *App) TransformImage(src string, ops []backend.ImageOperation) (string, error) {
+	return a.imageAssetService.TransformImage(src, ops)
+}
+
+// ExportBundle saves the canvas with all referenced images as a portable .fmcanvas file
+func (a *App) ExportBundle(c canvas.Canvas) (backend.ExportBundleResult, error) {
+	return a.fileService.ExportBundle(c)
+}
+
+// ImportBundle opens a .fmcanvas file, stores its images and returns the canvas
+func (a *App) ImportBundle() (backend.LoadCanvasResult, error) {
+	return a.fileService.ImportBundle()
+}
+
+// ImportMarkdownFolder reads a folder of Markdown notes as a linked, laid-out canvas; an empty dir opens a folder dialog
+func (a *App) ImportMarkdownFolder(dir string) (backend.LoadCanvasResult, error) {
+	return a.fileService.ImportMarkdownFolder(dir)
+}
+
+// PushCanvasState hands the current canvas to the autosave service
+func (a *App) PushCanvasState(c canvas.Canvas, sourcePath string) error {
+	return a.autosaveService.PushCanvasState(c, sourcePath)
+}
+
+// GetRecoverableSessions returns unsaved work left by previous runs of the app
+func (a *App) GetRecoverableSessions() ([]backend.RecoverableSession, error) {
+	return a.autosaveService.GetRecoverableSessions()
+}
+
+// RestoreSession returns the canvas of a recoverable session
+func (a *App) RestoreSession(id string) (backend.LoadCanvasResult, error) {
+	result, err := a.autosaveService.RestoreSession(id)
+	if err == nil {
+		// Saving the recovered work goes back to the file it was edited from
+		a.fileService.SetCurrentCanvasPath(result.Path)
+	}
+	return result, err
+}
+
+// DiscardSession deletes a recoverable session
+func (a *App) DiscardSession(id string) error {
+	return a.autosaveService.DiscardSession(id)
+}
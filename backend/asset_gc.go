@@ -0,0 +1,528 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// trashDirName is the directory (relative to the download path) holding orphans moved by CleanupOrphans
+const trashDirName = "Trash"
+
+// trashManifestName is the file inside each trash batch listing the original paths
+const trashManifestName = "manifest.json"
+
+// AssetFileInfo describes an image file in the download directory
+type AssetFileInfo struct {
+	Path    string `json:"path"` // Relative path from the download directory (forward slashes)
+	Size    int64  `json:"size"`
+	ModTime string `json:"modTime"` // RFC3339 timestamp
+}
+
+// MissingAsset is an image referenced by a canvas that does not exist on disk
+type MissingAsset struct {
+	Path   string `json:"path"`
+	Canvas string `json:"canvas"` // Canvas file that references the image
+}
+
+// AssetReport is the result of ScanAssets
+type AssetReport struct {
+	Canvases     []string        `json:"canvases"` // Canvas files that were scanned
+	Referenced   []AssetFileInfo `json:"referenced"`
+	Orphaned     []AssetFileInfo `json:"orphaned"`
+	Missing      []MissingAsset  `json:"missing"`
+	TotalSize    int64           `json:"totalSize"`    // Size of all image files in the download directory
+	OrphanedSize int64           `json:"orphanedSize"` // Size of the orphaned files
+	Warnings     []string        `json:"warnings"`     // Canvas files that could not be read
+}
+
+// TrashBatch is a set of files moved to the trash by one CleanupOrphans call
+type TrashBatch struct {
+	ID        string   `json:"id"`
+	CreatedAt string   `json:"createdAt"` // RFC3339 timestamp
+	Paths     []string `json:"paths"`     // Original relative paths of the images
+	Size      int64    `json:"size"`
+}
+
+// ScanAssets compares the images in the download directory with the images referenced by the given canvas files,
+// plus the recent canvases when includeRecent is set.
+// Images in autosave recovery snapshots and in the open canvas and its saved versions count as referenced,
+// as do reference images recorded in the provenance of a referenced image.
+// Thumbnails, the trash, the asset index and provenance sidecars are not reported.
+func (s *ImageAssetService) ScanAssets(canvasPaths []string, includeRecent bool) (AssetReport, error) {
+	report := AssetReport{
+		Canvases:   []string{},
+		Referenced: []AssetFileInfo{},
+		Orphaned:   []AssetFileInfo{},
+		Missing:    []MissingAsset{},
+		Warnings:   []string{},
+	}
+
+	downloadPath, err := s.configService.ResolveDownloadPath()
+	if err != nil {
+		return report, fmt.Errorf("failed to resolve download path: %w", err)
+	}
+
+	files, err := listImageFiles(downloadPath)
+	if err != nil {
+		return report, err
+	}
+
+	if includeRecent {
+		for _, recent := range s.configService.RecentCanvases() {
+			if !containsString(canvasPaths, recent) {
+				canvasPaths = append(canvasPaths, recent)
+			}
+		}
+	}
+
+	// Collect references from the canvases
+	referenced := map[string]bool{}
+	for _, canvasPath := range canvasPaths {
+		srcs, err := readCanvasImageSources(canvasPath)
+		if err != nil {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%s: %v", canvasPath, err))
+			continue
+		}
+		report.Canvases = append(report.Canvases, canvasPath)
+
+		for _, src := range srcs {
+			key := normalizeAssetPath(src)
+			if referenced[key] {
+				continue
+			}
+			referenced[key] = true
+
+			if _, ok := files[key]; !ok {
+				// Legacy paths may still resolve relative to the executable
+				if filePath, err := s.configService.ResolveImagePath(src); err != nil || !fileExists(filePath) {
+					report.Missing = append(report.Missing, MissingAsset{Path: src, Canvas: canvasPath})
+				}
+			}
+		}
+	}
+
+	// Images of saved versions must be kept so RestoreCanvasVersion does not bring back broken nodes
+	for _, canvasPath := range report.Canvases {
+		ids, err := listVersionIDs(canvasPath)
+		if err != nil {
+			continue
+		}
+		for _, id := range ids {
+			c, _, err := readCanvasVersion(canvasPath, id)
+			if err != nil {
+				continue
+			}
+			for _, src := range c.ImageSources() {
+				referenced[normalizeAssetPath(src)] = true
+			}
+		}
+	}
+
+	for key := range s.snapshotReferences() {
+		referenced[key] = true
+	}
+	s.addProvenanceReferences(referenced)
+
+	for key, info := range files {
+		report.TotalSize += info.Size
+		if referenced[key] {
+			report.Referenced = append(report.Referenced, info)
+		} else {
+			report.Orphaned = append(report.Orphaned, info)
+			report.OrphanedSize += info.Size
+		}
+	}
+
+	sortAssetFiles(report.Referenced)
+	sortAssetFiles(report.Orphaned)
+	return report, nil
+}
+
+// CleanupOrphans moves the given images (and their provenance sidecars) into a new trash batch.
+// Paths are relative to the download directory, typically taken from AssetReport.Orphaned.
+// Images still used by a recovery snapshot or by the open canvas and its saved versions are skipped,
+// as those may have appeared since the scan.
+func (s *ImageAssetService) CleanupOrphans(paths []string) (TrashBatch, error) {
+	downloadPath, err := s.configService.ResolveDownloadPath()
+	if err != nil {
+		return TrashBatch{}, fmt.Errorf("failed to resolve download path: %w", err)
+	}
+
+	protected := s.snapshotReferences()
+	s.addProvenanceReferences(protected)
+
+	now := time.Now()
+	batch := TrashBatch{
+		ID:        now.Format("20060102_150405.000000000"),
+		CreatedAt: now.Format(time.RFC3339),
+		Paths:     []string{},
+	}
+	batchDir := filepath.Join(downloadPath, trashDirName, batch.ID)
+
+	for _, src := range paths {
+		key := normalizeAssetPath(src)
+		if isReservedAssetPath(key) {
+			return batch, fmt.Errorf("cannot move %s to the trash", src)
+		}
+		if protected[key] {
+			continue
+		}
+
+		filePath, err := s.configService.ResolveContainedImagePath(key)
+		if err != nil {
+			return batch, err
+		}
+		info, err := os.Stat(filePath)
+		if err != nil {
+			return batch, fmt.Errorf("failed to stat %s: %w", src, err)
+		}
+
+		dest := filepath.Join(batchDir, filepath.FromSlash(key))
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return batch, fmt.Errorf("failed to create trash directory: %w", err)
+		}
+		if err := os.Rename(filePath, dest); err != nil {
+			return batch, fmt.Errorf("failed to move %s to the trash: %w", src, err)
+		}
+		batch.Paths = append(batch.Paths, key)
+		batch.Size += info.Size()
+
+		// The sidecar travels with its image so a restore brings the provenance back
+		sidecar := provenanceSidecarPath(filePath)
+		if fileExists(sidecar) {
+			if err := os.Rename(sidecar, provenanceSidecarPath(dest)); err != nil {
+				fmt.Printf("Warning: failed to move provenance of %s to the trash: %v\n", src, err)
+			}
+		}
+
+		// Write the manifest after every move so a partial batch can still be restored
+		if err := writeTrashManifest(batchDir, &batch); err != nil {
+			return batch, err
+		}
+	}
+
+	return batch, nil
+}
+
+// SetOpenCanvasSource sets the function reporting the canvas file being edited,
+// whose images and saved versions are kept by ScanAssets and CleanupOrphans
+func (s *ImageAssetService) SetOpenCanvasSource(openCanvas func() string) {
+	s.openCanvas = openCanvas
+}
+
+// snapshotReferences returns the images used by canvases that are not regular canvas files:
+// autosave recovery snapshots, and the open canvas with its saved versions.
+// Restoring either must not bring back broken image nodes.
+func (s *ImageAssetService) snapshotReferences() map[string]bool {
+	referenced := map[string]bool{}
+
+	recoveryDir := filepath.Join(s.configService.ConfigDir(), recoveryDirName)
+	if entries, err := os.ReadDir(recoveryDir); err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(recoveryDir, entry.Name()))
+			if err != nil {
+				continue
+			}
+			var snapshot RecoverySnapshot
+			if err := json.Unmarshal(data, &snapshot); err != nil || snapshot.Canvas == nil {
+				continue
+			}
+			for _, src := range snapshot.Canvas.ImageSources() {
+				referenced[normalizeAssetPath(src)] = true
+			}
+		}
+	}
+
+	if s.openCanvas == nil {
+		return referenced
+	}
+	canvasPath := s.openCanvas()
+	if canvasPath == "" {
+		return referenced
+	}
+	if srcs, err := readCanvasImageSources(canvasPath); err == nil {
+		for _, src := range srcs {
+			referenced[normalizeAssetPath(src)] = true
+		}
+	}
+	ids, err := listVersionIDs(canvasPath)
+	if err != nil {
+		return referenced
+	}
+	for _, id := range ids {
+		c, _, err := readCanvasVersion(canvasPath, id)
+		if err != nil {
+			continue
+		}
+		for _, src := range c.ImageSources() {
+			referenced[normalizeAssetPath(src)] = true
+		}
+	}
+	return referenced
+}
+
+// addProvenanceReferences marks the ancestors of edited images and the reference images recorded
+// in the provenance of referenced images and their ancestors, which must be kept for RegenerateImage
+func (s *ImageAssetService) addProvenanceReferences(referenced map[string]bool) {
+	pending := make([]string, 0, len(referenced))
+	for key := range referenced {
+		pending = append(pending, key)
+	}
+	visited := make(map[string]bool, len(pending))
+	for len(pending) > 0 {
+		key := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if visited[key] {
+			continue
+		}
+		visited[key] = true
+
+		prov, err := readImageProvenance(s.configService, key)
+		if err != nil {
+			continue
+		}
+		for _, ref := range prov.RefImages {
+			referenced[normalizeAssetPath(ref)] = true
+		}
+		if prov.Parent != "" {
+			parent := normalizeAssetPath(prov.Parent)
+			referenced[parent] = true
+			pending = append(pending, parent)
+		}
+	}
+}
+
+// ListTrash returns the trash batches, newest first
+func (s *ImageAssetService) ListTrash() ([]TrashBatch, error) {
+	downloadPath, err := s.configService.ResolveDownloadPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve download path: %w", err)
+	}
+
+	trashDir := filepath.Join(downloadPath, trashDirName)
+	entries, err := os.ReadDir(trashDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []TrashBatch{}, nil
+		}
+		return nil, fmt.Errorf("failed to read trash directory: %w", err)
+	}
+
+	batches := []TrashBatch{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		batch, err := readTrashManifest(filepath.Join(trashDir, entry.Name()))
+		if err != nil {
+			fmt.Printf("Warning: skipping trash batch %s: %v\n", entry.Name(), err)
+			continue
+		}
+		batches = append(batches, *batch)
+	}
+
+	sort.Slice(batches, func(i, j int) bool {
+		return batches[i].ID > batches[j].ID
+	})
+	return batches, nil
+}
+
+// RestoreTrash moves the files of a trash batch back to their original paths.
+// Files whose original path is occupied again are left in the trash and reported in the error.
+func (s *ImageAssetService) RestoreTrash(batchID string) error {
+	batchDir, err := s.trashBatchDir(batchID)
+	if err != nil {
+		return err
+	}
+	batch, err := readTrashManifest(batchDir)
+	if err != nil {
+		return err
+	}
+
+	downloadPath, err := s.configService.ResolveDownloadPath()
+	if err != nil {
+		return fmt.Errorf("failed to resolve download path: %w", err)
+	}
+
+	var conflicts []string
+	for _, key := range batch.Paths {
+		if strings.Contains(key, "..") {
+			return fmt.Errorf("path traversal is not allowed")
+		}
+
+		src := filepath.Join(batchDir, filepath.FromSlash(key))
+		dest := filepath.Join(downloadPath, filepath.FromSlash(key))
+		if fileExists(dest) {
+			conflicts = append(conflicts, key)
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+		if err := os.Rename(src, dest); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", key, err)
+		}
+		if fileExists(provenanceSidecarPath(src)) {
+			if err := os.Rename(provenanceSidecarPath(src), provenanceSidecarPath(dest)); err != nil {
+				fmt.Printf("Warning: failed to restore provenance of %s: %v\n", key, err)
+			}
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("some files were not restored because their original path exists: %s", strings.Join(conflicts, ", "))
+	}
+	if err := os.RemoveAll(batchDir); err != nil {
+		return fmt.Errorf("failed to remove trash batch: %w", err)
+	}
+	return nil
+}
+
+// PurgeTrash permanently deletes a trash batch
+func (s *ImageAssetService) PurgeTrash(batchID string) error {
+	batchDir, err := s.trashBatchDir(batchID)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(batchDir); err != nil {
+		return fmt.Errorf("failed to delete trash batch: %w", err)
+	}
+	return nil
+}
+
+// trashBatchDir returns the directory of a trash batch, rejecting IDs that could escape the trash
+func (s *ImageAssetService) trashBatchDir(batchID string) (string, error) {
+	if batchID == "" || strings.ContainsAny(batchID, `/\`) || strings.Contains(batchID, "..") {
+		return "", fmt.Errorf("invalid trash batch: %s", batchID)
+	}
+
+	downloadPath, err := s.configService.ResolveDownloadPath()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve download path: %w", err)
+	}
+
+	batchDir := filepath.Join(downloadPath, trashDirName, batchID)
+	if _, err := os.Stat(batchDir); err != nil {
+		return "", fmt.Errorf("trash batch not found: %s", batchID)
+	}
+	return batchDir, nil
+}
+
+func writeTrashManifest(batchDir string, batch *TrashBatch) error {
+	data, err := json.MarshalIndent(batch, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal trash manifest: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(batchDir, trashManifestName), data, 0644); err != nil {
+		return fmt.Errorf("failed to write trash manifest: %w", err)
+	}
+	return nil
+}
+
+func readTrashManifest(batchDir string) (*TrashBatch, error) {
+	data, err := os.ReadFile(filepath.Join(batchDir, trashManifestName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trash manifest: %w", err)
+	}
+
+	var batch TrashBatch
+	if err := json.Unmarshal(data, &batch); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal trash manifest: %w", err)
+	}
+	return &batch, nil
+}
+
+// listImageFiles returns the image files under the download directory keyed by relative path,
+// skipping the thumbnail cache and the trash
+func listImageFiles(downloadPath string) (map[string]AssetFileInfo, error) {
+	files := map[string]AssetFileInfo{}
+
+	err := filepath.WalkDir(downloadPath, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && p == downloadPath {
+				return filepath.SkipDir
+			}
+			return err
+		}
+
+		rel, err := filepath.Rel(downloadPath, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if p != downloadPath && isReservedAssetPath(key) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isImageExtension(filepath.Ext(p)) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files[key] = AssetFileInfo{
+			Path:    key,
+			Size:    info.Size(),
+			ModTime: info.ModTime().Format(time.RFC3339),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan download directory: %w", err)
+	}
+	return files, nil
+}
+
+// readCanvasImageSources returns the src of every image node in a canvas file
+func readCanvasImageSources(canvasPath string) ([]string, error) {
+	c, _, err := readCanvasFile(canvasPath)
+	if err != nil {
+		return nil, err
+	}
+	return c.ImageSources(), nil
+}
+
+// normalizeAssetPath turns an image src into the relative key used by listImageFiles
+func normalizeAssetPath(src string) string {
+	return strings.TrimPrefix(path.Clean(filepath.ToSlash(src)), "./")
+}
+
+// isReservedAssetPath reports whether a relative path lies in a directory managed by the app itself
+func isReservedAssetPath(key string) bool {
+	top := strings.SplitN(key, "/", 2)[0]
+	return top == thumbDirName || top == trashDirName
+}
+
+func isImageExtension(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".png", ".jpg", ".jpeg", ".webp", ".gif":
+		return true
+	}
+	return false
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func sortAssetFiles(files []AssetFileInfo) {
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+}
@@ -0,0 +1,122 @@
+package backend
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// assetURLPrefix is the URL path prefix under which the download directory is served
+const assetURLPrefix = "/assets/"
+
+// AssetHandler serves images from the download directory through the Wails AssetServer,
+// so the frontend can load them by URL instead of passing Data URLs over IPC.
+// Files without an image extension, such as provenance sidecars, are not served.
+type AssetHandler struct {
+	configService *ConfigService
+	hashCache     *fileHashCache
+}
+
+// NewAssetHandler creates a new instance of AssetHandler
+func NewAssetHandler(configService *ConfigService) *AssetHandler {
+	return &AssetHandler{
+		configService: configService,
+		hashCache:     newFileHashCache(),
+	}
+}
+
+// ServeHTTP implements http.Handler for "/assets/<relative path>" requests.
+// Conditional requests (ETag / If-None-Match) and Range requests are handled by http.ServeContent.
+func (h *AssetHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if !strings.HasPrefix(r.URL.Path, assetURLPrefix) {
+		http.NotFound(w, r)
+		return
+	}
+	src := strings.TrimPrefix(r.URL.Path, assetURLPrefix)
+
+	// Only images are served; provenance sidecars and other files stay private to the backend
+	if !isImageExtension(filepath.Ext(src)) {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Same traversal checks as ExportImage
+	filePath, err := h.configService.ResolveContainedImagePath(src)
+	if err != nil {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+
+	contentType, err := detectContentType(file, filePath)
+	if err != nil {
+		http.Error(w, "failed to read file", http.StatusInternalServerError)
+		return
+	}
+
+	hash, err := h.hashCache.hash(filePath)
+	if err != nil {
+		http.Error(w, "failed to read file", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("ETag", fmt.Sprintf(`"%s"`, hash))
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	http.ServeContent(w, r, info.Name(), info.ModTime(), file)
+}
+
+// detectContentType sniffs the content type from the file header, falling back to the extension
+// when the content is not recognized. The file offset is reset to the start.
+func detectContentType(file io.ReadSeeker, filePath string) (string, error) {
+	header := make([]byte, 512)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	contentType := http.DetectContentType(header[:n])
+	if contentType == "application/octet-stream" || strings.HasPrefix(contentType, "text/plain") {
+		if byExt := mime.TypeByExtension(filepath.Ext(filePath)); byExt != "" {
+			contentType = byExt
+		}
+	}
+	return contentType, nil
+}
+
+// assetURL returns the AssetServer URL for a relative image path
+func assetURL(src string) string {
+	segments := strings.Split(filepath.ToSlash(src), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return assetURLPrefix + strings.Join(segments, "/")
+}
@@ -0,0 +1,228 @@
+package backend
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// assetDirName is the directory (relative to the download path) holding content-addressed assets
+const assetDirName = "Assets"
+
+// AssetIndexEntry describes a stored asset and the original names it was saved under
+type AssetIndexEntry struct {
+	Path      string   `json:"path"` // Relative path from the download directory
+	Names     []string `json:"names"`
+	Size      int64    `json:"size"`
+	CreatedAt string   `json:"createdAt"` // RFC3339 timestamp
+}
+
+// AssetStore stores image assets under the download directory, named by the SHA-256 of their content.
+// Identical content is stored only once; the original names are kept in "Assets/index.json".
+type AssetStore struct {
+	configService *ConfigService
+	mu            sync.Mutex
+}
+
+// NewAssetStore creates a new instance of AssetStore
+func NewAssetStore(configService *ConfigService) *AssetStore {
+	return &AssetStore{
+		configService: configService,
+	}
+}
+
+// hashBytes returns the hex encoded SHA-256 of data
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Put stores data under its content hash and returns the relative path (with forward slashes).
+// ext is the file extension without dot; originalName is recorded in the index for display and export.
+func (s *AssetStore) Put(data []byte, ext string, originalName string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	assetDir, err := s.assetDir()
+	if err != nil {
+		return "", err
+	}
+
+	hash := hashBytes(data)
+	filename := fmt.Sprintf("%s.%s", hash, strings.TrimPrefix(ext, "."))
+	fullPath := filepath.Join(assetDir, filename)
+	relPath := assetDirName + "/" + filename
+
+	// Deduplicate: only write when the content is not stored intact yet.
+	// A file with the right name but other content (e.g. truncated by a crash) is replaced.
+	if !assetFileMatches(fullPath, hash, int64(len(data))) {
+		if err := writeFileAtomic(fullPath, data, 0644); err != nil {
+			return "", fmt.Errorf("failed to save asset: %w", err)
+		}
+	}
+
+	index, err := s.loadIndex(assetDir)
+	if err != nil {
+		// A broken index must not block saving; it is rebuilt from scratch
+		fmt.Printf("Warning: failed to load asset index: %v\n", err)
+		index = map[string]*AssetIndexEntry{}
+	}
+
+	entry, ok := index[hash]
+	if !ok {
+		entry = &AssetIndexEntry{
+			Path:      relPath,
+			Size:      int64(len(data)),
+			CreatedAt: time.Now().Format(time.RFC3339),
+		}
+		index[hash] = entry
+	}
+	if originalName != "" && !containsString(entry.Names, originalName) {
+		entry.Names = append(entry.Names, originalName)
+	}
+
+	if err := s.saveIndex(assetDir, index); err != nil {
+		fmt.Printf("Warning: failed to save asset index: %v\n", err)
+	}
+
+	return relPath, nil
+}
+
+// assetFileMatches reports whether the file at path exists with the given size and content hash
+func assetFileMatches(path string, hash string, size int64) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() != size {
+		return false
+	}
+	existing, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	return hashBytes(existing) == hash
+}
+
+// FriendlyName returns the first original name recorded for a stored asset,
+// falling back to the base name of the relative path.
+func (s *AssetStore) FriendlyName(relPath string) string {
+	base := filepath.Base(filepath.FromSlash(relPath))
+	hash := strings.TrimSuffix(base, filepath.Ext(base))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	assetDir, err := s.assetDir()
+	if err != nil {
+		return base
+	}
+	index, err := s.loadIndex(assetDir)
+	if err != nil {
+		return base
+	}
+	if entry, ok := index[hash]; ok && len(entry.Names) > 0 {
+		return entry.Names[0]
+	}
+	return base
+}
+
+// assetDir returns the absolute asset directory, creating it if needed
+func (s *AssetStore) assetDir() (string, error) {
+	downloadPath, err := s.configService.ResolveDownloadPath()
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve download path: %w", err)
+	}
+
+	assetDir := filepath.Join(downloadPath, assetDirName)
+	if err := os.MkdirAll(assetDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create asset directory: %w", err)
+	}
+	return assetDir, nil
+}
+
+func (s *AssetStore) loadIndex(assetDir string) (map[string]*AssetIndexEntry, error) {
+	index := map[string]*AssetIndexEntry{}
+
+	data, err := os.ReadFile(filepath.Join(assetDir, "index.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return index, nil
+		}
+		return nil, fmt.Errorf("failed to read asset index: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal asset index: %w", err)
+	}
+	return index, nil
+}
+
+func (s *AssetStore) saveIndex(assetDir string, index map[string]*AssetIndexEntry) error {
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal asset index: %w", err)
+	}
+
+	if err := writeFileAtomic(filepath.Join(assetDir, "index.json"), data, 0644); err != nil {
+		return fmt.Errorf("failed to write asset index: %w", err)
+	}
+	return nil
+}
+
+// fileHashCache memoizes content hashes of files by path, size and modification time,
+// so repeated lookups of unchanged files do not re-read them.
+type fileHashCache struct {
+	mu      sync.Mutex
+	entries map[string]fileHashEntry
+}
+
+type fileHashEntry struct {
+	size    int64
+	modTime time.Time
+	hash    string
+}
+
+func newFileHashCache() *fileHashCache {
+	return &fileHashCache{
+		entries: map[string]fileHashEntry{},
+	}
+}
+
+// hash returns the hex encoded SHA-256 of the file content
+func (c *fileHashCache) hash(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat file: %w", err)
+	}
+
+	c.mu.Lock()
+	entry, ok := c.entries[path]
+	c.mu.Unlock()
+	if ok && entry.size == info.Size() && entry.modTime.Equal(info.ModTime()) {
+		return entry.hash, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	hash := hashBytes(data)
+
+	c.mu.Lock()
+	c.entries[path] = fileHashEntry{size: info.Size(), modTime: info.ModTime(), hash: hash}
+	c.mu.Unlock()
+	return hash, nil
+}
+
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
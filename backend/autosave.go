@@ -0,0 +1,302 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"fm-doc-canvas/backend/canvas"
+)
+
+const (
+	// recoveryDirName is the directory (relative to the config directory) holding autosave snapshots
+	recoveryDirName = "recovery"
+
+	// autosaveInterval is the minimum time between two snapshots of a session
+	autosaveInterval = 5 * time.Second
+
+	// maxRecoverySessions and maxRecoveryAge limit how many old sessions are kept for recovery
+	maxRecoverySessions = 10
+	maxRecoveryAge      = 7 * 24 * time.Hour
+)
+
+// RecoverySnapshot is the content of a recovery file
+type RecoverySnapshot struct {
+	SessionID  string         `json:"sessionId"`
+	SavedAt    string         `json:"savedAt"`    // RFC3339 timestamp
+	SourcePath string         `json:"sourcePath"` // Canvas file being edited, empty for a new canvas
+	Canvas     *canvas.Canvas `json:"canvas"`
+}
+
+// RecoverableSession describes unsaved work left by a previous run
+type RecoverableSession struct {
+	ID         string `json:"id"`
+	SavedAt    string `json:"savedAt"` // RFC3339 timestamp of the last snapshot
+	SourcePath string `json:"sourcePath"`
+	NodeCount  int    `json:"nodeCount"`
+}
+
+// AutosaveService writes throttled snapshots of the canvas state pushed by the frontend,
+// so unsaved work survives a crash or closing the app without saving
+type AutosaveService struct {
+	configService *ConfigService
+	sessionID     string
+
+	mu         sync.Mutex
+	pending    *RecoverySnapshot // Latest state not yet written
+	lastWrite  time.Time
+	timer      *time.Timer
+	savedHash  string // Hash of the canvas as last saved or loaded
+	hasWritten bool   // Whether a snapshot of this session is on disk
+}
+
+// NewAutosaveService creates a new instance of AutosaveService for this run of the app
+func NewAutosaveService(configService *ConfigService) *AutosaveService {
+	return &AutosaveService{
+		configService: configService,
+		sessionID:     time.Now().Format("20060102_150405.000"),
+	}
+}
+
+// PushCanvasState records the current canvas. A snapshot is written at most once per autosaveInterval;
+// pushes in between only replace the pending state, which is written when the interval has passed.
+// The state is kept even when it is not valid for saving, e.g. an edge whose node was just deleted;
+// RestoreSession repairs it like a loaded file.
+func (s *AutosaveService) PushCanvasState(c canvas.Canvas, sourcePath string) error {
+	data, _, err := canvas.Marshal(&c)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Nothing to recover while the canvas matches the file; an invalid canvas never does
+	if err == nil && hashBytes(data) == s.savedHash {
+		s.pending = nil
+		s.removeSnapshotLocked()
+		return nil
+	}
+
+	s.pending = &RecoverySnapshot{
+		SessionID:  s.sessionID,
+		SourcePath: sourcePath,
+		Canvas:     &c,
+	}
+
+	wait := autosaveInterval - time.Since(s.lastWrite)
+	if wait <= 0 {
+		return s.flushLocked()
+	}
+	if s.timer == nil {
+		s.timer = time.AfterFunc(wait, func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.timer = nil
+			if err := s.flushLocked(); err != nil {
+				fmt.Printf("Warning: autosave failed: %v\n", err)
+			}
+		})
+	}
+	return nil
+}
+
+// MarkSaved records that the canvas was saved to or loaded from a file, so the current state
+// needs no recovery. The session's snapshot is removed.
+func (s *AutosaveService) MarkSaved(c *canvas.Canvas) {
+	data, _, err := canvas.Marshal(c)
+	if err != nil {
+		fmt.Printf("Warning: failed to marshal saved canvas: %v\n", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.savedHash = hashBytes(data)
+	s.pending = nil
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	s.removeSnapshotLocked()
+}
+
+// Flush writes the pending state immediately, e.g. when the app shuts down
+func (s *AutosaveService) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	return s.flushLocked()
+}
+
+// GetRecoverableSessions returns the sessions of previous runs that left unsaved work, newest first.
+// Sessions beyond the retention limits are deleted.
+func (s *AutosaveService) GetRecoverableSessions() ([]RecoverableSession, error) {
+	recoveryDir := s.recoveryDir()
+	entries, err := os.ReadDir(recoveryDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []RecoverableSession{}, nil
+		}
+		return nil, fmt.Errorf("failed to read recovery directory: %w", err)
+	}
+
+	sessions := []RecoverableSession{}
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if entry.IsDir() || !ok || id == s.sessionID {
+			continue
+		}
+
+		snapshot, err := s.readSnapshot(id)
+		if err != nil {
+			fmt.Printf("Warning: skipping recovery file %s: %v\n", entry.Name(), err)
+			continue
+		}
+		nodeCount := 0
+		if snapshot.Canvas != nil {
+			nodeCount = len(snapshot.Canvas.Nodes)
+		}
+		sessions = append(sessions, RecoverableSession{
+			ID:         id,
+			SavedAt:    snapshot.SavedAt,
+			SourcePath: snapshot.SourcePath,
+			NodeCount:  nodeCount,
+		})
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].SavedAt > sessions[j].SavedAt
+	})
+
+	// Apply retention: keep the newest sessions that are not too old
+	kept := sessions[:0]
+	for i, session := range sessions {
+		savedAt, err := time.Parse(time.RFC3339, session.SavedAt)
+		if i >= maxRecoverySessions || err != nil || time.Since(savedAt) > maxRecoveryAge {
+			if err := s.DiscardSession(session.ID); err != nil {
+				fmt.Printf("Warning: failed to delete old recovery session %s: %v\n", session.ID, err)
+			}
+			continue
+		}
+		kept = append(kept, session)
+	}
+	return kept, nil
+}
+
+// RestoreSession returns the canvas of a recoverable session.
+// The snapshot is deleted; the restored state is autosaved again by the current session.
+func (s *AutosaveService) RestoreSession(id string) (LoadCanvasResult, error) {
+	snapshot, err := s.readSnapshot(id)
+	if err != nil {
+		return LoadCanvasResult{}, err
+	}
+	if snapshot.Canvas == nil {
+		return LoadCanvasResult{}, fmt.Errorf("recovery session %s has no canvas", id)
+	}
+
+	// Re-parse so snapshots from older versions are migrated and validated like files
+	data, err := json.Marshal(snapshot.Canvas)
+	if err != nil {
+		return LoadCanvasResult{}, fmt.Errorf("failed to marshal recovered canvas: %w", err)
+	}
+	c, warnings, err := canvas.Parse(data)
+	if err != nil {
+		return LoadCanvasResult{}, err
+	}
+
+	if err := s.DiscardSession(id); err != nil {
+		fmt.Printf("Warning: failed to delete recovery session %s: %v\n", id, err)
+	}
+	return LoadCanvasResult{Path: snapshot.SourcePath, Canvas: c, Warnings: warnings}, nil
+}
+
+// DiscardSession deletes the snapshot of a recoverable session
+func (s *AutosaveService) DiscardSession(id string) error {
+	snapshotPath, err := s.snapshotPath(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(snapshotPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete recovery session: %w", err)
+	}
+	return nil
+}
+
+// flushLocked writes the pending snapshot; s.mu must be held
+func (s *AutosaveService) flushLocked() error {
+	if s.pending == nil {
+		return nil
+	}
+	snapshot := s.pending
+	snapshot.SavedAt = time.Now().Format(time.RFC3339)
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to marshal recovery snapshot: %w", err)
+	}
+
+	if err := os.MkdirAll(s.recoveryDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create recovery directory: %w", err)
+	}
+	snapshotPath, err := s.snapshotPath(s.sessionID)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(snapshotPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write recovery snapshot: %w", err)
+	}
+
+	s.pending = nil
+	s.lastWrite = time.Now()
+	s.hasWritten = true
+	return nil
+}
+
+// removeSnapshotLocked deletes the snapshot of the current session; s.mu must be held
+func (s *AutosaveService) removeSnapshotLocked() {
+	if !s.hasWritten {
+		return
+	}
+	if err := s.DiscardSession(s.sessionID); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+		return
+	}
+	s.hasWritten = false
+}
+
+func (s *AutosaveService) readSnapshot(id string) (*RecoverySnapshot, error) {
+	snapshotPath, err := s.snapshotPath(id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(snapshotPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recovery snapshot: %w", err)
+	}
+
+	var snapshot RecoverySnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal recovery snapshot: %w", err)
+	}
+	return &snapshot, nil
+}
+
+func (s *AutosaveService) recoveryDir() string {
+	return filepath.Join(s.configService.ConfigDir(), recoveryDirName)
+}
+
+// snapshotPath returns the recovery file of a session, rejecting IDs that could escape the recovery directory
+func (s *AutosaveService) snapshotPath(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.Contains(id, "..") {
+		return "", fmt.Errorf("invalid recovery session: %s", id)
+	}
+	return filepath.Join(s.recoveryDir(), id+".json"), nil
+}
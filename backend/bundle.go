@@ -0,0 +1,367 @@
+package backend
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"fm-doc-canvas/backend/canvas"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+const (
+	// bundleVersion is the manifest version written by ExportBundle
+	bundleVersion = 1
+
+	bundleManifestName = "manifest.json"
+	bundleCanvasName   = "canvas.json"
+	bundleImageDir     = "images/"
+
+	// maxBundleImageBytes limits the size of a single image unpacked from a bundle
+	maxBundleImageBytes = 100 << 20
+	// maxBundleCanvasBytes limits the size of the canvas JSON in a bundle
+	maxBundleCanvasBytes = 50 << 20
+)
+
+// BundleManifest lists the contents of a .fmcanvas bundle
+type BundleManifest struct {
+	Version   int           `json:"version"`
+	CreatedAt string        `json:"createdAt"` // RFC3339 timestamp
+	Canvas    string        `json:"canvas"`    // Name of the canvas JSON in the zip
+	Images    []BundleImage `json:"images"`
+}
+
+// BundleImage is an image stored in a bundle
+type BundleImage struct {
+	File   string `json:"file"`   // Name in the zip, referenced by the src of the bundled canvas
+	Source string `json:"source"` // src on the exporting machine
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// ExportBundleResult is the outcome of ExportBundle. Path is empty when the user cancelled the dialog.
+type ExportBundleResult struct {
+	Path    string   `json:"path"`
+	Images  int      `json:"images"`  // Number of images stored in the bundle
+	Missing []string `json:"missing"` // Image sources that could not be read and were left out
+}
+
+// ExportBundle writes the canvas and every image it references into a single zip-based .fmcanvas file.
+// Image sources in the bundled canvas point into the zip, so the bundle does not depend on the download directory.
+func (s *FileService) ExportBundle(c canvas.Canvas) (ExportBundleResult, error) {
+	if s.ctx == nil {
+		return ExportBundleResult{}, fmt.Errorf("context not initialized")
+	}
+
+	options := runtime.SaveDialogOptions{
+		Title:           "Export Bundle",
+		DefaultFilename: "canvas.fmcanvas",
+		Filters: []runtime.FileFilter{
+			{
+				DisplayName: "Canvas Bundles (*.fmcanvas)",
+				Pattern:     "*.fmcanvas",
+			},
+		},
+	}
+
+	filePath, err := runtime.SaveFileDialog(s.ctx, options)
+	if err != nil {
+		return ExportBundleResult{}, fmt.Errorf("failed to open save dialog: %w", err)
+	}
+
+	if filePath == "" {
+		return ExportBundleResult{}, nil // User cancelled
+	}
+
+	result, err := s.writeBundle(filePath, &c)
+	if err != nil {
+		return ExportBundleResult{}, err
+	}
+	result.Path = filePath
+	return result, nil
+}
+
+// writeBundle writes the bundle to filePath; the file is only replaced once the bundle is complete
+func (s *FileService) writeBundle(filePath string, c *canvas.Canvas) (ExportBundleResult, error) {
+	result := ExportBundleResult{Missing: []string{}}
+	manifest := BundleManifest{
+		Version:   bundleVersion,
+		CreatedAt: time.Now().Format(time.RFC3339),
+		Canvas:    bundleCanvasName,
+		Images:    []BundleImage{},
+	}
+
+	tmpPath := filePath + ".tmp"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return result, fmt.Errorf("failed to create bundle: %w", err)
+	}
+	defer os.Remove(tmpPath)
+
+	zw := zip.NewWriter(out)
+	bundled := map[string]string{} // src -> file in the zip
+	for i := range c.Nodes {
+		node := &c.Nodes[i]
+		if !node.IsImage() || node.Data.Src == "" {
+			continue
+		}
+
+		if file, ok := bundled[node.Data.Src]; ok {
+			node.Data.Src = file
+			continue
+		}
+
+		// Only images inside the download directory may end up in a bundle meant for sharing
+		imagePath, err := s.configService.ResolveContainedImagePath(node.Data.Src)
+		if err != nil {
+			result.Missing = append(result.Missing, node.Data.Src)
+			continue
+		}
+		data, err := os.ReadFile(imagePath)
+		if err != nil {
+			result.Missing = append(result.Missing, node.Data.Src)
+			continue
+		}
+
+		// Images are named by content hash, so identical images are stored once
+		hash := hashBytes(data)
+		file := bundleImageDir + hash + "." + extensionForMIME(http.DetectContentType(data))
+		if !containsBundleImage(manifest.Images, file) {
+			w, err := zw.CreateHeader(&zip.FileHeader{Name: file, Method: zip.Store})
+			if err != nil {
+				out.Close()
+				return result, fmt.Errorf("failed to add image to bundle: %w", err)
+			}
+			if _, err := w.Write(data); err != nil {
+				out.Close()
+				return result, fmt.Errorf("failed to add image to bundle: %w", err)
+			}
+			manifest.Images = append(manifest.Images, BundleImage{
+				File:   file,
+				Source: node.Data.Src,
+				SHA256: hash,
+				Size:   int64(len(data)),
+			})
+		}
+
+		bundled[node.Data.Src] = file
+		node.Data.Src = file
+	}
+	result.Images = len(manifest.Images)
+
+	canvasData, _, err := canvas.Marshal(c)
+	if err != nil {
+		out.Close()
+		return result, err
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		out.Close()
+		return result, fmt.Errorf("failed to marshal bundle manifest: %w", err)
+	}
+
+	for name, data := range map[string][]byte{bundleCanvasName: canvasData, bundleManifestName: manifestData} {
+		w, err := zw.Create(name)
+		if err != nil {
+			out.Close()
+			return result, fmt.Errorf("failed to write %s to bundle: %w", name, err)
+		}
+		if _, err := w.Write(data); err != nil {
+			out.Close()
+			return result, fmt.Errorf("failed to write %s to bundle: %w", name, err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		out.Close()
+		return result, fmt.Errorf("failed to finish bundle: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		return result, fmt.Errorf("failed to finish bundle: %w", err)
+	}
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		return result, fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return result, nil
+}
+
+// ImportBundle opens a .fmcanvas bundle, stores its images in the asset store and returns the canvas
+// with image sources rewritten to the stored assets. Images already in the store are not duplicated.
+// The returned Path is empty, as the canvas has not been saved as a canvas file yet.
+func (s *FileService) ImportBundle() (LoadCanvasResult, error) {
+	if s.ctx == nil {
+		return LoadCanvasResult{}, fmt.Errorf("context not initialized")
+	}
+
+	options := runtime.OpenDialogOptions{
+		Title: "Import Bundle",
+		Filters: []runtime.FileFilter{
+			{
+				DisplayName: "Canvas Bundles (*.fmcanvas)",
+				Pattern:     "*.fmcanvas",
+			},
+		},
+	}
+
+	filePath, err := runtime.OpenFileDialog(s.ctx, options)
+	if err != nil {
+		return LoadCanvasResult{}, fmt.Errorf("failed to open file dialog: %w", err)
+	}
+
+	if filePath == "" {
+		return LoadCanvasResult{}, nil // User cancelled
+	}
+
+	c, warnings, err := s.readBundle(filePath)
+	if err != nil {
+		return LoadCanvasResult{}, err
+	}
+
+	// The bundle is not a canvas file, so the imported canvas starts without a current file
+	s.SetCurrentCanvasPath("")
+	return LoadCanvasResult{Canvas: c, Warnings: warnings}, nil
+}
+
+// readBundle unpacks a bundle. Only the files listed in the manifest are read;
+// every image must match its recorded hash.
+func (s *FileService) readBundle(filePath string) (*canvas.Canvas, []canvas.Warning, error) {
+	zr, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer zr.Close()
+
+	entries := map[string]*zip.File{}
+	for _, f := range zr.File {
+		name, err := cleanBundleEntryName(f.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		entries[name] = f
+	}
+
+	manifestData, err := readBundleEntry(entries, bundleManifestName, maxBundleCanvasBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	var manifest BundleManifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal bundle manifest: %w", err)
+	}
+	if manifest.Version > bundleVersion {
+		return nil, nil, fmt.Errorf("bundle version %d is newer than the supported version %d", manifest.Version, bundleVersion)
+	}
+
+	canvasName, err := cleanBundleEntryName(manifest.Canvas)
+	if err != nil {
+		return nil, nil, err
+	}
+	canvasData, err := readBundleEntry(entries, canvasName, maxBundleCanvasBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	c, warnings, err := canvas.Parse(canvasData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Unpack images into the asset store and remember where each one went
+	stored := map[string]string{}
+	for _, image := range manifest.Images {
+		name, err := cleanBundleEntryName(image.File)
+		if err != nil {
+			return nil, nil, err
+		}
+		data, err := readBundleEntry(entries, name, maxBundleImageBytes)
+		if err != nil {
+			return nil, nil, err
+		}
+		if hashBytes(data) != image.SHA256 {
+			return nil, nil, fmt.Errorf("image %s in bundle does not match its hash", name)
+		}
+
+		mimeType := http.DetectContentType(data)
+		if !strings.HasPrefix(mimeType, "image/") {
+			return nil, nil, fmt.Errorf("bundle entry %s is not an image", name)
+		}
+		originalName := path.Base(image.Source)
+		if image.Source == "" {
+			originalName = path.Base(name)
+		}
+		relPath, err := s.assetStore.Put(data, extensionForMIME(mimeType), originalName)
+		if err != nil {
+			return nil, nil, err
+		}
+		stored[name] = relPath
+	}
+
+	for i := range c.Nodes {
+		node := &c.Nodes[i]
+		if !node.IsImage() || node.Data.Src == "" {
+			continue
+		}
+		if relPath, ok := stored[path.Clean(node.Data.Src)]; ok {
+			node.Data.Src = relPath
+		} else {
+			warnings = append(warnings, canvas.Warning{
+				Code:    canvas.WarnMissingImageSrc,
+				Message: fmt.Sprintf("image %s of node %q is not in the bundle", node.Data.Src, node.ID),
+				NodeID:  node.ID,
+			})
+		}
+	}
+
+	return c, warnings, nil
+}
+
+// cleanBundleEntryName rejects zip entry names that could escape the extraction root (zip slip)
+func cleanBundleEntryName(name string) (string, error) {
+	clean := path.Clean(strings.ReplaceAll(name, `\`, "/"))
+	if name == "" || path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") || strings.Contains(clean, ":") {
+		return "", fmt.Errorf("invalid path in bundle: %s", name)
+	}
+	return clean, nil
+}
+
+// readBundleEntry reads a zip entry, refusing entries larger than limit
+func readBundleEntry(entries map[string]*zip.File, name string, limit int64) ([]byte, error) {
+	f, ok := entries[name]
+	if !ok {
+		return nil, fmt.Errorf("bundle is missing %s", name)
+	}
+	if f.UncompressedSize64 > uint64(limit) {
+		return nil, fmt.Errorf("%s in bundle is too large", name)
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s in bundle: %w", name, err)
+	}
+	defer rc.Close()
+
+	// The recorded size can be forged, so the read is limited as well
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s in bundle: %w", name, err)
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%s in bundle is too large", name)
+	}
+	return data, nil
+}
+
+func containsBundleImage(images []BundleImage, file string) bool {
+	for _, image := range images {
+		if image.File == file {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+// Package canvas defines the canvas file format shared with the frontend,
+// with validation and migration of older file versions.
+package canvas
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// CurrentVersion is the canvas file version written by Marshal
+const CurrentVersion = "1.1"
+
+// Node types understood by the frontend
+const (
+	TextNode  = "customNode"
+	ImageNode = "imageNode"
+)
+
+// Canvas is a canvas file (see CanvasFile in frontend/src/types)
+type Canvas struct {
+	Version  string    `json:"version"`
+	Metadata *Metadata `json:"metadata,omitempty"`
+	LLM      *LLMInfo  `json:"llm,omitempty"`
+	Nodes    []Node    `json:"nodes"`
+	Edges    []Edge    `json:"edges"`
+}
+
+// Metadata holds informational fields of a canvas file
+type Metadata struct {
+	LastOpened string `json:"lastOpened,omitempty"`
+}
+
+// LLMInfo records the LLM the canvas was created with
+type LLMInfo struct {
+	BaseURL string `json:"baseURL,omitempty"`
+	Model   string `json:"model,omitempty"`
+}
+
+// Position is the location of a node on the canvas
+type Position struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// NodeData holds the fields of both node types; only those of the node's type are written
+type NodeData struct {
+	// Text nodes
+	Content string `json:"content,omitempty"`
+	Summary string `json:"summary,omitempty"`
+
+	// Image nodes
+	Src         string `json:"src,omitempty"` // Relative path from the download directory
+	Alt         string `json:"alt,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Node is a text or image node
+type Node struct {
+	ID       string   `json:"id"`
+	Type     string   `json:"type"`
+	Position Position `json:"position"`
+	Data     NodeData `json:"data"`
+	Width    *float64 `json:"width,omitempty"`
+	Height   *float64 `json:"height,omitempty"`
+}
+
+// MarkerEnd is the arrow head of an edge
+type MarkerEnd struct {
+	Type string `json:"type"`
+}
+
+// Edge connects two nodes
+type Edge struct {
+	ID           string     `json:"id"`
+	Source       string     `json:"source"`
+	Target       string     `json:"target"`
+	SourceHandle string     `json:"sourceHandle,omitempty"`
+	TargetHandle string     `json:"targetHandle,omitempty"`
+	Type         string     `json:"type,omitempty"`
+	MarkerEnd    *MarkerEnd `json:"markerEnd,omitempty"`
+}
+
+type textNodeData struct {
+	Content string `json:"content"`
+	Summary string `json:"summary"`
+}
+
+type imageNodeData struct {
+	Src         string `json:"src"`
+	Alt         string `json:"alt,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// MarshalJSON writes only the data fields belonging to the node type,
+// so text nodes always carry content and summary and image nodes always carry src
+func (n Node) MarshalJSON() ([]byte, error) {
+	type plainNode Node
+
+	var data interface{}
+	switch n.Type {
+	case ImageNode:
+		data = imageNodeData{Src: n.Data.Src, Alt: n.Data.Alt, Description: n.Data.Description}
+	case TextNode:
+		data = textNodeData{Content: n.Data.Content, Summary: n.Data.Summary}
+	default:
+		data = n.Data
+	}
+
+	return json.Marshal(struct {
+		plainNode
+		Data interface{} `json:"data"`
+	}{plainNode(n), data})
+}
+
+// IsImage reports whether the node is an image node
+func (n *Node) IsImage() bool {
+	return n.Type == ImageNode
+}
+
+// ImageSources returns the src of every image node, in node order
+func (c *Canvas) ImageSources() []string {
+	srcs := []string{}
+	for _, node := range c.Nodes {
+		if node.IsImage() && node.Data.Src != "" {
+			srcs = append(srcs, node.Data.Src)
+		}
+	}
+	return srcs
+}
+
+// Parse decodes a canvas file of any supported version, migrates it to CurrentVersion
+// and removes what cannot be loaded. Everything migrated or removed is reported as a warning.
+func Parse(data []byte) (*Canvas, []Warning, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse canvas: %w", err)
+	}
+	if doc == nil {
+		return nil, nil, fmt.Errorf("failed to parse canvas: not a JSON object")
+	}
+
+	warnings, err := migrate(doc)
+	if err != nil {
+		return nil, warnings, err
+	}
+
+	// Round trip through JSON to decode the migrated document into the typed model
+	migrated, err := json.Marshal(doc)
+	if err != nil {
+		return nil, warnings, fmt.Errorf("failed to encode migrated canvas: %w", err)
+	}
+	var c Canvas
+	if err := json.Unmarshal(migrated, &c); err != nil {
+		return nil, warnings, fmt.Errorf("failed to decode canvas: %w", err)
+	}
+
+	warnings = append(warnings, c.Repair()...)
+	return &c, warnings, nil
+}
+
+// Marshal validates the canvas and encodes it as CurrentVersion. A canvas with nodes or edges
+// that Repair would remove is rejected rather than saved with parts silently dropped;
+// problems that are only reported on load (e.g. an image node without src) are returned as warnings.
+func Marshal(c *Canvas) ([]byte, []Warning, error) {
+	c.Version = CurrentVersion
+	warnings := c.Validate()
+
+	var invalid []string
+	for _, w := range warnings {
+		if w.Removes() {
+			invalid = append(invalid, w.Message)
+		}
+	}
+	if len(invalid) > 0 {
+		return nil, warnings, fmt.Errorf("invalid canvas: %s", strings.Join(invalid, "; "))
+	}
+
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return nil, warnings, fmt.Errorf("failed to marshal canvas: %w", err)
+	}
+	return data, warnings, nil
+}
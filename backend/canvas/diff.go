@@ -0,0 +1,113 @@
+package canvas
+
+import "sort"
+
+// NodeChange describes how a node differs between two canvases.
+// Fields not relevant to the change kind are left empty.
+type NodeChange struct {
+	NodeID string `json:"nodeId"`
+	Type   string `json:"type"`
+
+	// Moved nodes
+	From *Position `json:"from,omitempty"`
+	To   *Position `json:"to,omitempty"`
+
+	// Changed text or image fields: the field name and its old and new values
+	Field    string `json:"field,omitempty"`
+	OldValue string `json:"oldValue,omitempty"`
+	NewValue string `json:"newValue,omitempty"`
+}
+
+// Diff lists the node-level differences from one canvas to another
+type Diff struct {
+	Added        []NodeChange `json:"added"`
+	Removed      []NodeChange `json:"removed"`
+	Moved        []NodeChange `json:"moved"`
+	Changed      []NodeChange `json:"changed"` // One entry per changed field of a node
+	EdgesAdded   []Edge       `json:"edgesAdded"`
+	EdgesRemoved []Edge       `json:"edgesRemoved"`
+}
+
+// Compare returns the differences from old to new. Nodes and edges are matched by ID;
+// the results are sorted by ID so the same pair of canvases always yields the same diff.
+func Compare(old, new *Canvas) Diff {
+	diff := Diff{
+		Added:        []NodeChange{},
+		Removed:      []NodeChange{},
+		Moved:        []NodeChange{},
+		Changed:      []NodeChange{},
+		EdgesAdded:   []Edge{},
+		EdgesRemoved: []Edge{},
+	}
+
+	oldNodes := nodesByID(old.Nodes)
+	newNodes := nodesByID(new.Nodes)
+
+	for _, node := range new.Nodes {
+		before, ok := oldNodes[node.ID]
+		if !ok {
+			diff.Added = append(diff.Added, NodeChange{NodeID: node.ID, Type: node.Type})
+			continue
+		}
+
+		if before.Position != node.Position {
+			from, to := before.Position, node.Position
+			diff.Moved = append(diff.Moved, NodeChange{NodeID: node.ID, Type: node.Type, From: &from, To: &to})
+		}
+		diff.Changed = append(diff.Changed, changedFields(before, node)...)
+	}
+	for _, node := range old.Nodes {
+		if _, ok := newNodes[node.ID]; !ok {
+			diff.Removed = append(diff.Removed, NodeChange{NodeID: node.ID, Type: node.Type})
+		}
+	}
+
+	oldEdges := edgesByID(old.Edges)
+	newEdges := edgesByID(new.Edges)
+	for _, edge := range new.Edges {
+		if _, ok := oldEdges[edge.ID]; !ok {
+			diff.EdgesAdded = append(diff.EdgesAdded, edge)
+		}
+	}
+	for _, edge := range old.Edges {
+		if _, ok := newEdges[edge.ID]; !ok {
+			diff.EdgesRemoved = append(diff.EdgesRemoved, edge)
+		}
+	}
+
+	for _, changes := range [][]NodeChange{diff.Added, diff.Removed, diff.Moved, diff.Changed} {
+		sort.SliceStable(changes, func(i, j int) bool { return changes[i].NodeID < changes[j].NodeID })
+	}
+	for _, edges := range [][]Edge{diff.EdgesAdded, diff.EdgesRemoved} {
+		sort.Slice(edges, func(i, j int) bool { return edges[i].ID < edges[j].ID })
+	}
+	return diff
+}
+
+// changedFields compares the data fields of a node present in both canvases
+func changedFields(old, new Node) []NodeChange {
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"content", old.Data.Content, new.Data.Content},
+		{"summary", old.Data.Summary, new.Data.Summary},
+		{"src", old.Data.Src, new.Data.Src},
+		{"alt", old.Data.Alt, new.Data.Alt},
+		{"description", old.Data.Description, new.Data.Description},
+	}
+
+	changes := []NodeChange{}
+	for _, field := range fields {
+		if field.old != field.new {
+			changes = append(changes, NodeChange{
+				NodeID:   new.ID,
+				Type:     new.Type,
+				Field:    field.name,
+				OldValue: field.old,
+				NewValue: field.new,
+			})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,194 @@
+package canvas
+
+import "math"
+
+// Layout parameters
+const (
+	layoutCellWidth   = 400 // Grid spacing, also the ideal distance between linked nodes
+	layoutCellHeight  = 300
+	layoutGap         = 40  // Minimum space kept between node boxes
+	layoutIterations  = 300 // Steps of the force simulation
+	layoutMaxForce    = 500 // Above this many nodes the O(n²) simulation is skipped and the grid is kept
+	layoutGravity     = 4   // Pull towards the center of all nodes, per unit of distance
+	layoutSeparation  = 500 // Passes of separateBoxes before falling back to a grid of the largest box
+	defaultNodeWidth  = 250
+	defaultNodeHeight = 150
+)
+
+// Layout positions every node. Nodes start on a grid in their order; when there are edges,
+// a force-directed simulation pulls linked nodes together and pushes all others apart.
+// Overlapping boxes are separated afterwards; if they cannot be, the nodes are placed on a grid
+// large enough for every box. The result starts at (0, 0).
+// The layout is deterministic: the same canvas always gets the same positions.
+func Layout(c *Canvas) {
+	n := len(c.Nodes)
+	if n == 0 {
+		return
+	}
+
+	cols := int(math.Ceil(math.Sqrt(float64(n))))
+	x := make([]float64, n)
+	y := make([]float64, n)
+	w := make([]float64, n)
+	h := make([]float64, n)
+	index := make(map[string]int, n)
+	for i, node := range c.Nodes {
+		x[i] = float64(i%cols) * layoutCellWidth
+		y[i] = float64(i/cols) * layoutCellHeight
+		w[i], h[i] = defaultNodeWidth, defaultNodeHeight
+		if node.Width != nil && *node.Width > 0 {
+			w[i] = *node.Width
+		}
+		if node.Height != nil && *node.Height > 0 {
+			h[i] = *node.Height
+		}
+		index[node.ID] = i
+	}
+
+	links := [][2]int{}
+	for _, edge := range c.Edges {
+		source, ok1 := index[edge.Source]
+		target, ok2 := index[edge.Target]
+		if ok1 && ok2 && source != target {
+			links = append(links, [2]int{source, target})
+		}
+	}
+
+	if len(links) > 0 && n <= layoutMaxForce {
+		forceLayout(x, y, links)
+	}
+	if !separateBoxes(x, y, w, h) {
+		gridBoxes(x, y, w, h, cols)
+	}
+
+	minX, minY := math.Inf(1), math.Inf(1)
+	for i := range c.Nodes {
+		minX = math.Min(minX, x[i]-w[i]/2)
+		minY = math.Min(minY, y[i]-h[i]/2)
+	}
+	for i := range c.Nodes {
+		c.Nodes[i].Position = Position{
+			X: math.Round(x[i] - w[i]/2 - minX),
+			Y: math.Round(y[i] - h[i]/2 - minY),
+		}
+	}
+}
+
+// forceLayout runs a Fruchterman-Reingold simulation on node centers. A weak pull towards
+// the center keeps unlinked nodes and separate groups from drifting away.
+func forceLayout(x, y []float64, links [][2]int) {
+	n := len(x)
+	k := float64(layoutCellWidth)
+	dx := make([]float64, n)
+	dy := make([]float64, n)
+
+	temperature := k * math.Sqrt(float64(n)) / 2
+	cooling := temperature / layoutIterations
+	for iter := 0; iter < layoutIterations; iter++ {
+		for i := range dx {
+			dx[i], dy[i] = 0, 0
+		}
+
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				ddx, ddy := x[i]-x[j], y[i]-y[j]
+				dist := math.Hypot(ddx, ddy)
+				if dist < 1 {
+					// Coincident nodes are pushed apart in a fixed direction
+					ddx, ddy, dist = float64(j-i), 1, math.Hypot(float64(j-i), 1)
+				}
+				force := k * k / dist
+				dx[i] += ddx / dist * force
+				dy[i] += ddy / dist * force
+				dx[j] -= ddx / dist * force
+				dy[j] -= ddy / dist * force
+			}
+		}
+
+		for _, link := range links {
+			i, j := link[0], link[1]
+			ddx, ddy := x[i]-x[j], y[i]-y[j]
+			dist := math.Hypot(ddx, ddy)
+			if dist < 1 {
+				continue
+			}
+			force := dist * dist / k
+			dx[i] -= ddx / dist * force
+			dy[i] -= ddy / dist * force
+			dx[j] += ddx / dist * force
+			dy[j] += ddy / dist * force
+		}
+
+		cx, cy := 0.0, 0.0
+		for i := 0; i < n; i++ {
+			cx += x[i]
+			cy += y[i]
+		}
+		cx /= float64(n)
+		cy /= float64(n)
+
+		for i := 0; i < n; i++ {
+			dx[i] -= (x[i] - cx) * layoutGravity
+			dy[i] -= (y[i] - cy) * layoutGravity
+			dist := math.Hypot(dx[i], dy[i])
+			if dist < 1e-9 {
+				continue
+			}
+			step := math.Min(dist, temperature)
+			x[i] += dx[i] / dist * step
+			y[i] += dy[i] / dist * step
+		}
+		temperature = math.Max(temperature-cooling, 1)
+	}
+}
+
+// separateBoxes moves overlapping node boxes apart along the axis that needs the smaller shift.
+// It reports whether no boxes overlap any more.
+func separateBoxes(x, y, w, h []float64) bool {
+	n := len(x)
+	for pass := 0; pass < layoutSeparation; pass++ {
+		moved := false
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				overlapX := (w[i]+w[j])/2 + layoutGap - math.Abs(x[i]-x[j])
+				overlapY := (h[i]+h[j])/2 + layoutGap - math.Abs(y[i]-y[j])
+				if overlapX <= 0 || overlapY <= 0 {
+					continue
+				}
+				moved = true
+				if overlapX < overlapY {
+					shift := overlapX / 2
+					if x[i] < x[j] || (x[i] == x[j] && i < j) {
+						shift = -shift
+					}
+					x[i] += shift
+					x[j] -= shift
+				} else {
+					shift := overlapY / 2
+					if y[i] < y[j] || (y[i] == y[j] && i < j) {
+						shift = -shift
+					}
+					y[i] += shift
+					y[j] -= shift
+				}
+			}
+		}
+		if !moved {
+			return true
+		}
+	}
+	return false
+}
+
+// gridBoxes places the nodes in their order on a grid whose cells fit the largest box
+func gridBoxes(x, y, w, h []float64, cols int) {
+	cellWidth, cellHeight := 0.0, 0.0
+	for i := range x {
+		cellWidth = math.Max(cellWidth, w[i]+layoutGap)
+		cellHeight = math.Max(cellHeight, h[i]+layoutGap)
+	}
+	for i := range x {
+		x[i] = float64(i%cols) * cellWidth
+		y[i] = float64(i/cols) * cellHeight
+	}
+}
@@ -0,0 +1,57 @@
+package canvas
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// starCanvas returns n nodes with every node linked to the first one
+func starCanvas(n int) *Canvas {
+	c := &Canvas{Version: CurrentVersion}
+	for i := 0; i < n; i++ {
+		c.Nodes = append(c.Nodes, Node{ID: fmt.Sprintf("n%d", i), Type: "textNode"})
+		if i > 0 {
+			c.Edges = append(c.Edges, Edge{ID: fmt.Sprintf("e%d", i), Source: "n0", Target: fmt.Sprintf("n%d", i)})
+		}
+	}
+	return c
+}
+
+// overlappingPairs counts the pairs of node boxes that intersect
+func overlappingPairs(c *Canvas) int {
+	size := func(n Node) (float64, float64) {
+		w, h := float64(defaultNodeWidth), float64(defaultNodeHeight)
+		if n.Width != nil && *n.Width > 0 {
+			w = *n.Width
+		}
+		if n.Height != nil && *n.Height > 0 {
+			h = *n.Height
+		}
+		return w, h
+	}
+	count := 0
+	for i, a := range c.Nodes {
+		aw, ah := size(a)
+		for _, b := range c.Nodes[i+1:] {
+			bw, bh := size(b)
+			if math.Max(a.Position.X, b.Position.X) < math.Min(a.Position.X+aw, b.Position.X+bw) &&
+				math.Max(a.Position.Y, b.Position.Y) < math.Min(a.Position.Y+ah, b.Position.Y+bh) {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func TestLayoutLargeGraphsDoNotOverlap(t *testing.T) {
+	for _, n := range []int{300, 500, 800} {
+		t.Run(fmt.Sprint(n), func(t *testing.T) {
+			c := starCanvas(n)
+			Layout(c)
+			if overlaps := overlappingPairs(c); overlaps > 0 {
+				t.Errorf("%d overlapping node pairs", overlaps)
+			}
+		})
+	}
+}
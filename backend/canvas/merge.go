@@ -0,0 +1,191 @@
+package canvas
+
+import "fmt"
+
+// Merge combines the changes made to base in ours (e.g. the canvas being edited) and in theirs
+// (e.g. the file changed on disk by another program). Nodes are merged field by field;
+// where both sides changed the same field differently, ours wins and a WarnMergeConflict is reported.
+// Edges referring to a node removed by the merge are dropped.
+func Merge(base, ours, theirs *Canvas) (*Canvas, []Warning) {
+	warnings := []Warning{}
+
+	baseNodes := nodesByID(base.Nodes)
+	theirNodes := nodesByID(theirs.Nodes)
+	ourNodes := nodesByID(ours.Nodes)
+
+	merged := &Canvas{
+		Version:  ours.Version,
+		Metadata: ours.Metadata,
+		LLM:      ours.LLM,
+		Nodes:    []Node{},
+		Edges:    []Edge{},
+	}
+
+	for _, our := range ours.Nodes {
+		b, inBase := baseNodes[our.ID]
+		their, inTheirs := theirNodes[our.ID]
+
+		switch {
+		case !inTheirs && !inBase:
+			// Added here
+			merged.Nodes = append(merged.Nodes, our)
+		case !inTheirs:
+			// Removed in theirs: keep it only if it was edited here
+			if nodesEqual(our, b) {
+				continue
+			}
+			warnings = append(warnings, Warning{
+				Code:    WarnMergeConflict,
+				Message: fmt.Sprintf("node %q was removed in the file but edited here; kept", our.ID),
+				NodeID:  our.ID,
+			})
+			merged.Nodes = append(merged.Nodes, our)
+		case !inBase:
+			// Added on both sides with the same ID
+			if !nodesEqual(our, their) {
+				warnings = append(warnings, Warning{
+					Code:    WarnMergeConflict,
+					Message: fmt.Sprintf("node %q was added on both sides; kept this version", our.ID),
+					NodeID:  our.ID,
+				})
+			}
+			merged.Nodes = append(merged.Nodes, our)
+		default:
+			node, conflicts := mergeNode(b, our, their)
+			for _, field := range conflicts {
+				warnings = append(warnings, Warning{
+					Code:    WarnMergeConflict,
+					Message: fmt.Sprintf("%s of node %q was changed on both sides; kept this version", field, our.ID),
+					NodeID:  our.ID,
+				})
+			}
+			merged.Nodes = append(merged.Nodes, node)
+		}
+	}
+
+	for _, their := range theirs.Nodes {
+		if _, inOurs := ourNodes[their.ID]; inOurs {
+			continue
+		}
+		b, inBase := baseNodes[their.ID]
+		switch {
+		case !inBase:
+			// Added in theirs
+			merged.Nodes = append(merged.Nodes, their)
+		case !nodesEqual(their, b):
+			// Removed here but edited in theirs: keep the edit
+			warnings = append(warnings, Warning{
+				Code:    WarnMergeConflict,
+				Message: fmt.Sprintf("node %q was removed here but edited in the file; restored", their.ID),
+				NodeID:  their.ID,
+			})
+			merged.Nodes = append(merged.Nodes, their)
+		}
+	}
+
+	// Edges have no editable fields: keep ours, add those added in theirs and drop those removed in theirs
+	baseEdges := edgesByID(base.Edges)
+	theirEdges := edgesByID(theirs.Edges)
+	ourEdges := edgesByID(ours.Edges)
+	for _, edge := range ours.Edges {
+		_, inBase := baseEdges[edge.ID]
+		_, inTheirs := theirEdges[edge.ID]
+		if inBase && !inTheirs {
+			continue
+		}
+		merged.Edges = append(merged.Edges, edge)
+	}
+	for _, edge := range theirs.Edges {
+		_, inBase := baseEdges[edge.ID]
+		_, inOurs := ourEdges[edge.ID]
+		if !inBase && !inOurs {
+			merged.Edges = append(merged.Edges, edge)
+		}
+	}
+
+	warnings = append(warnings, merged.Repair()...)
+	return merged, warnings
+}
+
+// mergeNode merges a node changed on both sides and returns the names of the conflicting fields
+func mergeNode(base, ours, theirs Node) (Node, []string) {
+	conflicts := []string{}
+	merged := ours
+
+	var conflict bool
+	if merged.Position, conflict = pick(base.Position, ours.Position, theirs.Position); conflict {
+		conflicts = append(conflicts, "position")
+	}
+	if merged.Data.Content, conflict = pick(base.Data.Content, ours.Data.Content, theirs.Data.Content); conflict {
+		conflicts = append(conflicts, "content")
+	}
+	if merged.Data.Summary, conflict = pick(base.Data.Summary, ours.Data.Summary, theirs.Data.Summary); conflict {
+		conflicts = append(conflicts, "summary")
+	}
+	if merged.Data.Src, conflict = pick(base.Data.Src, ours.Data.Src, theirs.Data.Src); conflict {
+		conflicts = append(conflicts, "src")
+	}
+	if merged.Data.Alt, conflict = pick(base.Data.Alt, ours.Data.Alt, theirs.Data.Alt); conflict {
+		conflicts = append(conflicts, "alt")
+	}
+	if merged.Data.Description, conflict = pick(base.Data.Description, ours.Data.Description, theirs.Data.Description); conflict {
+		conflicts = append(conflicts, "description")
+	}
+
+	// Sizes are merged as one field, since they are changed together by resizing
+	size, conflict := pick(sizeOf(base), sizeOf(ours), sizeOf(theirs))
+	if conflict {
+		conflicts = append(conflicts, "size")
+	} else if size == sizeOf(theirs) {
+		merged.Width, merged.Height = theirs.Width, theirs.Height
+	}
+	return merged, conflicts
+}
+
+// pick is a three-way merge of a single value. On conflict ours is returned.
+func pick[T comparable](base, ours, theirs T) (T, bool) {
+	switch {
+	case ours == theirs || theirs == base:
+		return ours, false
+	case ours == base:
+		return theirs, false
+	default:
+		return ours, true
+	}
+}
+
+type nodeSize struct {
+	width, height       float64
+	hasWidth, hasHeight bool
+}
+
+func sizeOf(n Node) nodeSize {
+	var s nodeSize
+	if n.Width != nil {
+		s.width, s.hasWidth = *n.Width, true
+	}
+	if n.Height != nil {
+		s.height, s.hasHeight = *n.Height, true
+	}
+	return s
+}
+
+func nodesEqual(a, b Node) bool {
+	return a.Type == b.Type && a.Position == b.Position && a.Data == b.Data && sizeOf(a) == sizeOf(b)
+}
+
+func nodesByID(nodes []Node) map[string]Node {
+	m := make(map[string]Node, len(nodes))
+	for _, node := range nodes {
+		m[node.ID] = node
+	}
+	return m
+}
+
+func edgesByID(edges []Edge) map[string]Edge {
+	m := make(map[string]Edge, len(edges))
+	for _, edge := range edges {
+		m[edge.ID] = edge
+	}
+	return m
+}
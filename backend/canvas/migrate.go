@@ -0,0 +1,83 @@
+package canvas
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// migration upgrades a decoded canvas document from one version to the next
+type migration struct {
+	from    string
+	to      string
+	migrate func(doc map[string]interface{}) error
+}
+
+// migrations is the chain applied by Parse, in order. A new file version adds a step here
+// and bumps CurrentVersion; the frontend only ever sees CurrentVersion.
+var migrations = []migration{
+	{from: "1.0", to: "1.1", migrate: migrate1_0To1_1},
+}
+
+// migrate upgrades doc in place to CurrentVersion
+func migrate(doc map[string]interface{}) ([]Warning, error) {
+	warnings := []Warning{}
+
+	version, _ := doc["version"].(string)
+	if version == "" {
+		// Files written before versioning are the 1.0 format
+		version = "1.0"
+	}
+	if compareVersions(version, CurrentVersion) > 0 {
+		return warnings, fmt.Errorf("canvas version %s is newer than the supported version %s", version, CurrentVersion)
+	}
+
+	for _, m := range migrations {
+		if version != m.from {
+			continue
+		}
+		if err := m.migrate(doc); err != nil {
+			return warnings, fmt.Errorf("failed to migrate canvas from %s to %s: %w", m.from, m.to, err)
+		}
+		warnings = append(warnings, Warning{Code: WarnMigrated, Message: fmt.Sprintf("migrated canvas from version %s to %s", m.from, m.to)})
+		version = m.to
+	}
+
+	if version != CurrentVersion {
+		return warnings, fmt.Errorf("unsupported canvas version: %s", version)
+	}
+	doc["version"] = version
+	return warnings, nil
+}
+
+// migrate1_0To1_1 marks every node as a text node; 1.0 had no image nodes
+func migrate1_0To1_1(doc map[string]interface{}) error {
+	nodes, _ := doc["nodes"].([]interface{})
+	for _, n := range nodes {
+		if node, ok := n.(map[string]interface{}); ok {
+			node["type"] = TextNode
+		}
+	}
+	return nil
+}
+
+// compareVersions compares dotted numeric versions such as "1.1" and "1.10"
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
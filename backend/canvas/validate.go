@@ -0,0 +1,124 @@
+package canvas
+
+import "fmt"
+
+// Warning codes
+const (
+	WarnMigrated         = "migrated"
+	WarnMissingNodeID    = "missing_node_id"
+	WarnDuplicateNodeID  = "duplicate_node_id"
+	WarnUnknownNodeType  = "unknown_node_type"
+	WarnMissingImageSrc  = "missing_image_src"
+	WarnMissingEdgeID    = "missing_edge_id"
+	WarnDuplicateEdgeID  = "duplicate_edge_id"
+	WarnDanglingEdge     = "dangling_edge"
+	WarnInvalidDimension = "invalid_dimension"
+	WarnMergeConflict    = "merge_conflict"
+	WarnRecovered        = "recovered_from_backup"
+	WarnImportSkipped    = "import_skipped"
+)
+
+// Warning describes a problem found in a canvas, or a change made while loading it
+type Warning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	NodeID  string `json:"nodeId,omitempty"`
+	EdgeID  string `json:"edgeId,omitempty"`
+}
+
+// Removes reports whether Repair removes the node or edge the warning refers to
+func (w Warning) Removes() bool {
+	switch w.Code {
+	case WarnMissingNodeID, WarnDuplicateNodeID, WarnUnknownNodeType,
+		WarnMissingEdgeID, WarnDuplicateEdgeID, WarnDanglingEdge:
+		return true
+	}
+	return false
+}
+
+// Validate reports the problems of the canvas without changing it
+func (c *Canvas) Validate() []Warning {
+	return c.check(false)
+}
+
+// Repair removes nodes and edges that cannot be loaded and reports what was removed:
+// nodes without ID, of unknown type or with a duplicate ID (the first one is kept),
+// and edges without ID, with a duplicate ID or referring to a missing node.
+// Image nodes without src and invalid dimensions are only reported.
+func (c *Canvas) Repair() []Warning {
+	return c.check(true)
+}
+
+func (c *Canvas) check(repair bool) []Warning {
+	warnings := []Warning{}
+
+	if c.Nodes == nil {
+		c.Nodes = []Node{}
+	}
+	if c.Edges == nil {
+		c.Edges = []Edge{}
+	}
+
+	nodeIDs := map[string]bool{}
+	nodes := c.Nodes[:0:0]
+	for i, node := range c.Nodes {
+		var w *Warning
+		switch {
+		case node.ID == "":
+			w = &Warning{Code: WarnMissingNodeID, Message: fmt.Sprintf("node %d has no id", i)}
+		case nodeIDs[node.ID]:
+			w = &Warning{Code: WarnDuplicateNodeID, Message: fmt.Sprintf("duplicate node id %q", node.ID), NodeID: node.ID}
+		case node.Type != TextNode && node.Type != ImageNode:
+			w = &Warning{Code: WarnUnknownNodeType, Message: fmt.Sprintf("node %q has unknown type %q", node.ID, node.Type), NodeID: node.ID}
+		}
+		if w != nil {
+			if repair {
+				w.Message += "; removed"
+			}
+			warnings = append(warnings, *w)
+			if repair {
+				continue
+			}
+		}
+		nodeIDs[node.ID] = true
+
+		if node.IsImage() && node.Data.Src == "" {
+			warnings = append(warnings, Warning{Code: WarnMissingImageSrc, Message: fmt.Sprintf("image node %q has no src", node.ID), NodeID: node.ID})
+		}
+		if (node.Width != nil && *node.Width <= 0) || (node.Height != nil && *node.Height <= 0) {
+			warnings = append(warnings, Warning{Code: WarnInvalidDimension, Message: fmt.Sprintf("node %q has a non-positive size", node.ID), NodeID: node.ID})
+		}
+		nodes = append(nodes, node)
+	}
+
+	edgeIDs := map[string]bool{}
+	edges := c.Edges[:0:0]
+	for i, edge := range c.Edges {
+		var w *Warning
+		switch {
+		case edge.ID == "":
+			w = &Warning{Code: WarnMissingEdgeID, Message: fmt.Sprintf("edge %d has no id", i)}
+		case edgeIDs[edge.ID]:
+			w = &Warning{Code: WarnDuplicateEdgeID, Message: fmt.Sprintf("duplicate edge id %q", edge.ID), EdgeID: edge.ID}
+		case !nodeIDs[edge.Source] || !nodeIDs[edge.Target]:
+			w = &Warning{Code: WarnDanglingEdge, Message: fmt.Sprintf("edge %q connects missing node %q -> %q", edge.ID, edge.Source, edge.Target), EdgeID: edge.ID}
+		}
+		if w != nil {
+			if repair {
+				w.Message += "; removed"
+			}
+			warnings = append(warnings, *w)
+			if repair {
+				continue
+			}
+		}
+		edgeIDs[edge.ID] = true
+		edges = append(edges, edge)
+	}
+
+	if repair {
+		c.Nodes = nodes
+		c.Edges = edges
+	}
+	return warnings
+}
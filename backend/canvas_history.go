@@ -0,0 +1,233 @@
+package backend
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"fm-doc-canvas/backend/canvas"
+)
+
+// maxCanvasVersions is the number of snapshots kept per canvas file; older ones are deleted on save
+const maxCanvasVersions = 50
+
+// CanvasVersion describes a snapshot in the history of a canvas file
+type CanvasVersion struct {
+	ID        string `json:"id"`
+	SavedAt   string `json:"savedAt"` // RFC3339 timestamp
+	NodeCount int    `json:"nodeCount"`
+	EdgeCount int    `json:"edgeCount"`
+	Size      int64  `json:"size"`
+}
+
+// historyDir returns the hidden directory next to a canvas file that holds its snapshots,
+// e.g. notes/.plan.json.history for notes/plan.json
+func historyDir(canvasPath string) string {
+	return filepath.Join(filepath.Dir(canvasPath), "."+filepath.Base(canvasPath)+".history")
+}
+
+// versionPath returns the snapshot file of a version, rejecting IDs that could escape the history directory
+func versionPath(canvasPath, id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.Contains(id, "..") {
+		return "", fmt.Errorf("invalid canvas version: %s", id)
+	}
+	return filepath.Join(historyDir(canvasPath), id+".json"), nil
+}
+
+// recordCanvasVersion stores data as the newest snapshot of the canvas file, unless it matches
+// the newest snapshot already, and deletes snapshots beyond maxCanvasVersions
+func recordCanvasVersion(canvasPath string, data []byte) error {
+	ids, err := listVersionIDs(canvasPath)
+	if err != nil {
+		return err
+	}
+	if len(ids) > 0 {
+		newestPath, err := versionPath(canvasPath, ids[0])
+		if err != nil {
+			return err
+		}
+		if newest, err := os.ReadFile(newestPath); err == nil && hashBytes(newest) == hashBytes(data) {
+			return nil
+		}
+	}
+
+	dir := historyDir(canvasPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	id := time.Now().Format("20060102_150405.000")
+	for len(ids) > 0 && id <= ids[0] {
+		// Two saves within the same millisecond, or a clock set back: keep IDs ordered and unique
+		id = ids[0] + "_1"
+	}
+	snapshotPath, err := versionPath(canvasPath, id)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(snapshotPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write canvas version: %w", err)
+	}
+
+	ids = append([]string{id}, ids...)
+	for _, old := range ids[min(len(ids), maxCanvasVersions):] {
+		oldPath, err := versionPath(canvasPath, old)
+		if err != nil {
+			continue
+		}
+		if err := os.Remove(oldPath); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("Warning: failed to delete old canvas version %s: %v\n", old, err)
+		}
+	}
+	return nil
+}
+
+// listVersionIDs returns the snapshot IDs of a canvas file, newest first
+func listVersionIDs(canvasPath string) ([]string, error) {
+	entries, err := os.ReadDir(historyDir(canvasPath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("failed to read history directory: %w", err)
+	}
+
+	ids := []string{}
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".json")
+		if entry.IsDir() || !ok || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	return ids, nil
+}
+
+// readCanvasVersion reads and migrates a snapshot. An empty ID reads the canvas file itself,
+// so a version can be compared with the saved state.
+func readCanvasVersion(canvasPath, id string) (*canvas.Canvas, []canvas.Warning, error) {
+	if id == "" {
+		return readCanvasFile(canvasPath)
+	}
+	snapshotPath, err := versionPath(canvasPath, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := os.Stat(snapshotPath); os.IsNotExist(err) {
+		return nil, nil, fmt.Errorf("canvas version not found: %s", id)
+	}
+	return readCanvasFile(snapshotPath)
+}
+
+// historyCanvasPath returns the canvas file whose history is requested; empty means the current file
+func (s *FileService) historyCanvasPath(canvasPath string) (string, error) {
+	if canvasPath == "" {
+		canvasPath = s.CurrentCanvasPath()
+	}
+	if canvasPath == "" {
+		return "", fmt.Errorf("the canvas has not been saved to a file yet")
+	}
+	return canvasPath, nil
+}
+
+// ListCanvasVersions returns the saved versions of a canvas file, newest first.
+// An empty canvasPath means the current file.
+func (s *FileService) ListCanvasVersions(canvasPath string) ([]CanvasVersion, error) {
+	canvasPath, err := s.historyCanvasPath(canvasPath)
+	if err != nil {
+		return nil, err
+	}
+	ids, err := listVersionIDs(canvasPath)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := []CanvasVersion{}
+	for _, id := range ids {
+		snapshotPath, err := versionPath(canvasPath, id)
+		if err != nil {
+			continue
+		}
+		info, err := os.Stat(snapshotPath)
+		if err != nil {
+			continue
+		}
+		c, _, err := readCanvasFile(snapshotPath)
+		if err != nil {
+			fmt.Printf("Warning: skipping canvas version %s: %v\n", id, err)
+			continue
+		}
+		versions = append(versions, CanvasVersion{
+			ID:        id,
+			SavedAt:   info.ModTime().Format(time.RFC3339),
+			NodeCount: len(c.Nodes),
+			EdgeCount: len(c.Edges),
+			Size:      info.Size(),
+		})
+	}
+	return versions, nil
+}
+
+// GetCanvasVersion returns a saved version of a canvas file without changing the file
+func (s *FileService) GetCanvasVersion(canvasPath, id string) (LoadCanvasResult, error) {
+	canvasPath, err := s.historyCanvasPath(canvasPath)
+	if err != nil {
+		return LoadCanvasResult{}, err
+	}
+	if id == "" {
+		return LoadCanvasResult{}, fmt.Errorf("invalid canvas version: %s", id)
+	}
+	c, warnings, err := readCanvasVersion(canvasPath, id)
+	if err != nil {
+		return LoadCanvasResult{}, err
+	}
+	return LoadCanvasResult{Path: canvasPath, Canvas: c, Warnings: warnings}, nil
+}
+
+// DiffCanvasVersions compares two versions of a canvas file.
+// An empty toID compares with the file as currently saved.
+func (s *FileService) DiffCanvasVersions(canvasPath, fromID, toID string) (canvas.Diff, error) {
+	canvasPath, err := s.historyCanvasPath(canvasPath)
+	if err != nil {
+		return canvas.Diff{}, err
+	}
+	if fromID == "" {
+		return canvas.Diff{}, fmt.Errorf("invalid canvas version: %s", fromID)
+	}
+	from, _, err := readCanvasVersion(canvasPath, fromID)
+	if err != nil {
+		return canvas.Diff{}, err
+	}
+	to, _, err := readCanvasVersion(canvasPath, toID)
+	if err != nil {
+		return canvas.Diff{}, err
+	}
+	return canvas.Compare(from, to), nil
+}
+
+// RestoreCanvasVersion writes a saved version back to the canvas file and makes it the current file.
+// The state being replaced is kept in the history, so a restore can be undone.
+func (s *FileService) RestoreCanvasVersion(canvasPath, id string) (LoadCanvasResult, error) {
+	result, err := s.GetCanvasVersion(canvasPath, id)
+	if err != nil {
+		return LoadCanvasResult{}, err
+	}
+
+	// Keep the file as it is now, in case it was changed outside the app since the last save
+	if current, err := os.ReadFile(result.Path); err == nil {
+		if err := recordCanvasVersion(result.Path, current); err != nil {
+			fmt.Printf("Warning: failed to record canvas version: %v\n", err)
+		}
+	}
+
+	saved, err := s.writeCanvas(result.Path, result.Canvas)
+	if err != nil {
+		return LoadCanvasResult{}, err
+	}
+	result.Warnings = append(result.Warnings, saved.Warnings...)
+	return result, nil
+}
@@ -0,0 +1,266 @@
+package backend
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"fm-doc-canvas/backend/canvas"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// CanvasFileChangedEvent is emitted to the frontend when the current canvas file is changed by another program
+const CanvasFileChangedEvent = "canvas:file-changed"
+
+// watchDebounce collapses the burst of events a single save by another program produces
+const watchDebounce = 300 * time.Millisecond
+
+// CanvasFileChange describes a change of the current canvas file made outside the app
+type CanvasFileChange struct {
+	Path       string `json:"path"`
+	ModifiedAt string `json:"modifiedAt,omitempty"` // RFC3339 timestamp; empty when removed
+	Removed    bool   `json:"removed"`
+
+	hash string // Content hash of the changed file, used to notify once per change
+}
+
+// canvasFileState is the content of the current canvas file as last read or written by the app
+type canvasFileState struct {
+	modTime time.Time
+	size    int64
+	hash    string
+	data    []byte // Base for merging external changes
+}
+
+// newCanvasFileState records data as the content of filePath; the file's mtime is read from disk
+func newCanvasFileState(filePath string, data []byte) *canvasFileState {
+	state := &canvasFileState{size: int64(len(data)), hash: hashBytes(data), data: data}
+	if info, err := os.Stat(filePath); err == nil {
+		state.modTime = info.ModTime()
+	}
+	return state
+}
+
+// readCanvasFileState reads the current content of filePath, or returns nil if it cannot be read
+func readCanvasFileState(filePath string) *canvasFileState {
+	if filePath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil
+	}
+	return newCanvasFileState(filePath, data)
+}
+
+// detectCanvasFileChange compares filePath with the state the app last saw.
+// The mtime is checked first; a changed mtime only counts when the content hash differs too.
+func detectCanvasFileChange(filePath string, known *canvasFileState) *CanvasFileChange {
+	if filePath == "" || known == nil {
+		return nil
+	}
+
+	info, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
+		return &CanvasFileChange{Path: filePath, Removed: true}
+	}
+	if err != nil || (info.ModTime().Equal(known.modTime) && info.Size() == known.size) {
+		return nil
+	}
+
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil
+	}
+	hash := hashBytes(data)
+	if hash == known.hash {
+		return nil
+	}
+	return &CanvasFileChange{Path: filePath, ModifiedAt: info.ModTime().Format(time.RFC3339), hash: hash}
+}
+
+// StartWatching watches the current canvas file and emits CanvasFileChangedEvent when another program changes it.
+// The directory is watched rather than the file, so replacing the file by rename is noticed too.
+func (s *FileService) StartWatching() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+
+	s.mu.Lock()
+	s.watcher = watcher
+	s.watchCurrentLocked()
+	s.mu.Unlock()
+
+	go s.watchLoop(watcher)
+	return nil
+}
+
+// StopWatching stops watching the current canvas file
+func (s *FileService) StopWatching() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.watcher == nil {
+		return
+	}
+	if s.notifyTimer != nil {
+		s.notifyTimer.Stop()
+		s.notifyTimer = nil
+	}
+	if err := s.watcher.Close(); err != nil {
+		fmt.Printf("Warning: failed to close file watcher: %v\n", err)
+	}
+	s.watcher = nil
+	s.watchedDir = ""
+}
+
+// watchCurrentLocked moves the watch to the directory of the current file; s.mu must be held
+func (s *FileService) watchCurrentLocked() {
+	if s.watcher == nil {
+		return
+	}
+
+	dir := ""
+	if s.currentPath != "" {
+		dir = filepath.Dir(s.currentPath)
+	}
+	if dir == s.watchedDir {
+		return
+	}
+
+	if s.watchedDir != "" {
+		if err := s.watcher.Remove(s.watchedDir); err != nil {
+			fmt.Printf("Warning: failed to stop watching %s: %v\n", s.watchedDir, err)
+		}
+		s.watchedDir = ""
+	}
+	if dir != "" {
+		if err := s.watcher.Add(dir); err != nil {
+			fmt.Printf("Warning: failed to watch %s: %v\n", dir, err)
+			return
+		}
+		s.watchedDir = dir
+	}
+}
+
+func (s *FileService) watchLoop(watcher *fsnotify.Watcher) {
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			s.handleWatchEvent(event)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			fmt.Printf("Warning: file watcher error: %v\n", err)
+		}
+	}
+}
+
+// handleWatchEvent schedules a check of the current file after the events of one save have settled
+func (s *FileService) handleWatchEvent(event fsnotify.Event) {
+	if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Remove) && !event.Has(fsnotify.Rename) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.currentPath == "" || filepath.Clean(event.Name) != filepath.Clean(s.currentPath) {
+		return
+	}
+	if s.notifyTimer != nil {
+		s.notifyTimer.Stop()
+	}
+	s.notifyTimer = time.AfterFunc(watchDebounce, s.notifyExternalChange)
+}
+
+// notifyExternalChange emits CanvasFileChangedEvent once per external version of the current file.
+// Writes by the app itself update the known state first and are not reported.
+func (s *FileService) notifyExternalChange() {
+	s.mu.Lock()
+	filePath, known := s.currentPath, s.loaded
+	s.mu.Unlock()
+
+	change := detectCanvasFileChange(filePath, known)
+	if change == nil {
+		return
+	}
+
+	// ModifiedAt only has a resolution of one second, so edits are told apart by their content
+	key := change.hash
+	if change.Removed {
+		key = "removed"
+	}
+	s.mu.Lock()
+	if filePath != s.currentPath || key == s.notified {
+		s.mu.Unlock()
+		return
+	}
+	s.notified = key
+	s.mu.Unlock()
+
+	if s.ctx != nil {
+		runtime.EventsEmit(s.ctx, CanvasFileChangedEvent, *change)
+	}
+}
+
+// externalChange reports whether the current file was changed outside the app since it was read or written
+func (s *FileService) externalChange() *CanvasFileChange {
+	s.mu.Lock()
+	filePath, known := s.currentPath, s.loaded
+	s.mu.Unlock()
+	return detectCanvasFileChange(filePath, known)
+}
+
+// OverwriteCanvas writes the canvas to the current file even if it was changed outside the app
+func (s *FileService) OverwriteCanvas(c canvas.Canvas) (SaveCanvasResult, error) {
+	filePath := s.CurrentCanvasPath()
+	if filePath == "" {
+		return s.SaveCanvasAs(c)
+	}
+	return s.writeCanvas(filePath, &c)
+}
+
+// MergeCanvasWithFile merges the changes made to the current file outside the app into the canvas being edited.
+// The merged canvas is returned without being saved; the file's content becomes the new base,
+// so the next SaveCanvas writes the merged canvas without reporting a conflict.
+func (s *FileService) MergeCanvasWithFile(c canvas.Canvas) (LoadCanvasResult, error) {
+	s.mu.Lock()
+	filePath, known := s.currentPath, s.loaded
+	s.mu.Unlock()
+
+	if filePath == "" || known == nil {
+		return LoadCanvasResult{}, fmt.Errorf("the canvas has not been saved to a file yet")
+	}
+
+	base, _, err := canvas.Parse(known.data)
+	if err != nil {
+		return LoadCanvasResult{}, fmt.Errorf("failed to read the canvas as last saved: %w", err)
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return LoadCanvasResult{}, fmt.Errorf("failed to read file: %w", err)
+	}
+	theirs, warnings, err := canvas.Parse(data)
+	if err != nil {
+		return LoadCanvasResult{}, err
+	}
+
+	merged, mergeWarnings := canvas.Merge(base, &c, theirs)
+
+	s.mu.Lock()
+	if s.currentPath == filePath {
+		s.loaded = newCanvasFileState(filePath, data)
+	}
+	s.mu.Unlock()
+
+	return LoadCanvasResult{Path: filePath, Canvas: merged, Warnings: append(warnings, mergeWarnings...)}, nil
+}
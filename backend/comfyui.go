@@ -0,0 +1,395 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterImageProvider(ImageProviderRegistration{
+		Name:        "comfyui",
+		DisplayName: "ComfyUI (Local)",
+		Default: func() ProviderConfig {
+			return &ComfyUIConfig{
+				BaseURL:        "http://127.0.0.1:8188",
+				Seed:           -1,
+				TimeoutSeconds: 300,
+			}
+		},
+		FromLegacy: func(baseURL string, model string, apiKey string) ProviderConfig {
+			return &ComfyUIConfig{
+				BaseURL: baseURL,
+				Seed:    -1,
+			}
+		},
+		New: func(cfg ProviderConfig, baseCfg *ImageGenConfig, service *ImageGenService) ImageGenProvider {
+			return &ComfyUIProvider{
+				config:  cfg.(*ComfyUIConfig),
+				baseCfg: baseCfg,
+				service: service,
+			}
+		},
+	})
+}
+
+// comfyUIPollInterval is the delay between polls of /history while a prompt is running
+var comfyUIPollInterval = time.Second
+
+type ComfyUIProvider struct {
+	config  *ComfyUIConfig
+	baseCfg *ImageGenConfig
+	service *ImageGenService
+}
+
+// comfyUIImageRef identifies a file on the ComfyUI server (as used by /view and returned by /upload/image)
+type comfyUIImageRef struct {
+	Filename  string `json:"filename"`
+	Name      string `json:"name"` // /upload/image returns "name" instead of "filename"
+	Subfolder string `json:"subfolder"`
+	Type      string `json:"type"`
+}
+
+// Capabilities implements ImageGenProvider.Capabilities for ComfyUIProvider
+func (p *ComfyUIProvider) Capabilities() ImageGenCapabilities {
+	return ImageGenCapabilities{
+		MaxReferenceImages:     -1, // Depends on the {{ref_image_N}} placeholders in the workflow
+		Formats:                []string{"image/png"},
+		SupportsEdit:           true,
+		SupportsNegativePrompt: true, // Via the {{negative_prompt}} placeholder
+	}
+}
+
+// Generate implements ImageGenProvider.Generate for ComfyUIProvider.
+// The configured workflow is submitted with its placeholders filled in, the history is polled
+// until the prompt finishes, and the first output image is downloaded.
+func (p *ComfyUIProvider) Generate(request ImageGenRequest) (string, error) {
+	// Combine prompt and context for better generation
+	fullPrompt := request.FullPrompt()
+
+	workflowData, err := p.config.readWorkflow()
+	if err != nil {
+		return "", err
+	}
+
+	var workflow map[string]interface{}
+	if err := json.Unmarshal(workflowData, &workflow); err != nil {
+		return "", fmt.Errorf("failed to parse comfyui workflow (export it in API format): %w", err)
+	}
+
+	baseURL := strings.TrimSuffix(valueOrDefault(p.config.BaseURL, "http://127.0.0.1:8188"), "/")
+	client := &http.Client{Timeout: 60 * time.Second}
+
+	// Upload reference images so LoadImage nodes can refer to them by name
+	uploaded := make([]string, 0, len(request.RefImages))
+	for i, img := range request.RefImages {
+		name, err := p.uploadImage(client, baseURL, img, i)
+		if err != nil {
+			return "", err
+		}
+		uploaded = append(uploaded, name)
+	}
+
+	seed := p.config.Seed
+	if seed < 0 {
+		seed = rand.Int63n(1 << 32)
+	}
+
+	replacements := map[string]string{
+		"{{prompt}}":          fullPrompt,
+		"{{negative_prompt}}": request.NegativePrompt,
+	}
+	for i, name := range uploaded {
+		replacements[fmt.Sprintf("{{ref_image_%d}}", i+1)] = name
+		if i == 0 {
+			replacements["{{ref_image}}"] = name
+		}
+	}
+	filled := fillWorkflowPlaceholders(workflow, replacements, seed)
+
+	promptID, err := p.submitPrompt(client, baseURL, filled)
+	if err != nil {
+		return "", err
+	}
+
+	timeout := time.Duration(valueOrDefault(p.config.TimeoutSeconds, 300)) * time.Second
+	output, err := p.waitForOutput(client, baseURL, promptID, timeout)
+	if err != nil {
+		return "", err
+	}
+
+	return p.downloadOutput(client, baseURL, output)
+}
+
+// readWorkflow returns the inline workflow, or the contents of the workflow file
+func (c *ComfyUIConfig) readWorkflow() ([]byte, error) {
+	if len(c.Workflow) > 0 {
+		return c.Workflow, nil
+	}
+	if c.WorkflowPath == "" {
+		return nil, fmt.Errorf("comfyui workflow path is not set")
+	}
+	data, err := os.ReadFile(c.WorkflowPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read comfyui workflow: %w", err)
+	}
+	return data, nil
+}
+
+// resolve implements resolvableProviderConfig: the seed is fixed and the workflow file is inlined
+func (c *ComfyUIConfig) resolve() (ProviderConfig, error) {
+	resolved := *c
+	if resolved.Seed < 0 {
+		resolved.Seed = rand.Int63n(1 << 32)
+	}
+
+	workflowData, err := c.readWorkflow()
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid(workflowData) {
+		return nil, fmt.Errorf("failed to parse comfyui workflow (export it in API format): invalid JSON")
+	}
+	resolved.Workflow = json.RawMessage(workflowData)
+	return &resolved, nil
+}
+
+// fillWorkflowPlaceholders returns a copy of the workflow with placeholders replaced in every string value.
+// A value that is exactly "{{seed}}" becomes a number so it is accepted by sampler inputs.
+func fillWorkflowPlaceholders(value interface{}, replacements map[string]string, seed int64) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			out[key] = fillWorkflowPlaceholders(child, replacements, seed)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, child := range v {
+			out[i] = fillWorkflowPlaceholders(child, replacements, seed)
+		}
+		return out
+	case string:
+		if v == "{{seed}}" {
+			return seed
+		}
+		for placeholder, replacement := range replacements {
+			v = strings.ReplaceAll(v, placeholder, replacement)
+		}
+		return strings.ReplaceAll(v, "{{seed}}", fmt.Sprintf("%d", seed))
+	default:
+		return v
+	}
+}
+
+// uploadImage uploads a reference image (data URL) and returns the name to use in LoadImage nodes
+func (p *ComfyUIProvider) uploadImage(client *http.Client, baseURL string, dataURL string, index int) (string, error) {
+	mimeType, data, err := decodeDataURL(dataURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid reference image: %w", err)
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	filename := fmt.Sprintf("fm-doc-canvas_ref_%d_%s.%s", index+1, hashBytes(data)[:12], extensionForMIME(mimeType))
+	part, err := writer.CreateFormFile("image", filename)
+	if err != nil {
+		return "", fmt.Errorf("failed to create upload form: %w", err)
+	}
+	if _, err := part.Write(data); err != nil {
+		return "", fmt.Errorf("failed to write upload form: %w", err)
+	}
+	if err := writer.WriteField("overwrite", "true"); err != nil {
+		return "", fmt.Errorf("failed to write upload form: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("failed to write upload form: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", baseURL+"/upload/image", &body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	respBody, err := doComfyUIRequest(client, req)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload reference image: %w", err)
+	}
+
+	var ref comfyUIImageRef
+	if err := json.Unmarshal(respBody, &ref); err != nil {
+		return "", fmt.Errorf("failed to unmarshal upload response: %w", err)
+	}
+	if ref.Name == "" {
+		return "", fmt.Errorf("upload response did not contain an image name")
+	}
+
+	// LoadImage expects "subfolder/name" for files outside the input root
+	if ref.Subfolder != "" {
+		return ref.Subfolder + "/" + ref.Name, nil
+	}
+	return ref.Name, nil
+}
+
+// submitPrompt queues the workflow and returns the prompt ID
+func (p *ComfyUIProvider) submitPrompt(client *http.Client, baseURL string, workflow interface{}) (string, error) {
+	payload := map[string]interface{}{
+		"prompt":    workflow,
+		"client_id": "fm-doc-canvas",
+	}
+
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request payload: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", baseURL+"/prompt", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	body, err := doComfyUIRequest(client, req)
+	if err != nil {
+		return "", fmt.Errorf("failed to queue comfyui prompt: %w", err)
+	}
+
+	var result struct {
+		PromptID   string                 `json:"prompt_id"`
+		NodeErrors map[string]interface{} `json:"node_errors"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if len(result.NodeErrors) > 0 {
+		return "", fmt.Errorf("comfyui rejected the workflow: %s", string(body))
+	}
+	if result.PromptID == "" {
+		return "", fmt.Errorf("no prompt_id in response")
+	}
+	return result.PromptID, nil
+}
+
+// waitForOutput polls /history until the prompt completes and returns its first output image
+func (p *ComfyUIProvider) waitForOutput(client *http.Client, baseURL string, promptID string, timeout time.Duration) (*comfyUIImageRef, error) {
+	deadline := time.Now().Add(timeout)
+
+	for time.Now().Before(deadline) {
+		req, err := http.NewRequest("GET", baseURL+"/history/"+url.PathEscape(promptID), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+		}
+
+		body, err := doComfyUIRequest(client, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to poll comfyui history: %w", err)
+		}
+
+		var history map[string]struct {
+			Outputs map[string]struct {
+				Images []comfyUIImageRef `json:"images"`
+			} `json:"outputs"`
+			Status struct {
+				StatusStr string `json:"status_str"`
+				Completed bool   `json:"completed"`
+			} `json:"status"`
+		}
+		if err := json.Unmarshal(body, &history); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal history: %w", err)
+		}
+
+		// The prompt appears in the history once it has finished
+		if entry, ok := history[promptID]; ok {
+			if entry.Status.StatusStr == "error" {
+				return nil, fmt.Errorf("comfyui workflow failed: %s", string(body))
+			}
+
+			// Prefer final outputs over previews; node IDs are sorted for a stable choice
+			nodeIDs := make([]string, 0, len(entry.Outputs))
+			for id := range entry.Outputs {
+				nodeIDs = append(nodeIDs, id)
+			}
+			sort.Strings(nodeIDs)
+
+			var fallback *comfyUIImageRef
+			for _, id := range nodeIDs {
+				for _, img := range entry.Outputs[id].Images {
+					img := img
+					if img.Type == "output" {
+						return &img, nil
+					}
+					if fallback == nil {
+						fallback = &img
+					}
+				}
+			}
+			if fallback != nil {
+				return fallback, nil
+			}
+			if entry.Status.Completed {
+				return nil, fmt.Errorf("comfyui workflow produced no images")
+			}
+		}
+
+		time.Sleep(comfyUIPollInterval)
+	}
+
+	return nil, fmt.Errorf("timed out waiting for comfyui after %s", timeout)
+}
+
+// downloadOutput fetches an output image via /view and saves it
+func (p *ComfyUIProvider) downloadOutput(client *http.Client, baseURL string, ref *comfyUIImageRef) (string, error) {
+	query := url.Values{}
+	query.Set("filename", ref.Filename)
+	query.Set("subfolder", ref.Subfolder)
+	query.Set("type", ref.Type)
+
+	req, err := http.NewRequest("GET", baseURL+"/view?"+query.Encode(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	data, err := doComfyUIRequest(client, req)
+	if err != nil {
+		return "", fmt.Errorf("failed to download comfyui output: %w", err)
+	}
+
+	mimeType := http.DetectContentType(data)
+	if !strings.HasPrefix(mimeType, "image/") {
+		return "", fmt.Errorf("comfyui output is not an image (%s)", mimeType)
+	}
+
+	dataURL := fmt.Sprintf("data:%s;base64,%s", mimeType, base64.StdEncoding.EncodeToString(data))
+	return p.service.downloadAndSaveImage(dataURL)
+}
+
+// doComfyUIRequest sends a request and returns the body, treating non-200 responses as errors
+func doComfyUIRequest(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ComfyUI returned error status %d: %s", resp.StatusCode, string(body))
+	}
+	return body, nil
+}
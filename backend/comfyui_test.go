@@ -0,0 +1,320 @@
+package backend
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testComfyUIWorkflow = `{
+  "3": {"class_type": "KSampler", "inputs": {"seed": "{{seed}}", "steps": 20}},
+  "6": {"class_type": "CLIPTextEncode", "inputs": {"text": "{{prompt}}"}},
+  "7": {"class_type": "CLIPTextEncode", "inputs": {"text": "{{negative_prompt}}"}},
+  "10": {"class_type": "LoadImage", "inputs": {"image": "{{ref_image}}"}},
+  "11": {"class_type": "SaveImage", "inputs": {"filename_prefix": "seed_{{seed}}"}}
+}`
+
+// fakeComfyUI is a minimal ComfyUI server. Handlers can be replaced per test.
+type fakeComfyUI struct {
+	mu         sync.Mutex
+	uploads    []string               // Uploaded file names
+	submitted  map[string]interface{} // Last /prompt payload
+	polls      int                    // Number of /history requests
+	viewQuery  string                 // Query of the last /view request
+	pendingFor int                    // Polls answered with an empty history before the result
+	history    string                 // History entry returned once finished
+	submit     string                 // /prompt response
+	view       []byte                 // /view response
+	viewStatus int
+}
+
+func newFakeComfyUI(t *testing.T) (*fakeComfyUI, *httptest.Server) {
+	t.Helper()
+	fake := &fakeComfyUI{
+		history: `{"p1":{"outputs":{
+			"12":{"images":[{"filename":"preview.png","subfolder":"","type":"temp"}]},
+			"9":{"images":[{"filename":"out.png","subfolder":"run","type":"output"}]}
+		},"status":{"status_str":"success","completed":true}}}`,
+		submit:     `{"prompt_id":"p1","number":1,"node_errors":{}}`,
+		view:       testPNG(t),
+		viewStatus: http.StatusOK,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+
+		switch r.URL.Path {
+		case "/upload/image":
+			file, header, err := r.FormFile("image")
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			file.Close()
+			if r.FormValue("overwrite") != "true" {
+				http.Error(w, "overwrite not set", http.StatusBadRequest)
+				return
+			}
+			fake.uploads = append(fake.uploads, header.Filename)
+			json.NewEncoder(w).Encode(map[string]string{"name": header.Filename, "subfolder": "refs", "type": "input"})
+		case "/prompt":
+			body, _ := io.ReadAll(r.Body)
+			json.Unmarshal(body, &fake.submitted)
+			io.WriteString(w, fake.submit)
+		case "/history/p1":
+			fake.polls++
+			if fake.polls <= fake.pendingFor {
+				io.WriteString(w, `{}`)
+				return
+			}
+			io.WriteString(w, fake.history)
+		case "/view":
+			fake.viewQuery = r.URL.RawQuery
+			w.WriteHeader(fake.viewStatus)
+			w.Write(fake.view)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+// fastComfyUIPolling shortens the history poll interval for the duration of a test
+func fastComfyUIPolling(t *testing.T) {
+	t.Helper()
+	interval := comfyUIPollInterval
+	comfyUIPollInterval = 5 * time.Millisecond
+	t.Cleanup(func() { comfyUIPollInterval = interval })
+}
+
+func newTestComfyUIProvider(t *testing.T, baseURL string) *ComfyUIProvider {
+	t.Helper()
+	workflowPath := filepath.Join(t.TempDir(), "workflow.json")
+	if err := os.WriteFile(workflowPath, []byte(testComfyUIWorkflow), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return &ComfyUIProvider{
+		config:  &ComfyUIConfig{BaseURL: baseURL, WorkflowPath: workflowPath, Seed: 1234, TimeoutSeconds: 5},
+		service: newTestImageGenService(t),
+	}
+}
+
+func TestComfyUIGenerate(t *testing.T) {
+	fastComfyUIPolling(t)
+	fake, server := newFakeComfyUI(t)
+	fake.pendingFor = 2
+
+	provider := newTestComfyUIProvider(t, server.URL)
+	refImage := "data:image/png;base64," + base64.StdEncoding.EncodeToString(testPNG(t))
+	relPath, err := provider.Generate(ImageGenRequest{
+		Prompt:         "a cat",
+		NegativePrompt: "blurry",
+		RefImages:      []string{refImage},
+	})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	assertStoredImage(t, provider.service, relPath)
+
+	if len(fake.uploads) != 1 || !strings.HasPrefix(fake.uploads[0], "fm-doc-canvas_ref_1_") {
+		t.Errorf("uploads = %v, want one reference image", fake.uploads)
+	}
+	if fake.submitted["client_id"] != "fm-doc-canvas" {
+		t.Errorf("client_id = %v", fake.submitted["client_id"])
+	}
+
+	workflow, _ := fake.submitted["prompt"].(map[string]interface{})
+	inputs := func(id string) map[string]interface{} {
+		node, _ := workflow[id].(map[string]interface{})
+		in, _ := node["inputs"].(map[string]interface{})
+		return in
+	}
+	if seed := inputs("3")["seed"]; seed != 1234.0 {
+		t.Errorf("seed = %#v, want the number 1234", seed)
+	}
+	if text := inputs("6")["text"]; text != "a cat" {
+		t.Errorf("prompt = %v, want \"a cat\"", text)
+	}
+	if text := inputs("7")["text"]; text != "blurry" {
+		t.Errorf("negative prompt = %v, want \"blurry\"", text)
+	}
+	if image := inputs("10")["image"]; image != "refs/"+fake.uploads[0] {
+		t.Errorf("ref image = %v, want the uploaded name with its subfolder", image)
+	}
+	if prefix := inputs("11")["filename_prefix"]; prefix != "seed_1234" {
+		t.Errorf("filename_prefix = %v, want the seed inside a string", prefix)
+	}
+
+	if fake.polls != 3 {
+		t.Errorf("history polled %d times, want 3", fake.polls)
+	}
+	// The final output is preferred over the preview
+	if fake.viewQuery != "filename=out.png&subfolder=run&type=output" {
+		t.Errorf("/view query = %q", fake.viewQuery)
+	}
+}
+
+func TestComfyUIInlineWorkflow(t *testing.T) {
+	fastComfyUIPolling(t)
+	fake, server := newFakeComfyUI(t)
+
+	provider := &ComfyUIProvider{
+		config: &ComfyUIConfig{
+			BaseURL:      server.URL,
+			WorkflowPath: filepath.Join(t.TempDir(), "missing.json"),
+			Workflow:     json.RawMessage(`{"6":{"inputs":{"text":"inline {{prompt}}"}}}`),
+		},
+		service: newTestImageGenService(t),
+	}
+	if _, err := provider.Generate(ImageGenRequest{Prompt: "a cat"}); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	workflow, _ := fake.submitted["prompt"].(map[string]interface{})
+	node, _ := workflow["6"].(map[string]interface{})
+	if inputs, _ := node["inputs"].(map[string]interface{}); inputs["text"] != "inline a cat" {
+		t.Errorf("submitted workflow = %v, want the inline workflow", workflow)
+	}
+}
+
+func TestComfyUIWaitForOutputTimeout(t *testing.T) {
+	fastComfyUIPolling(t)
+	fake, server := newFakeComfyUI(t)
+	fake.pendingFor = 1 << 30
+
+	provider := newTestComfyUIProvider(t, server.URL)
+	start := time.Now()
+	_, err := provider.waitForOutput(&http.Client{}, server.URL, "p1", 50*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("waitForOutput error = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("waitForOutput took %s, want it to stop at the timeout", elapsed)
+	}
+	if fake.polls < 2 {
+		t.Errorf("history polled %d times, want polling until the timeout", fake.polls)
+	}
+}
+
+func TestComfyUIErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(fake *fakeComfyUI, provider *ComfyUIProvider)
+		wantErr string
+	}{
+		{
+			name:    "missing workflow path",
+			setup:   func(fake *fakeComfyUI, provider *ComfyUIProvider) { provider.config.WorkflowPath = "" },
+			wantErr: "workflow path is not set",
+		},
+		{
+			name: "workflow not in API format",
+			setup: func(fake *fakeComfyUI, provider *ComfyUIProvider) {
+				os.WriteFile(provider.config.WorkflowPath, []byte(`[1, 2`), 0644)
+			},
+			wantErr: "failed to parse comfyui workflow",
+		},
+		{
+			name: "node errors",
+			setup: func(fake *fakeComfyUI, provider *ComfyUIProvider) {
+				fake.submit = `{"prompt_id":"p1","node_errors":{"3":{"errors":["bad input"]}}}`
+			},
+			wantErr: "comfyui rejected the workflow",
+		},
+		{
+			name:    "no prompt id",
+			setup:   func(fake *fakeComfyUI, provider *ComfyUIProvider) { fake.submit = `{}` },
+			wantErr: "no prompt_id",
+		},
+		{
+			name: "workflow failed",
+			setup: func(fake *fakeComfyUI, provider *ComfyUIProvider) {
+				fake.history = `{"p1":{"outputs":{},"status":{"status_str":"error","completed":false}}}`
+			},
+			wantErr: "comfyui workflow failed",
+		},
+		{
+			name: "no output images",
+			setup: func(fake *fakeComfyUI, provider *ComfyUIProvider) {
+				fake.history = `{"p1":{"outputs":{},"status":{"status_str":"success","completed":true}}}`
+			},
+			wantErr: "produced no images",
+		},
+		{
+			name: "view error status",
+			setup: func(fake *fakeComfyUI, provider *ComfyUIProvider) {
+				fake.viewStatus = http.StatusNotFound
+				fake.view = []byte("missing")
+			},
+			wantErr: "error status 404",
+		},
+		{
+			name:    "view returns no image",
+			setup:   func(fake *fakeComfyUI, provider *ComfyUIProvider) { fake.view = []byte("<html></html>") },
+			wantErr: "not an image",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fastComfyUIPolling(t)
+			fake, server := newFakeComfyUI(t)
+			provider := newTestComfyUIProvider(t, server.URL)
+			tt.setup(fake, provider)
+
+			_, err := provider.Generate(ImageGenRequest{Prompt: "a cat"})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Generate error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestComfyUIUploadError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/upload/image" {
+			t.Errorf("unexpected request to %s after a failed upload", r.URL.Path)
+		}
+		http.Error(w, "disk full", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	provider := newTestComfyUIProvider(t, server.URL)
+	refImage := "data:image/png;base64," + base64.StdEncoding.EncodeToString(testPNG(t))
+	_, err := provider.Generate(ImageGenRequest{Prompt: "a cat", RefImages: []string{refImage}})
+	if err == nil || !strings.Contains(err.Error(), "failed to upload reference image") {
+		t.Fatalf("Generate error = %v, want an upload error", err)
+	}
+}
+
+func TestComfyUIConfigResolve(t *testing.T) {
+	workflowPath := filepath.Join(t.TempDir(), "workflow.json")
+	if err := os.WriteFile(workflowPath, []byte(testComfyUIWorkflow), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &ComfyUIConfig{WorkflowPath: workflowPath, Seed: -1}
+	resolved, err := cfg.resolve()
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	got := resolved.(*ComfyUIConfig)
+	if got.Seed < 0 {
+		t.Errorf("seed = %d, want a fixed seed", got.Seed)
+	}
+	if string(got.Workflow) != testComfyUIWorkflow {
+		t.Error("workflow contents were not inlined")
+	}
+	if cfg.Seed != -1 || cfg.Workflow != nil {
+		t.Error("resolve modified the original config")
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 )
 
 // LLMConfig holds credentials and settings for LLM access
@@ -18,7 +20,8 @@ type LLMConfig struct {
 
 // GenerationConfig holds settings for content generation
 type GenerationConfig struct {
-	SummaryMaxChars int `json:"summaryMaxChars"`
+	SummaryMaxChars    int  `json:"summaryMaxChars"`
+	AutoDescribeImages bool `json:"autoDescribeImages"` // Caption imported and generated images with the LLM
 }
 
 // OpenRouterConfig holds settings for OpenRouter
@@ -32,6 +35,10 @@ func (c *OpenRouterConfig) GetProvider() string {
 	return "openrouter"
 }
 
+func (c *OpenRouterConfig) GetModel() string {
+	return c.Model
+}
+
 // OpenAIConfig holds settings for OpenAI
 type OpenAIConfig struct {
 	BaseURL string `json:"baseURL"`
@@ -43,40 +50,128 @@ func (c *OpenAIConfig) GetProvider() string {
 	return "openai"
 }
 
+func (c *OpenAIConfig) GetModel() string {
+	return c.Model
+}
+
 // GoogleConfig holds settings for Google
 type GoogleConfig struct {
-	Model  string `json:"model"`
-	APIKey string `json:"apiKey"` // Sensitive information
+	BaseURL string `json:"baseURL"` // Default: "https://generativelanguage.googleapis.com/v1beta"
+	Model   string `json:"model"`
+	APIKey  string `json:"apiKey"` // Sensitive information
 }
 
 func (c *GoogleConfig) GetProvider() string {
 	return "google"
 }
 
+func (c *GoogleConfig) GetModel() string {
+	return c.Model
+}
+
 // XAIConfig holds settings for xAI
 type XAIConfig struct {
-	APIKey string `json:"apiKey"` // Sensitive information
-	Model  string `json:"model"`  // Default: "grok-imagine-image"
+	BaseURL string `json:"baseURL"` // Default: "https://api.x.ai/v1"
+	APIKey  string `json:"apiKey"`  // Sensitive information
+	Model   string `json:"model"`   // Default: "grok-imagine-image"
 }
 
 func (c *XAIConfig) GetProvider() string {
 	return "xai"
 }
 
+func (c *XAIConfig) GetModel() string {
+	return c.Model
+}
+
+// SDWebUIConfig holds settings for a local AUTOMATIC1111 / Forge Stable Diffusion WebUI (txt2img / img2img API)
+type SDWebUIConfig struct {
+	BaseURL           string  `json:"baseURL"`           // Default: "http://127.0.0.1:7860"
+	Model             string  `json:"model"`             // Checkpoint name; empty uses the currently loaded checkpoint
+	Steps             int     `json:"steps"`             // Default: 20
+	Width             int     `json:"width"`             // Default: 1024
+	Height            int     `json:"height"`            // Default: 1024
+	CFGScale          float64 `json:"cfgScale"`          // Default: 7
+	SamplerName       string  `json:"samplerName"`       // Default: "Euler a"
+	DenoisingStrength float64 `json:"denoisingStrength"` // img2img only. Default: 0.6
+	Seed              int64   `json:"seed"`              // -1 for a random seed
+}
+
+func (c *SDWebUIConfig) GetProvider() string {
+	return "sdwebui"
+}
+
+func (c *SDWebUIConfig) GetModel() string {
+	return c.Model
+}
+
+// ComfyUIConfig holds settings for a local ComfyUI server
+type ComfyUIConfig struct {
+	BaseURL        string `json:"baseURL"`        // Default: "http://127.0.0.1:8188"
+	WorkflowPath   string `json:"workflowPath"`   // Workflow JSON exported in API format, with {{prompt}}, {{seed}}, {{ref_image}} placeholders
+	Seed           int64  `json:"seed"`           // -1 for a random seed
+	TimeoutSeconds int    `json:"timeoutSeconds"` // Default: 300
+	// Workflow contents; when set they are used instead of reading WorkflowPath.
+	// Recorded in the provenance so a regeneration does not depend on the file.
+	Workflow json.RawMessage `json:"workflow,omitempty" schema:"-"`
+}
+
+func (c *ComfyUIConfig) GetProvider() string {
+	return "comfyui"
+}
+
+func (c *ComfyUIConfig) GetModel() string {
+	return filepath.Base(c.WorkflowPath)
+}
+
+// ExecConfig holds settings for an external command that generates images (see exec.go for the protocol)
+type ExecConfig struct {
+	Command        string   `json:"command"`        // Executable path
+	Args           []string `json:"args"`           // Additional command line arguments
+	Model          string   `json:"model"`          // Passed through to the command as-is
+	TimeoutSeconds int      `json:"timeoutSeconds"` // Default: 300
+}
+
+func (c *ExecConfig) GetProvider() string {
+	return "exec"
+}
+
+func (c *ExecConfig) GetModel() string {
+	return c.Model
+}
+
+// StylePreset is a named set of prompt additions and defaults for image generation
+type StylePreset struct {
+	Name           string `json:"name"`
+	PromptPrefix   string `json:"promptPrefix"`
+	PromptSuffix   string `json:"promptSuffix"`
+	NegativePrompt string `json:"negativePrompt"`
+	Size           string `json:"size"`    // "WxH", empty for the provider default
+	Quality        string `json:"quality"` // e.g. "low", "medium", "high"; empty for the provider default
+}
+
+// PromptEnhancementConfig controls the optional LLM rewrite of image prompts before generation
+type PromptEnhancementConfig struct {
+	Enabled bool   `json:"enabled"`
+	Style   string `json:"style"` // Optional style hint passed to the LLM, e.g. "flat illustration"
+}
+
 // ProviderConfig is an interface for provider-specific configurations
 type ProviderConfig interface {
 	// GetProvider returns the provider name
 	GetProvider() string
+	// GetModel returns the configured model name
+	GetModel() string
 }
 
 // ImageGenConfig holds credentials and settings for image generation
 type ImageGenConfig struct {
-	Provider      string          `json:"provider"`
-	DownloadPath  string          `json:"downloadPath"` // Default: "Image/" (resolved relative to executable)
-	OpenRouter    *OpenRouterConfig   `json:"openrouter,omitempty"`
-	OpenAI        *OpenAIConfig        `json:"openai,omitempty"`
-	Google        *GoogleConfig        `json:"google,omitempty"`
-	XAI           *XAIConfig           `json:"xai,omitempty"` // New: xAI support
+	Provider     string                    `json:"provider"`
+	DownloadPath string                    `json:"downloadPath"` // Default: "Image/" (resolved relative to executable)
+	Providers    map[string]ProviderConfig `json:"providers"`    // Settings of each provider, keyed by registration name
+
+	PromptEnhancement PromptEnhancementConfig `json:"promptEnhancement"`
+	StylePresets      []StylePreset           `json:"stylePresets"`
 
 	// For backward compatibility
 	BaseURL string `json:"baseURL,omitempty"`
@@ -84,40 +179,83 @@ type ImageGenConfig struct {
 	APIKey  string `json:"apiKey,omitempty"`
 }
 
-// GetProviderConfig returns the provider-specific configuration
+// GetProviderConfig returns the provider-specific configuration.
+// A provider without stored settings uses its defaults.
 func (c *ImageGenConfig) GetProviderConfig() (ProviderConfig, error) {
-	switch c.Provider {
-	case "openrouter":
-		if c.OpenRouter == nil {
-			return nil, fmt.Errorf("openrouter config is not set")
-		}
-		return c.OpenRouter, nil
-	case "openai":
-		if c.OpenAI == nil {
-			return nil, fmt.Errorf("openai config is not set")
-		}
-		return c.OpenAI, nil
-	case "google":
-		if c.Google == nil {
-			return nil, fmt.Errorf("google config is not set")
-		}
-		return c.Google, nil
-	case "xai":
-		if c.XAI == nil {
-			return nil, fmt.Errorf("xai config is not set")
-		}
-		return c.XAI, nil
-	default:
+	reg, ok := lookupImageProvider(c.Provider)
+	if !ok {
 		return nil, fmt.Errorf("unknown provider: %s", c.Provider)
 	}
+
+	if providerCfg, ok := c.Providers[c.Provider]; ok && providerCfg != nil {
+		if _, raw := providerCfg.(*rawProviderConfig); !raw {
+			return providerCfg, nil
+		}
+	}
+	return reg.Default(), nil
+}
+
+// setProviderConfig stores the settings of a provider. The map is copied first, as copies of
+// the configuration returned by ConfigService.GetConfig share it with the stored configuration.
+func (c *ImageGenConfig) setProviderConfig(name string, providerCfg ProviderConfig) {
+	providers := make(map[string]ProviderConfig, len(c.Providers)+1)
+	for key, value := range c.Providers {
+		providers[key] = value
+	}
+	providers[name] = providerCfg
+	c.Providers = providers
+}
+
+// withProviderModel returns a copy of the config that targets the given provider and model.
+// The provider-specific config is copied so the stored configuration is left untouched.
+func (c ImageGenConfig) withProviderModel(provider string, model string) ImageGenConfig {
+	c.Provider = provider
+	if providerCfg, err := c.GetProviderConfig(); err == nil {
+		c.setProviderConfig(provider, cloneWithModel(providerCfg, model))
+	}
+	return c
+}
+
+// applyRecordedSettings replaces the settings of the selected provider with settings recorded in a
+// provenance. Secrets are not recorded, so they are kept from the current settings.
+func (c *ImageGenConfig) applyRecordedSettings(recorded map[string]interface{}) error {
+	reg, ok := lookupImageProvider(c.Provider)
+	if !ok {
+		return fmt.Errorf("unsupported provider: %s", c.Provider)
+	}
+	current, err := c.GetProviderConfig()
+	if err != nil {
+		return err
+	}
+
+	settings := make(map[string]interface{}, len(recorded)+1)
+	for key, value := range recorded {
+		settings[key] = value
+	}
+	for _, secret := range secretValues(current) {
+		settings["apiKey"] = secret
+	}
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("failed to marshal recorded %s config: %w", c.Provider, err)
+	}
+	cfg, err := decodeProviderConfig(reg, data)
+	if err != nil {
+		return err
+	}
+	c.setProviderConfig(c.Provider, cfg)
+	return nil
 }
 
 // UnmarshalJSON implements custom unmarshaling for backward compatibility
 func (c *ImageGenConfig) UnmarshalJSON(data []byte) error {
-	// First, try to unmarshal into a temporary struct for backward compatibility
+	// First, try to unmarshal into a temporary struct for backward compatibility.
+	// Provider settings are decoded into the type registered for each provider.
 	type Alias ImageGenConfig
 	temp := &struct {
 		*Alias
+		Providers map[string]json.RawMessage `json:"providers"`
 	}{
 		Alias: (*Alias)(c),
 	}
@@ -126,31 +264,46 @@ func (c *ImageGenConfig) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
+	c.Providers = map[string]ProviderConfig{}
+	for name, raw := range temp.Providers {
+		reg, ok := lookupImageProvider(name)
+		if !ok {
+			c.Providers[name] = &rawProviderConfig{name: name, data: raw}
+			continue
+		}
+		providerCfg, err := decodeProviderConfig(reg, raw)
+		if err != nil {
+			return err
+		}
+		c.Providers[name] = providerCfg
+	}
+
+	// Configs saved before the providers map kept each provider's settings under its name
+	var legacy map[string]json.RawMessage
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return err
+	}
+	for _, reg := range registeredImageProviders() {
+		raw, ok := legacy[reg.Name]
+		if _, exists := c.Providers[reg.Name]; !ok || exists || string(raw) == "null" {
+			continue
+		}
+		providerCfg, err := decodeProviderConfig(reg, raw)
+		if err != nil {
+			return err
+		}
+		c.Providers[reg.Name] = providerCfg
+	}
+
+	// Configs saved before style presets existed get the built-in presets
+	if c.StylePresets == nil {
+		c.StylePresets = defaultStylePresets()
+	}
+
 	// If the old fields are set, migrate them to the new structure
 	if c.BaseURL != "" || c.Model != "" || c.APIKey != "" {
-		switch c.Provider {
-		case "openrouter":
-			c.OpenRouter = &OpenRouterConfig{
-				BaseURL: c.BaseURL,
-				Model:   c.Model,
-				APIKey:  c.APIKey,
-			}
-		case "openai":
-			c.OpenAI = &OpenAIConfig{
-				BaseURL: c.BaseURL,
-				Model:   c.Model,
-				APIKey:  c.APIKey,
-			}
-		case "google":
-			c.Google = &GoogleConfig{
-				Model:  c.Model,
-				APIKey: c.APIKey,
-			}
-		case "xai":
-			c.XAI = &XAIConfig{
-				Model:  c.Model,
-				APIKey: c.APIKey,
-			}
+		if reg, ok := lookupImageProvider(c.Provider); ok && reg.FromLegacy != nil {
+			c.Providers[reg.Name] = reg.FromLegacy(c.BaseURL, c.Model, c.APIKey)
 		}
 		// Clear the old fields
 		c.BaseURL = ""
@@ -161,17 +314,42 @@ func (c *ImageGenConfig) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// findStylePreset returns the style preset with the given name; an empty name selects no preset
+func (c *ImageGenConfig) findStylePreset(name string) (StylePreset, error) {
+	if name == "" {
+		return StylePreset{}, nil
+	}
+	for _, preset := range c.StylePresets {
+		if preset.Name == name {
+			return preset, nil
+		}
+	}
+	return StylePreset{}, fmt.Errorf("unknown style preset: %s", name)
+}
+
 // Config represents the application's local settings
 type Config struct {
-	LLM        LLMConfig        `json:"llm"`
-	Generation GenerationConfig `json:"generation"`
-	ImageGen   ImageGenConfig   `json:"imageGen"`
+	LLM            LLMConfig        `json:"llm"`
+	Generation     GenerationConfig `json:"generation"`
+	ImageGen       ImageGenConfig   `json:"imageGen"`
+	Import         ImportConfig     `json:"import"`
+	RecentCanvases []string         `json:"recentCanvases"` // Most recently used canvas files, newest first
+}
+
+// ImportConfig holds settings for importing files
+type ImportConfig struct {
+	PDFSplit      string `json:"pdfSplit"`      // "auto", "page" or "heading"
+	PDFPageImages bool   `json:"pdfPageImages"` // Import pages without text (scanned pages) as images
 }
 
+// maxRecentCanvases is the number of canvas files kept in Config.RecentCanvases
+const maxRecentCanvases = 10
+
 // ConfigService handles loading and saving application configuration
 type ConfigService struct {
 	config     *Config
 	configPath string
+	warnings   []string // Problems found while loading, shown to the user on startup
 	mu         sync.RWMutex
 }
 
@@ -196,8 +374,15 @@ func NewConfigService() (*ConfigService, error) {
 	// Load existing config if it exists
 	if _, err := os.Stat(configPath); err == nil {
 		if err := service.Load(); err != nil {
-			// If loading fails, we continue with default config
+			// If loading fails, we continue with default config.
+			// The unreadable file is moved aside so saving the defaults does not destroy it.
 			fmt.Printf("Warning: failed to load config: %v\n", err)
+			damagedPath := fmt.Sprintf("%s.damaged-%s", configPath, time.Now().Format("20060102_150405"))
+			if err := os.Rename(configPath, damagedPath); err != nil {
+				fmt.Printf("Warning: failed to move damaged config: %v\n", err)
+				damagedPath = configPath
+			}
+			service.warnings = append(service.warnings, fmt.Sprintf("The settings could not be loaded and the defaults are used. The old file was kept as %s.", damagedPath))
 		}
 	} else {
 		// Save default config if it doesn't exist
@@ -209,6 +394,11 @@ func NewConfigService() (*ConfigService, error) {
 	return service, nil
 }
 
+// ConfigDir returns the directory holding the configuration file and other app data
+func (s *ConfigService) ConfigDir() string {
+	return filepath.Dir(s.configPath)
+}
+
 // GetConfig returns a copy of the current configuration
 func (s *ConfigService) GetConfig() Config {
 	s.mu.RLock()
@@ -226,7 +416,9 @@ func (s *ConfigService) Save(cfg *Config) error {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	if err := os.WriteFile(s.configPath, data, 0644); err != nil {
+	// Keep the previous settings as config.json.bak, e.g. to recover API keys after a crash
+	valid := func(data []byte) bool { return validateConfigData(data) == nil }
+	if err := writeFileWithBackup(s.configPath, data, 0644, valid); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
@@ -234,15 +426,63 @@ func (s *ConfigService) Save(cfg *Config) error {
 	return nil
 }
 
+// SaveSettings persists settings edited in the frontend.
+// The recent canvas list is managed by the backend and kept as is.
+func (s *ConfigService) SaveSettings(cfg *Config) error {
+	cfg.RecentCanvases = s.GetConfig().RecentCanvases
+	return s.Save(cfg)
+}
+
+// AddRecentCanvas moves a canvas file to the top of the recent list
+func (s *ConfigService) AddRecentCanvas(path string) error {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	cfg := s.GetConfig()
+	recent := []string{path}
+	for _, p := range cfg.RecentCanvases {
+		if p != path && len(recent) < maxRecentCanvases {
+			recent = append(recent, p)
+		}
+	}
+	cfg.RecentCanvases = recent
+	return s.Save(&cfg)
+}
+
+// RecentCanvases returns the recent canvas files, newest first.
+// Files that no longer exist are pruned from the list.
+func (s *ConfigService) RecentCanvases() []string {
+	cfg := s.GetConfig()
+	recent := []string{}
+	for _, p := range cfg.RecentCanvases {
+		if _, err := os.Stat(p); err == nil {
+			recent = append(recent, p)
+		}
+	}
+
+	if len(recent) != len(cfg.RecentCanvases) {
+		cfg.RecentCanvases = recent
+		if err := s.Save(&cfg); err != nil {
+			fmt.Printf("Warning: failed to prune recent canvases: %v\n", err)
+		}
+	}
+	return recent
+}
+
 // Load reads the configuration from disk
 func (s *ConfigService) Load() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	data, err := os.ReadFile(s.configPath)
+	data, recovered, err := readFileWithBackup(s.configPath, 0644, validateConfigData)
 	if err != nil {
 		return fmt.Errorf("failed to read config file: %w", err)
 	}
+	if recovered {
+		fmt.Printf("Warning: config file was damaged and has been restored from %s\n", backupPath(s.configPath))
+		s.warnings = append(s.warnings, "The settings file was damaged and has been restored from its backup. Recent changes to the settings may be lost.")
+	}
 
 	var cfg Config
 	if err := json.Unmarshal(data, &cfg); err != nil {
@@ -253,6 +493,22 @@ func (s *ConfigService) Load() error {
 	return nil
 }
 
+// Warnings returns the problems found while loading the configuration
+func (s *ConfigService) Warnings() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]string{}, s.warnings...)
+}
+
+// validateConfigData checks that data is a readable config file
+func validateConfigData(data []byte) error {
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+	return nil
+}
+
 // ResolveDownloadPath returns the absolute path for the configured download directory
 func (s *ConfigService) ResolveDownloadPath() (string, error) {
 	s.mu.RLock()
@@ -305,8 +561,37 @@ func (s *ConfigService) ResolveImagePath(src string) (string, error) {
 	return path, nil
 }
 
+// ResolveContainedImagePath resolves a relative image path like ResolveImagePath,
+// but rejects traversal and any result that lies outside the download directory.
+func (s *ConfigService) ResolveContainedImagePath(src string) (string, error) {
+	if strings.Contains(src, "..") {
+		return "", fmt.Errorf("path traversal is not allowed")
+	}
+
+	resolvedPath, err := s.ResolveImagePath(src)
+	if err != nil {
+		return "", err
+	}
+
+	downloadPath, err := s.ResolveDownloadPath()
+	if err != nil {
+		return "", err
+	}
+
+	// Verify the resolved path is still within downloadPath
+	relPath, err := filepath.Rel(downloadPath, resolvedPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get relative path: %w", err)
+	}
+	if strings.HasPrefix(relPath, "..") || relPath == ".." {
+		return "", fmt.Errorf("resolved path is outside the allowed directory")
+	}
+
+	return resolvedPath, nil
+}
+
 func defaultConfig() *Config {
-	return &Config{
+	cfg := &Config{
 		LLM: LLMConfig{
 			BaseURL: "https://api.openai.com/v1",
 			Model:   "gpt-4o-mini",
@@ -318,24 +603,40 @@ func defaultConfig() *Config {
 		ImageGen: ImageGenConfig{
 			Provider:     "openrouter",
 			DownloadPath: "Image/",
-			OpenRouter: &OpenRouterConfig{
-				BaseURL: "https://openrouter.ai/api/v1",
-				Model:   "sourceful/riverflow-v2-standard-preview",
-				APIKey:  "",
-			},
-			OpenAI: &OpenAIConfig{
-				BaseURL: "https://api.openai.com/v1",
-				Model:   "gpt-image-1.5",
-				APIKey:  "",
-			},
-			Google: &GoogleConfig{
-				Model:  "gemini-2.5-flash-image",
-				APIKey: "",
-			},
-			XAI: &XAIConfig{
-				Model:  "grok-imagine-image",
-				APIKey: "",
-			},
+			Providers:    map[string]ProviderConfig{},
+			StylePresets: defaultStylePresets(),
+		},
+		Import: ImportConfig{
+			PDFSplit: PDFSplitAuto,
+		},
+	}
+
+	// Each registered provider contributes its default settings
+	for _, reg := range registeredImageProviders() {
+		cfg.ImageGen.Providers[reg.Name] = reg.Default()
+	}
+	return cfg
+}
+
+func defaultStylePresets() []StylePreset {
+	return []StylePreset{
+		{
+			Name:           "flat illustration",
+			PromptPrefix:   "Flat vector illustration of",
+			PromptSuffix:   "clean shapes, limited color palette, minimal shading",
+			NegativePrompt: "photorealistic, 3d render, noise, text, watermark",
+		},
+		{
+			Name:           "technical diagram",
+			PromptPrefix:   "Clean technical diagram of",
+			PromptSuffix:   "white background, thin lines, schematic style, clear structure",
+			NegativePrompt: "photo, clutter, shadows, blurry, watermark",
+		},
+		{
+			Name:           "photo",
+			PromptPrefix:   "High quality photograph of",
+			PromptSuffix:   "natural lighting, sharp focus, realistic detail",
+			NegativePrompt: "illustration, cartoon, drawing, deformed, blurry, text, watermark",
 		},
 	}
 }
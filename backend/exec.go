@@ -0,0 +1,181 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// execProtocolVersion is sent to external commands so they can detect incompatible changes
+const execProtocolVersion = 1
+
+// execStderrTail limits how much of the command's stderr is included in error messages
+const execStderrTail = 2000
+
+func init() {
+	RegisterImageProvider(ImageProviderRegistration{
+		Name:        "exec",
+		DisplayName: "External Command",
+		Default: func() ProviderConfig {
+			return &ExecConfig{
+				TimeoutSeconds: 300,
+			}
+		},
+		New: func(cfg ProviderConfig, baseCfg *ImageGenConfig, service *ImageGenService) ImageGenProvider {
+			return &ExecProvider{
+				config:  cfg.(*ExecConfig),
+				baseCfg: baseCfg,
+				service: service,
+			}
+		},
+	})
+}
+
+// ExecProvider generates images by running an external command.
+//
+// The command receives a JSON request on stdin:
+//
+//	{"version": 1, "prompt": "...", "context": "...", "negativePrompt": "...",
+//	 "size": "1024x1024", "quality": "...", "model": "...",
+//	 "refImages": [{"mimeType": "image/png", "data": "<base64>"}]}
+//
+// and must write a JSON response to stdout, either
+//
+//	{"mimeType": "image/png", "data": "<base64>"}
+//
+// or {"error": "message"} on failure.
+type ExecProvider struct {
+	config  *ExecConfig
+	baseCfg *ImageGenConfig
+	service *ImageGenService
+}
+
+type execImage struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"` // Base64 encoded image bytes
+}
+
+type execRequest struct {
+	Version        int         `json:"version"`
+	Prompt         string      `json:"prompt"`
+	Context        string      `json:"context"`
+	NegativePrompt string      `json:"negativePrompt"`
+	Size           string      `json:"size"`    // "WxH", empty for the command's default
+	Quality        string      `json:"quality"` // Empty for the command's default
+	Model          string      `json:"model"`
+	RefImages      []execImage `json:"refImages"`
+}
+
+type execResponse struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"`
+	Error    string `json:"error"`
+}
+
+// Capabilities implements ImageGenProvider.Capabilities for ExecProvider
+func (p *ExecProvider) Capabilities() ImageGenCapabilities {
+	return ImageGenCapabilities{
+		MaxReferenceImages:     -1,
+		Formats:                []string{"image/png", "image/jpeg", "image/gif", "image/webp"},
+		SupportsEdit:           true,
+		SupportsNegativePrompt: true,
+	}
+}
+
+// Generate implements ImageGenProvider.Generate for ExecProvider
+func (p *ExecProvider) Generate(request ImageGenRequest) (string, error) {
+	if p.config.Command == "" {
+		return "", fmt.Errorf("exec command is not set")
+	}
+
+	payload := execRequest{
+		Version:        execProtocolVersion,
+		Prompt:         request.Prompt,
+		Context:        request.Context,
+		NegativePrompt: request.NegativePrompt,
+		Size:           request.Size,
+		Quality:        request.Quality,
+		Model:          p.config.Model,
+		RefImages:      make([]execImage, 0, len(request.RefImages)),
+	}
+	for _, img := range request.RefImages {
+		mimeType, data, err := splitDataURL(img)
+		if err != nil {
+			return "", fmt.Errorf("invalid reference image: %w", err)
+		}
+		payload.RefImages = append(payload.RefImages, execImage{MimeType: mimeType, Data: data})
+	}
+
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request payload: %w", err)
+	}
+
+	timeout := time.Duration(valueOrDefault(p.config.TimeoutSeconds, 300)) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// Blank entries come from the one-per-line settings field and are dropped
+	args := make([]string, 0, len(p.config.Args))
+	for _, arg := range p.config.Args {
+		if strings.TrimSpace(arg) != "" {
+			args = append(args, arg)
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, p.config.Command, args...)
+	cmd.Stdin = bytes.NewReader(jsonData)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("exec command timed out after %s%s", timeout, formatStderr(stderr.String()))
+		}
+		// A command may still report a structured error before exiting non-zero
+		var response execResponse
+		if json.Unmarshal(stdout.Bytes(), &response) == nil && response.Error != "" {
+			return "", fmt.Errorf("exec command failed: %s", response.Error)
+		}
+		return "", fmt.Errorf("exec command failed: %w%s", err, formatStderr(stderr.String()))
+	}
+
+	var response execResponse
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		return "", fmt.Errorf("failed to unmarshal exec command output: %w%s", err, formatStderr(stderr.String()))
+	}
+	if response.Error != "" {
+		return "", fmt.Errorf("exec command failed: %s", response.Error)
+	}
+	if response.Data == "" {
+		return "", fmt.Errorf("no image data in exec command output")
+	}
+
+	// Validate the payload before handing it to downloadAndSaveImage
+	if _, err := base64.StdEncoding.DecodeString(response.Data); err != nil {
+		return "", fmt.Errorf("invalid base64 image data in exec command output: %w", err)
+	}
+
+	mimeType := valueOrDefault(response.MimeType, "image/png")
+	dataURL := fmt.Sprintf("data:%s;base64,%s", mimeType, response.Data)
+	return p.service.downloadAndSaveImage(dataURL)
+}
+
+// formatStderr returns the tail of a command's stderr for inclusion in an error message
+func formatStderr(stderr string) string {
+	stderr = strings.TrimSpace(stderr)
+	if stderr == "" {
+		return ""
+	}
+	if len(stderr) > execStderrTail {
+		stderr = "..." + stderr[len(stderr)-execStderrTail:]
+	}
+	return fmt.Sprintf("\nstderr: %s", stderr)
+}
@@ -2,24 +2,66 @@ package backend
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
+	"fm-doc-canvas/backend/canvas"
+
+	"github.com/fsnotify/fsnotify"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
-// ImportFileResult represents the result of importing a file
+// ImportedItem is a node to create from an imported file
+type ImportedItem struct {
+	Type    string `json:"type"`            // "text" or "image"
+	Content string `json:"content"`         // For text: file content. For image: relative path.
+	Title   string `json:"title,omitempty"` // Suggested summary, e.g. the page or heading of a PDF part
+}
+
+// ImportFileResult represents the result of importing a file. Most files yield one item;
+// a PDF yields one item per page or section.
 type ImportFileResult struct {
-	Type    string `json:"type"`    // "text" or "image"
-	Content string `json:"content"` // For text: file content. For image: relative path.
+	Items    []ImportedItem `json:"items"`
+	Chain    bool           `json:"chain"`    // Items are consecutive parts of one document, to be connected in order
+	Warnings []string       `json:"warnings"` // Parts that could not be imported
+}
+
+// LoadCanvasResult is a canvas read from a file, migrated to the current version.
+// Canvas is nil when the user cancelled the dialog.
+type LoadCanvasResult struct {
+	Path     string           `json:"path"`
+	Canvas   *canvas.Canvas   `json:"canvas"`
+	Warnings []canvas.Warning `json:"warnings"` // Migrations and removed nodes or edges
+}
+
+// SaveCanvasResult is the outcome of writing a canvas. Path is empty when the user cancelled the dialog
+// or when nothing was written because of a conflict.
+type SaveCanvasResult struct {
+	Path     string            `json:"path"`
+	Warnings []canvas.Warning  `json:"warnings"`           // Nodes or edges that were not saved
+	Conflict *CanvasFileChange `json:"conflict,omitempty"` // The file was changed outside the app since it was loaded
 }
 
 // FileService handles native file dialogs and file system I/O for canvas data
 type FileService struct {
-	ctx           context.Context
-	configService *ConfigService
+	ctx               context.Context
+	configService     *ConfigService
+	assetStore        *AssetStore
+	imageAssetService *ImageAssetService
+
+	mu          sync.Mutex
+	currentPath string           // Canvas file being edited, empty for a new canvas
+	loaded      *canvasFileState // Content of currentPath as last read or written by the app
+	watcher     *fsnotify.Watcher
+	watchedDir  string
+	notifyTimer *time.Timer
+	notified    string // Last external change reported, to report each change once
 }
 
 // ExportImage opens a save dialog and copies the image from internal storage to the selected path
@@ -29,29 +71,11 @@ func (s *FileService) ExportImage(src string) (string, error) {
 	}
 
 	// 1. Resolve source path with security checks
-	if strings.Contains(src, "..") {
-		return "", fmt.Errorf("path traversal is not allowed")
-	}
-
-	sourcePath, err := s.configService.ResolveImagePath(src)
-	if err != nil {
-		return "", err
-	}
-
-	downloadPath, err := s.configService.ResolveDownloadPath()
+	sourcePath, err := s.configService.ResolveContainedImagePath(src)
 	if err != nil {
 		return "", err
 	}
 
-	// Verify the resolved path is still within downloadPath
-	relPath, err := filepath.Rel(downloadPath, sourcePath)
-	if err != nil {
-		return "", fmt.Errorf("failed to get relative path: %w", err)
-	}
-	if strings.HasPrefix(relPath, "..") || relPath == ".." {
-		return "", fmt.Errorf("resolved path is outside the allowed directory")
-	}
-
 	// Check if source file exists
 	if _, err := os.Stat(sourcePath); os.IsNotExist(err) {
 		return "", fmt.Errorf("source image not found: %s", sourcePath)
@@ -61,7 +85,7 @@ func (s *FileService) ExportImage(src string) (string, error) {
 	ext := filepath.Ext(src)
 	options := runtime.SaveDialogOptions{
 		Title:           "Export Image",
-		DefaultFilename: filepath.Base(src),
+		DefaultFilename: s.assetStore.FriendlyName(src),
 		Filters: []runtime.FileFilter{
 			{
 				DisplayName: fmt.Sprintf("Image Files (*%s)", ext),
@@ -94,9 +118,11 @@ func (s *FileService) ExportImage(src string) (string, error) {
 }
 
 // NewFileService creates a new instance of FileService
-func NewFileService(configService *ConfigService) *FileService {
+func NewFileService(configService *ConfigService, assetStore *AssetStore, imageAssetService *ImageAssetService) *FileService {
 	return &FileService{
-		configService: configService,
+		configService:     configService,
+		assetStore:        assetStore,
+		imageAssetService: imageAssetService,
 	}
 }
 
@@ -105,74 +131,144 @@ func (s *FileService) SetContext(ctx context.Context) {
 	s.ctx = ctx
 }
 
-// ImportFile handles importing a file (text or image) from a file path
+// ImportFile handles importing a file from a file path. Images are copied into the asset store;
+// documents (DOCX, HTML, CSV), JSON/YAML and source code are converted to Markdown text,
+// and PDFs are split into a chain of text items by page or heading (see ImportConfig).
+// Text in Shift_JIS, EUC-JP or UTF-16 is converted to UTF-8.
 func (s *FileService) ImportFile(filePath string) (ImportFileResult, error) {
 	// Determine file type based on extension
 	ext := strings.ToLower(filepath.Ext(filePath))
 
-	var result ImportFileResult
+	var item ImportedItem
 
 	switch ext {
-	case ".txt", ".md":
-		// Read text file content
-		content, err := os.ReadFile(filePath)
+	case ".pdf":
+		importConfig := s.configService.GetConfig().Import
+		return s.importPDF(filePath, importConfig.PDFSplit, importConfig.PDFPageImages)
+
+	case ".png", ".jpg", ".jpeg", ".webp":
+		input, err := readImportFile(filePath, maxImportImageSize)
 		if err != nil {
-			return result, fmt.Errorf("failed to read text file: %w", err)
+			return ImportFileResult{}, err
 		}
-		result.Type = "text"
-		result.Content = string(content)
-	
-	case ".png", ".jpg", ".jpeg", ".webp":
-		// Resolve the download path
-		downloadPath, err := s.configService.ResolveDownloadPath()
+
+		// Store by content hash so repeated imports of the same file share one asset
+		relPath, err := s.assetStore.Put(input, ext, filepath.Base(filePath))
 		if err != nil {
-			return result, err
+			return ImportFileResult{}, fmt.Errorf("failed to write imported image: %w", err)
 		}
 
-		importPath := filepath.Join(downloadPath, "Import")
+		item.Type = "image"
+		// Relative path from the downloadPath (forward slashes for web compatibility)
+		item.Content = relPath
 
-		// Ensure the import directory exists
-		if err := os.MkdirAll(importPath, 0755); err != nil {
-			return result, fmt.Errorf("failed to create import directory: %w", err)
+	default:
+		content, err := convertImportText(filePath, ext)
+		if err != nil {
+			return ImportFileResult{}, err
 		}
+		item.Type = "text"
+		item.Content = content
+	}
 
-		// Generate a unique filename to avoid collisions
-		filename := filepath.Base(filePath)
-		ext := filepath.Ext(filename)
-		nameWithoutExt := strings.TrimSuffix(filename, ext)
-		targetFilename := fmt.Sprintf("%s_%d%s", nameWithoutExt, os.Getpid(), ext)
-		targetPath := filepath.Join(importPath, targetFilename)
+	return ImportFileResult{Items: []ImportedItem{item}, Warnings: []string{}}, nil
+}
+
+// convertImportText reads a non-image file as Markdown text
+func convertImportText(filePath string, ext string) (string, error) {
+	switch ext {
+	case ".txt", ".md", ".markdown":
+		return readImportText(filePath, maxImportTextSize)
 
-		// Copy the file
-		input, err := os.ReadFile(filePath)
+	case ".docx":
+		data, err := readImportFile(filePath, maxImportDocumentSize)
 		if err != nil {
-			return result, fmt.Errorf("failed to read source image: %w", err)
+			return "", err
 		}
+		return docxToMarkdown(data)
 
-		if err := os.WriteFile(targetPath, input, 0644); err != nil {
-			return result, fmt.Errorf("failed to write imported image: %w", err)
+	case ".html", ".htm":
+		text, err := readImportText(filePath, maxImportDocumentSize)
+		if err != nil {
+			return "", err
 		}
+		return htmlToMarkdown(text)
 
-		result.Type = "image"
-		// Return the relative path from the downloadPath (use forward slashes for web compatibility)
-		result.Content = "Import/" + targetFilename
-	
-	default:
-		return result, fmt.Errorf("unsupported file type: %s", ext)
+	case ".csv", ".tsv":
+		text, err := readImportText(filePath, maxImportTextSize)
+		if err != nil {
+			return "", err
+		}
+		comma := ','
+		if ext == ".tsv" {
+			comma = '\t'
+		}
+		return csvToMarkdown(text, comma)
+	}
+
+	if lang, ok := codeLanguages[ext]; ok {
+		text, err := readImportText(filePath, maxImportTextSize)
+		if err != nil {
+			return "", err
+		}
+		return fencedCode(text, lang), nil
 	}
+	return "", fmt.Errorf("unsupported file type: %s", ext)
+}
+
+// CurrentCanvasPath returns the canvas file being edited, or an empty string for a new canvas
+func (s *FileService) CurrentCanvasPath() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.currentPath
+}
+
+// SetCurrentCanvasPath sets the canvas file that SaveCanvas writes to; an empty path starts a new canvas.
+// The file's content on disk becomes the base for detecting external changes.
+func (s *FileService) SetCurrentCanvasPath(path string) {
+	s.setCurrent(path, readCanvasFileState(path))
+}
+
+// setCurrent makes path the current file with known as its content and watches it
+func (s *FileService) setCurrent(path string, known *canvasFileState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.currentPath = path
+	s.loaded = known
+	s.notified = ""
+	s.watchCurrentLocked()
+}
 
-	return result, nil
+// SaveCanvas writes the canvas to the current file without a dialog.
+// A new canvas has no file yet and is saved with SaveCanvasAs.
+// If the file was changed outside the app since it was loaded, nothing is written and the conflict is
+// returned; the frontend then calls OverwriteCanvas or MergeCanvasWithFile.
+func (s *FileService) SaveCanvas(c canvas.Canvas) (SaveCanvasResult, error) {
+	filePath := s.CurrentCanvasPath()
+	if filePath == "" {
+		return s.SaveCanvasAs(c)
+	}
+	if change := s.externalChange(); change != nil && !change.Removed {
+		return SaveCanvasResult{Conflict: change}, nil
+	}
+	return s.writeCanvas(filePath, &c)
 }
 
-// SaveCanvasToFile opens a save dialog and writes the JSON data to the selected path
-func (s *FileService) SaveCanvasToFile(jsonData string) (string, error) {
+// SaveCanvasAs opens a save dialog and writes the validated canvas to the selected path,
+// which becomes the current file
+func (s *FileService) SaveCanvasAs(c canvas.Canvas) (SaveCanvasResult, error) {
 	if s.ctx == nil {
-		return "", fmt.Errorf("context not initialized")
+		return SaveCanvasResult{}, fmt.Errorf("context not initialized")
+	}
+
+	defaultFilename := "canvas.json"
+	if current := s.CurrentCanvasPath(); current != "" {
+		defaultFilename = filepath.Base(current)
 	}
 
 	options := runtime.SaveDialogOptions{
 		Title:           "Save Canvas",
-		DefaultFilename: "canvas.json",
+		DefaultFilename: defaultFilename,
 		Filters: []runtime.FileFilter{
 			{
 				DisplayName: "JSON Files (*.json)",
@@ -183,19 +279,46 @@ func (s *FileService) SaveCanvasToFile(jsonData string) (string, error) {
 
 	filePath, err := runtime.SaveFileDialog(s.ctx, options)
 	if err != nil {
-		return "", fmt.Errorf("failed to open save dialog: %w", err)
+		return SaveCanvasResult{}, fmt.Errorf("failed to open save dialog: %w", err)
 	}
 
 	if filePath == "" {
-		return "", nil // User cancelled
+		return SaveCanvasResult{}, nil // User cancelled
 	}
 
-	err = os.WriteFile(filePath, []byte(jsonData), 0644)
+	return s.writeCanvas(filePath, &c)
+}
+
+// writeCanvas validates and writes the canvas, records it in the file's history
+// and makes filePath the current file
+func (s *FileService) writeCanvas(filePath string, c *canvas.Canvas) (SaveCanvasResult, error) {
+	data, warnings, err := canvas.Marshal(c)
 	if err != nil {
-		return "", fmt.Errorf("failed to write file: %w", err)
+		return SaveCanvasResult{}, err
 	}
 
-	return filePath, nil
+	// Replace the file atomically and keep the previous version as <file>.bak
+	err = writeFileWithBackup(filePath, data, 0644, func(current []byte) bool {
+		return validateCanvasData(current) == nil
+	})
+	if err != nil {
+		return SaveCanvasResult{}, fmt.Errorf("failed to write file: %w", err)
+	}
+	// Record the written content first, so the watcher does not report this save as an external change
+	s.openedCanvas(filePath, data)
+
+	if err := recordCanvasVersion(filePath, data); err != nil {
+		fmt.Printf("Warning: failed to record canvas version: %v\n", err)
+	}
+	return SaveCanvasResult{Path: filePath, Warnings: warnings}, nil
+}
+
+// openedCanvas makes filePath, just read or written with data, the current file and records it in the recent list
+func (s *FileService) openedCanvas(filePath string, data []byte) {
+	s.setCurrent(filePath, newCanvasFileState(filePath, data))
+	if err := s.configService.AddRecentCanvas(filePath); err != nil {
+		fmt.Printf("Warning: failed to update recent canvases: %v\n", err)
+	}
 }
 
 // ExportMarkdown opens a save dialog and writes the markdown content to the selected path
@@ -224,7 +347,7 @@ func (s *FileService) ExportMarkdown(content string) (string, error) {
 		return "", nil // User cancelled
 	}
 
-	err = os.WriteFile(filePath, []byte(content), 0644)
+	err = writeFileAtomic(filePath, []byte(content), 0644)
 	if err != nil {
 		return "", fmt.Errorf("failed to write file: %w", err)
 	}
@@ -252,10 +375,10 @@ func (s *FileService) GetImageFileURL(src string) (string, error) {
 	return fileURL, nil
 }
 
-// LoadCanvasFromFile opens an open dialog and reads the content of the selected file
-func (s *FileService) LoadCanvasFromFile() (string, error) {
+// LoadCanvasFromFile opens an open dialog and reads the selected canvas file
+func (s *FileService) LoadCanvasFromFile() (LoadCanvasResult, error) {
 	if s.ctx == nil {
-		return "", fmt.Errorf("context not initialized")
+		return LoadCanvasResult{}, fmt.Errorf("context not initialized")
 	}
 
 	options := runtime.OpenDialogOptions{
@@ -270,17 +393,83 @@ func (s *FileService) LoadCanvasFromFile() (string, error) {
 
 	filePath, err := runtime.OpenFileDialog(s.ctx, options)
 	if err != nil {
-		return "", fmt.Errorf("failed to open file dialog: %w", err)
+		return LoadCanvasResult{}, fmt.Errorf("failed to open file dialog: %w", err)
 	}
 
 	if filePath == "" {
-		return "", nil // User cancelled
+		return LoadCanvasResult{}, nil // User cancelled
+	}
+
+	return s.OpenCanvasPath(filePath)
+}
+
+// OpenCanvasPath reads the canvas file at filePath, e.g. from the recent list, and makes it the current file.
+// A damaged file is restored from its backup, which is reported as a warning.
+func (s *FileService) OpenCanvasPath(filePath string) (LoadCanvasResult, error) {
+	data, recovered, err := readFileWithBackup(filePath, 0644, validateCanvasData)
+	if err != nil {
+		var pathErr *fs.PathError
+		if errors.As(err, &pathErr) {
+			return LoadCanvasResult{}, fmt.Errorf("failed to read file: %w", err)
+		}
+		return LoadCanvasResult{}, err // Neither the file nor its backup could be parsed
+	}
+	c, warnings, err := canvas.Parse(data)
+	if err != nil {
+		return LoadCanvasResult{}, err
 	}
+	if recovered {
+		warnings = append([]canvas.Warning{{
+			Code:    canvas.WarnRecovered,
+			Message: fmt.Sprintf("%s was damaged and has been restored from %s", filepath.Base(filePath), filepath.Base(backupPath(filePath))),
+		}}, warnings...)
+	}
+
+	s.openedCanvas(filePath, data)
+	return LoadCanvasResult{Path: filePath, Canvas: c, Warnings: warnings}, nil
+}
 
+// GetRecentCanvases returns the recently opened or saved canvas files that still exist, newest first
+func (s *FileService) GetRecentCanvases() []string {
+	return s.configService.RecentCanvases()
+}
+
+// readCanvasFile reads and migrates a canvas file
+func readCanvasFile(filePath string) (*canvas.Canvas, []canvas.Warning, error) {
 	data, err := os.ReadFile(filePath)
 	if err != nil {
-		return "", fmt.Errorf("failed to read file: %w", err)
+		return nil, nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	return canvas.Parse(data)
+}
+
+// validateCanvasData checks that data is a canvas file that can be loaded
+func validateCanvasData(data []byte) error {
+	_, _, err := canvas.Parse(data)
+	return err
+}
+
+// SelectCanvasFiles opens a dialog for choosing one or more canvas files, e.g. for ScanAssets
+func (s *FileService) SelectCanvasFiles() ([]string, error) {
+	if s.ctx == nil {
+		return nil, fmt.Errorf("context not initialized")
+	}
+
+	options := runtime.OpenDialogOptions{
+		Title: "Select Canvases",
+		Filters: []runtime.FileFilter{
+			{
+				DisplayName: "JSON Files (*.json)",
+				Pattern:     "*.json",
+			},
+		},
+	}
+
+	filePaths, err := runtime.OpenMultipleFilesDialog(s.ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file dialog: %w", err)
 	}
 
-	return string(data), nil
+	return filePaths, nil
 }
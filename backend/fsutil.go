@@ -0,0 +1,88 @@
+package backend
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// writeFileAtomic writes data to a temporary file next to path, syncs it and renames it over path,
+// so readers see either the old or the new content even if the app crashes mid-write
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpPath := f.Name()
+	defer os.Remove(tmpPath) // No-op once renamed
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync temporary file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary file: %w", err)
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return fmt.Errorf("failed to set file permissions: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace file: %w", err)
+	}
+	return nil
+}
+
+// backupPath returns the file holding the previous version of path
+func backupPath(path string) string {
+	return path + ".bak"
+}
+
+// writeFileWithBackup atomically replaces path with data, first keeping the current content as path.bak.
+// The backup is only rotated when valid accepts the current content, so a damaged file never
+// replaces a good backup.
+func writeFileWithBackup(path string, data []byte, perm os.FileMode, valid func([]byte) bool) error {
+	if current, err := os.ReadFile(path); err == nil && valid(current) {
+		if err := writeFileAtomic(backupPath(path), current, perm); err != nil {
+			return fmt.Errorf("failed to write backup: %w", err)
+		}
+	}
+	return writeFileAtomic(path, data, perm)
+}
+
+// readFileWithBackup reads path and checks it with valid. If path cannot be read or is not valid,
+// the backup is used instead and written back to path; recovered reports that this happened.
+// The damaged content is kept as path.damaged-<timestamp>.
+// When neither can be used, the error of path is returned.
+func readFileWithBackup(path string, perm os.FileMode, valid func([]byte) error) (data []byte, recovered bool, err error) {
+	data, err = os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, false, err // Removed, not damaged: the backup is not restored
+	}
+	if err == nil {
+		if err = valid(data); err == nil {
+			return data, false, nil
+		}
+	}
+
+	backup, backupErr := os.ReadFile(backupPath(path))
+	if backupErr != nil || valid(backup) != nil {
+		return nil, false, err
+	}
+
+	// Keep the damaged file for inspection before restoring the backup over it
+	if data != nil {
+		damagedPath := fmt.Sprintf("%s.damaged-%s", path, time.Now().Format("20060102_150405"))
+		if writeErr := writeFileAtomic(damagedPath, data, perm); writeErr != nil {
+			fmt.Printf("Warning: failed to keep damaged file %s: %v\n", path, writeErr)
+		}
+	}
+	if writeErr := writeFileAtomic(path, backup, perm); writeErr != nil {
+		fmt.Printf("Warning: failed to restore %s from backup: %v\n", path, writeErr)
+	}
+	return backup, true, nil
+}
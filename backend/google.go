@@ -10,19 +10,54 @@ import (
 	"time"
 )
 
+// defaultGoogleBaseURL is the Gemini API endpoint used when GoogleConfig.BaseURL is empty
+const defaultGoogleBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+
+func init() {
+	RegisterImageProvider(ImageProviderRegistration{
+		Name:        "google",
+		DisplayName: "Google",
+		Default: func() ProviderConfig {
+			return &GoogleConfig{
+				BaseURL: defaultGoogleBaseURL,
+				Model:   "gemini-2.5-flash-image",
+			}
+		},
+		FromLegacy: func(baseURL string, model string, apiKey string) ProviderConfig {
+			return &GoogleConfig{
+				Model:  model,
+				APIKey: apiKey,
+			}
+		},
+		New: func(cfg ProviderConfig, baseCfg *ImageGenConfig, service *ImageGenService) ImageGenProvider {
+			return &GoogleProvider{
+				config:  cfg.(*GoogleConfig),
+				baseCfg: baseCfg,
+				service: service,
+			}
+		},
+	})
+}
+
 type GoogleProvider struct {
 	config    *GoogleConfig
 	baseCfg   *ImageGenConfig
 	service   *ImageGenService
 }
 
+// Capabilities implements ImageGenProvider.Capabilities for GoogleProvider
+func (p *GoogleProvider) Capabilities() ImageGenCapabilities {
+	return ImageGenCapabilities{
+		MaxReferenceImages: -1,
+		Formats:            []string{"image/png"},
+		SupportsEdit:       true,
+	}
+}
+
 // Generate implements ImageGenProvider.Generate for GoogleProvider
-func (p *GoogleProvider) Generate(prompt string, contextData string, refImages []string) (string, error) {
+func (p *GoogleProvider) Generate(request ImageGenRequest) (string, error) {
 	// Combine prompt and context for better generation
-	fullPrompt := prompt
-	if contextData != "" {
-		fullPrompt = fmt.Sprintf("Context information:\n%s\n\nBased on the above context, generate an image for: %s", contextData, prompt)
-	}
+	fullPrompt := request.FullPrompt()
 
 	// Prepare the parts for the request payload
 	parts := []map[string]interface{}{
@@ -30,7 +65,7 @@ func (p *GoogleProvider) Generate(prompt string, contextData string, refImages [
 	}
 
 	// Add reference images if provided
-	for _, imgURL := range refImages {
+	for _, imgURL := range request.RefImages {
 		// Extract base64 data from data URL
 		if strings.HasPrefix(imgURL, "data:image/") {
 			// Extract MIME type and base64 data from data URL
@@ -69,13 +104,16 @@ func (p *GoogleProvider) Generate(prompt string, contextData string, refImages [
 	}
 
 	// Create HTTP request
-	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s", p.config.Model, p.config.APIKey)
+	baseURL := strings.TrimSuffix(valueOrDefault(p.config.BaseURL, defaultGoogleBaseURL), "/")
+	url := fmt.Sprintf("%s/models/%s:generateContent", baseURL, p.config.Model)
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", fmt.Errorf("failed to create HTTP request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	// The key is sent as a header so it does not appear in URLs, logs or error messages
+	req.Header.Set("x-goog-api-key", p.config.APIKey)
 
 	// Send request
 	client := &http.Client{Timeout: 180 * time.Second}
@@ -2,82 +2,424 @@ package backend
 
 import (
 	"fmt"
-	"os"
+	"strings"
+	"time"
 )
 
 type ImageGenService struct {
-	configService *ConfigService
+	configService     *ConfigService
+	assetStore        *AssetStore
+	llmService        *LLMService
+	imageAssetService *ImageAssetService
 }
 
 type ImageGenProvider interface {
-	Generate(prompt string, contextData string, refImages []string) (string, error)
+	Generate(request ImageGenRequest) (string, error)
+	// Capabilities describes what the provider's API accepts
+	Capabilities() ImageGenCapabilities
 }
 
-func NewImageGenService(configService *ConfigService) *ImageGenService {
+// ImageGenRequest is a single image generation request passed to a provider
+type ImageGenRequest struct {
+	Prompt         string
+	Context        string   // Markdown context from the canvas; may be empty
+	NegativePrompt string   // Only set for providers that support a negative prompt; otherwise folded into Prompt
+	RefImages      []string // Reference images as data URLs
+	Size           string   // "WxH", empty for the provider default
+	Quality        string   // e.g. "low", "medium", "high"; empty for the provider default
+}
+
+// FullPrompt combines the prompt and the context into a single text for providers that take one prompt
+func (r ImageGenRequest) FullPrompt() string {
+	if r.Context == "" {
+		return r.Prompt
+	}
+	return fmt.Sprintf("Context information:\n%s\n\nBased on the above context, generate an image for: %s", r.Context, r.Prompt)
+}
+
+// ImageGenCapabilities describes the features supported by an image generation provider
+type ImageGenCapabilities struct {
+	MaxReferenceImages     int      `json:"maxReferenceImages"`     // -1 for unlimited, 0 when reference images are not supported
+	Sizes                  []string `json:"sizes"`                  // Supported "WxH" sizes; empty when the size is not selectable or free-form
+	Qualities              []string `json:"qualities"`              // Supported quality values; empty when the quality is not selectable or free-form
+	Formats                []string `json:"formats"`                // MIME types of the generated images
+	SupportsEdit           bool     `json:"supportsEdit"`           // Reference images are used as the image to edit
+	SupportsMask           bool     `json:"supportsMask"`           // Inpainting with a mask image
+	SupportsMultiple       bool     `json:"supportsMultiple"`       // More than one image per request (n > 1)
+	SupportsNegativePrompt bool     `json:"supportsNegativePrompt"` // A separate negative prompt
+}
+
+// resolvableProviderConfig is implemented by provider configs with inputs that are not fixed by the
+// settings alone, such as a random seed or a workflow file. resolve returns a copy with those inputs
+// fixed, which is used for the request and recorded in the provenance so it can be replayed.
+type resolvableProviderConfig interface {
+	resolve() (ProviderConfig, error)
+}
+
+func NewImageGenService(configService *ConfigService, assetStore *AssetStore, llmService *LLMService, imageAssetService *ImageAssetService) *ImageGenService {
 	return &ImageGenService{
-		configService: configService,
+		configService:     configService,
+		assetStore:        assetStore,
+		llmService:        llmService,
+		imageAssetService: imageAssetService,
 	}
 }
 
-func (s *ImageGenService) getProvider() (ImageGenProvider, error) {
-	cfg := s.configService.GetConfig()
-	providerCfg, err := cfg.ImageGen.GetProviderConfig()
+func (s *ImageGenService) getProvider(imageGenCfg *ImageGenConfig) (ImageGenProvider, error) {
+	providerCfg, err := imageGenCfg.GetProviderConfig()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get provider config: %w", err)
 	}
 
-	switch provider := providerCfg.(type) {
-	case *OpenRouterConfig:
-		return &OpenRouterProvider{
-			config:    provider,
-			baseCfg:   &cfg.ImageGen,
-			service:   s,
-		}, nil
-	case *OpenAIConfig:
-		return &OpenAIProvider{
-			config:    provider,
-			baseCfg:   &cfg.ImageGen,
-			service:   s,
-		}, nil
-	case *GoogleConfig:
-		return &GoogleProvider{
-			config:  provider,
-			baseCfg: &cfg.ImageGen,
-			service: s,
-		}, nil
-	case *XAIConfig:
-		return &XAIProvider{
-			config:  provider,
-			baseCfg: &cfg.ImageGen,
-			service: s,
-		}, nil
-	default:
-		return nil, fmt.Errorf("unsupported provider: %T", providerCfg)
-	}
-}
-
-// GenerateImage generates an image using the configured provider
-func (s *ImageGenService) GenerateImage(prompt string, contextData string, refImages []string) (string, error) {
-	provider, err := s.getProvider()
+	reg, ok := lookupImageProvider(imageGenCfg.Provider)
+	if !ok {
+		return nil, fmt.Errorf("unsupported provider: %s", imageGenCfg.Provider)
+	}
+	return reg.New(providerCfg, imageGenCfg, s), nil
+}
+
+// GetImageProviders returns the registered image providers and their config schema
+func (s *ImageGenService) GetImageProviders() []ImageProviderInfo {
+	return ListImageProviders()
+}
+
+// GetImageProviderCapabilities returns the capabilities of a provider, or of the configured provider when name is empty
+func (s *ImageGenService) GetImageProviderCapabilities(name string) (ImageGenCapabilities, error) {
+	cfg := s.configService.GetConfig()
+	if name == "" {
+		name = cfg.ImageGen.Provider
+	}
+
+	reg, ok := lookupImageProvider(name)
+	if !ok {
+		return ImageGenCapabilities{}, fmt.Errorf("unsupported provider: %s", name)
+	}
+
+	imageGenCfg := cfg.ImageGen
+	imageGenCfg.Provider = name
+	providerCfg, err := imageGenCfg.GetProviderConfig()
+	if err != nil {
+		return ImageGenCapabilities{}, err
+	}
+	return reg.New(providerCfg, &imageGenCfg, s).Capabilities(), nil
+}
+
+// ImageGenResult is the outcome of an image generation request
+type ImageGenResult struct {
+	Src            string `json:"src"`            // Relative path of the saved image
+	OriginalPrompt string `json:"originalPrompt"` // Prompt as entered by the user
+	Prompt         string `json:"prompt"`         // Prompt sent to the provider (LLM rewrite and style preset applied)
+	NegativePrompt string `json:"negativePrompt"`
+	Enhanced       bool   `json:"enhanced"`
+	StylePreset    string `json:"stylePreset"`
+}
+
+// imageGeneration holds the inputs of a generation before they are turned into a provider request
+type imageGeneration struct {
+	prompt          string // Prompt as entered by the user
+	enhancedPrompt  string // LLM rewrite of the prompt and context; empty when not enhanced
+	contextData     string
+	negativePrompt  string
+	preset          StylePreset
+	refImages       []string
+	regeneratedFrom string
+}
+
+// GenerateImage generates an image using the configured provider.
+// stylePreset names one of the configured style presets (empty for none).
+// When prompt enhancement is enabled, the LLM first rewrites the prompt and context into a visual prompt.
+func (s *ImageGenService) GenerateImage(prompt string, contextData string, refImages []string, stylePreset string) (ImageGenResult, error) {
+	cfg := s.configService.GetConfig()
+
+	preset, err := cfg.ImageGen.findStylePreset(stylePreset)
+	if err != nil {
+		return ImageGenResult{}, err
+	}
+
+	gen := imageGeneration{
+		prompt:         prompt,
+		contextData:    contextData,
+		negativePrompt: preset.NegativePrompt,
+		preset:         preset,
+		refImages:      refImages,
+	}
+	if cfg.ImageGen.PromptEnhancement.Enabled {
+		style := joinNonEmpty(", ", preset.Name, cfg.ImageGen.PromptEnhancement.Style)
+		enhanced, negative, err := s.llmService.EnhanceImagePrompt(prompt, contextData, style)
+		if err != nil {
+			// Enhancement is an optional pre-step; fall back to the original prompt
+			fmt.Printf("Warning: failed to enhance image prompt: %v\n", err)
+		} else {
+			gen.enhancedPrompt = enhanced
+			gen.negativePrompt = joinNonEmpty(", ", negative, preset.NegativePrompt)
+		}
+	}
+
+	return s.generate(&cfg.ImageGen, gen)
+}
+
+// RegenerateImage replays the request recorded in the provenance of an existing image.
+// An enhanced prompt and the style preset are replayed as recorded, without asking the LLM again.
+// For an image derived by TransformImage, the parent is regenerated and the operations are applied to it.
+func (s *ImageGenService) RegenerateImage(src string) (ImageGenResult, error) {
+	return s.regenerateImage(src, map[string]bool{})
+}
+
+// regenerateImage regenerates src; visited holds the edited images already on the way
+// from the image the user asked for, so a cyclic lineage is reported instead of recursed into
+func (s *ImageGenService) regenerateImage(src string, visited map[string]bool) (ImageGenResult, error) {
+	prov, err := readImageProvenance(s.configService, src)
 	if err != nil {
-		return "", fmt.Errorf("failed to get image generation provider: %w", err)
+		return ImageGenResult{}, err
+	}
+	if prov.Parent != "" {
+		if visited[src] {
+			return ImageGenResult{}, fmt.Errorf("image lineage contains a cycle at: %s", src)
+		}
+		visited[src] = true
+		return s.regenerateDerivedImage(src, prov, visited)
+	}
+	if prov.Provider == "" {
+		return ImageGenResult{}, fmt.Errorf("image was not generated by a provider: %s", src)
 	}
 
-	return provider.Generate(prompt, contextData, refImages)
+	refImages, err := s.loadReferenceImages(prov.RefImages)
+	if err != nil {
+		return ImageGenResult{}, err
+	}
+
+	gen := imageGeneration{
+		prompt:         prov.Prompt,
+		enhancedPrompt: prov.EnhancedPrompt,
+		contextData:    prov.Context,
+		negativePrompt: prov.NegativePrompt, // Already includes the preset's negative prompt
+		preset: StylePreset{
+			Name:         stringParameter(prov.Parameters, "stylePreset"),
+			PromptPrefix: stringParameter(prov.Parameters, "promptPrefix"),
+			PromptSuffix: stringParameter(prov.Parameters, "promptSuffix"),
+			Size:         stringParameter(prov.Parameters, "size"),
+			Quality:      stringParameter(prov.Parameters, "quality"),
+		},
+		refImages:       refImages,
+		regeneratedFrom: src,
+	}
+
+	cfg := s.configService.GetConfig()
+	replayCfg := cfg.ImageGen.withProviderModel(prov.Provider, prov.Model)
+	if recorded, ok := prov.Parameters["providerConfig"].(map[string]interface{}); ok {
+		if err := replayCfg.applyRecordedSettings(recorded); err != nil {
+			return ImageGenResult{}, err
+		}
+	}
+	return s.generate(&replayCfg, gen)
 }
 
-func (s *ImageGenService) resolveDownloadPath() (string, error) {
-	absPath, err := s.configService.ResolveDownloadPath()
+// regenerateDerivedImage regenerates the parent of an edited image and replays the edit on the new parent
+func (s *ImageGenService) regenerateDerivedImage(src string, prov *ImageProvenance, visited map[string]bool) (ImageGenResult, error) {
+	result, err := s.regenerateImage(prov.Parent, visited)
+	if err != nil {
+		return ImageGenResult{}, err
+	}
+
+	relPath, err := s.imageAssetService.TransformImage(result.Src, prov.Operations)
 	if err != nil {
-		return "", err
+		return ImageGenResult{}, fmt.Errorf("failed to replay the edit on the regenerated image: %w", err)
 	}
+	result.Src = relPath
 
-	// Create download directory if it doesn't exist
-	if _, err := os.Stat(absPath); os.IsNotExist(err) {
-		if err := os.MkdirAll(absPath, 0755); err != nil {
-			return "", fmt.Errorf("failed to create download directory: %w", err)
+	if derived, err := readImageProvenance(s.configService, relPath); err == nil {
+		derived.RegeneratedFrom = src
+		if err := s.writeProvenance(relPath, derived); err != nil {
+			fmt.Printf("Warning: failed to write image provenance: %v\n", err)
 		}
 	}
+	return result, nil
+}
 
-	return absPath, nil
+// generate runs a single generation request and records its provenance next to the saved image
+func (s *ImageGenService) generate(imageGenCfg *ImageGenConfig, gen imageGeneration) (ImageGenResult, error) {
+	providerCfg, err := imageGenCfg.GetProviderConfig()
+	if err != nil {
+		return ImageGenResult{}, fmt.Errorf("failed to get image generation provider: %w", err)
+	}
+	if resolvable, ok := providerCfg.(resolvableProviderConfig); ok {
+		// Fix the seed and similar inputs up front so the provenance describes this exact request
+		if providerCfg, err = resolvable.resolve(); err != nil {
+			return ImageGenResult{}, err
+		}
+		resolvedCfg := *imageGenCfg
+		resolvedCfg.setProviderConfig(imageGenCfg.Provider, providerCfg)
+		imageGenCfg = &resolvedCfg
+	}
+
+	provider, err := s.getProvider(imageGenCfg)
+	if err != nil {
+		return ImageGenResult{}, fmt.Errorf("failed to get image generation provider: %w", err)
+	}
+	caps := provider.Capabilities()
+
+	// Reject rather than silently drop reference images the provider cannot use
+	if maxRefs := caps.MaxReferenceImages; maxRefs >= 0 && len(gen.refImages) > maxRefs {
+		return ImageGenResult{}, fmt.Errorf("%s supports at most %d reference images, but %d were given", imageGenCfg.Provider, maxRefs, len(gen.refImages))
+	}
+	if gen.preset.Size != "" && len(caps.Sizes) > 0 && !containsString(caps.Sizes, gen.preset.Size) {
+		return ImageGenResult{}, fmt.Errorf("%s does not support size %s (supported: %s)", imageGenCfg.Provider, gen.preset.Size, strings.Join(caps.Sizes, ", "))
+	}
+	if gen.preset.Quality != "" && len(caps.Qualities) > 0 && !containsString(caps.Qualities, gen.preset.Quality) {
+		return ImageGenResult{}, fmt.Errorf("%s does not support quality %s (supported: %s)", imageGenCfg.Provider, gen.preset.Quality, strings.Join(caps.Qualities, ", "))
+	}
+
+	request := ImageGenRequest{
+		Prompt:         applyStylePreset(valueOrDefault(gen.enhancedPrompt, gen.prompt), gen.preset),
+		Context:        gen.contextData,
+		NegativePrompt: gen.negativePrompt,
+		RefImages:      gen.refImages,
+		Size:           gen.preset.Size,
+		Quality:        gen.preset.Quality,
+	}
+	if gen.enhancedPrompt != "" {
+		// An enhanced prompt already incorporates the context
+		request.Context = ""
+	}
+	result := ImageGenResult{
+		OriginalPrompt: gen.prompt,
+		Prompt:         request.Prompt,
+		NegativePrompt: gen.negativePrompt,
+		Enhanced:       gen.enhancedPrompt != "",
+		StylePreset:    gen.preset.Name,
+	}
+	if !caps.SupportsNegativePrompt {
+		request.Prompt = foldNegativePrompt(request.Prompt, request.NegativePrompt)
+		request.NegativePrompt = ""
+	}
+
+	relPath, err := provider.Generate(request)
+	if err != nil {
+		// Providers may echo request details (including credentials) back in error bodies
+		return ImageGenResult{}, redactError(err, secretValues(providerCfg))
+	}
+	result.Src = relPath
+
+	// Provenance is best effort: a failure here must not discard the generated image
+	params := presetParameters(gen.preset)
+	if settings := recordedSettings(providerCfg); settings != nil {
+		if params == nil {
+			params = map[string]interface{}{}
+		}
+		params["providerConfig"] = settings
+	}
+	prov := ImageProvenance{
+		Image:           relPath,
+		CreatedAt:       time.Now().Format(time.RFC3339),
+		Provider:        providerCfg.GetProvider(),
+		Model:           providerCfg.GetModel(),
+		Prompt:          gen.prompt,
+		Context:         gen.contextData,
+		EnhancedPrompt:  gen.enhancedPrompt,
+		NegativePrompt:  gen.negativePrompt,
+		Parameters:      params,
+		RegeneratedFrom: gen.regeneratedFrom,
+	}
+	prov.RefImages, err = s.saveReferenceImages(gen.refImages)
+	if err != nil {
+		fmt.Printf("Warning: failed to save reference images: %v\n", err)
+	}
+	if err := s.writeProvenance(relPath, &prov); err != nil {
+		fmt.Printf("Warning: failed to write image provenance: %v\n", err)
+	}
+
+	return result, nil
+}
+
+// applyStylePreset wraps a prompt with the prefix and suffix of a style preset
+func applyStylePreset(prompt string, preset StylePreset) string {
+	prompt = joinNonEmpty(" ", strings.TrimSpace(preset.PromptPrefix), strings.TrimSpace(prompt))
+	return joinNonEmpty(", ", prompt, strings.TrimSpace(preset.PromptSuffix))
+}
+
+// presetParameters returns the generation parameters contributed by a style preset, for provenance
+func presetParameters(preset StylePreset) map[string]interface{} {
+	params := map[string]interface{}{}
+	for key, value := range map[string]string{
+		"stylePreset":  preset.Name,
+		"promptPrefix": preset.PromptPrefix,
+		"promptSuffix": preset.PromptSuffix,
+		"size":         preset.Size,
+		"quality":      preset.Quality,
+	} {
+		if value != "" {
+			params[key] = value
+		}
+	}
+	if len(params) == 0 {
+		return nil
+	}
+	return params
+}
+
+// stringParameter returns a string value from provenance parameters, or "" when missing
+func stringParameter(params map[string]interface{}, key string) string {
+	value, _ := params[key].(string)
+	return value
+}
+
+// foldNegativePrompt appends a negative prompt to the prompt text for providers without a negative prompt field
+func foldNegativePrompt(prompt string, negativePrompt string) string {
+	if strings.TrimSpace(negativePrompt) == "" {
+		return prompt
+	}
+	return fmt.Sprintf("%s\n\nAvoid: %s", prompt, negativePrompt)
+}
+
+// joinNonEmpty joins the non-empty values with sep
+func joinNonEmpty(sep string, values ...string) string {
+	parts := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != "" {
+			parts = append(parts, v)
+		}
+	}
+	return strings.Join(parts, sep)
+}
+
+// parseImageSize parses a "WxH" size string
+func parseImageSize(size string) (int, int, bool) {
+	var width, height int
+	if _, err := fmt.Sscanf(strings.ToLower(size), "%dx%d", &width, &height); err != nil || width <= 0 || height <= 0 {
+		return 0, 0, false
+	}
+	return width, height, true
+}
+
+// redactedError hides secrets in the message of the wrapped error
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// redactError replaces every occurrence of the given secrets in the error message with "[REDACTED]"
+func redactError(err error, secrets []string) error {
+	if err == nil {
+		return nil
+	}
+
+	msg := err.Error()
+	redacted := msg
+	for _, secret := range secrets {
+		if secret != "" {
+			redacted = strings.ReplaceAll(redacted, secret, "[REDACTED]")
+		}
+	}
+	if redacted == msg {
+		return err
+	}
+	return &redactedError{msg: redacted, err: err}
 }
@@ -1,35 +1,52 @@
 package backend
 
 import (
+	"bytes"
 	"encoding/base64"
 	"fmt"
+	"image"
+	"io"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 )
 
+const (
+	// maxRemoteImageBytes limits the size of images downloaded from provider URLs
+	maxRemoteImageBytes = 50 << 20
+	// remoteImageTimeout limits the time spent downloading an image from a provider URL
+	remoteImageTimeout = 120 * time.Second
+)
+
 type ImageAssetService struct {
 	configService *ConfigService
+	assetStore    *AssetStore
+	llmService    *LLMService
+	hashCache     *fileHashCache
+	openCanvas    func() string // Canvas file being edited; set with SetOpenCanvasSource
 }
 
 // NewImageAssetService creates a new instance of ImageAssetService
-func NewImageAssetService(configService *ConfigService) *ImageAssetService {
+func NewImageAssetService(configService *ConfigService, assetStore *AssetStore, llmService *LLMService) *ImageAssetService {
 	return &ImageAssetService{
 		configService: configService,
+		assetStore:    assetStore,
+		llmService:    llmService,
+		hashCache:     newFileHashCache(),
 	}
 }
 
-// downloadAndSaveImage downloads and saves an image from a data URL
-func (s *ImageGenService) downloadAndSaveImage(dataURL string) (string, error) {
-	// Extract base64 data from data URL
+// decodeDataURL splits an image data URL into its MIME type and decoded bytes
+func decodeDataURL(dataURL string) (string, []byte, error) {
 	if !strings.HasPrefix(dataURL, "data:image/") {
-		return "", fmt.Errorf("invalid data URL format")
+		return "", nil, fmt.Errorf("invalid data URL format")
 	}
 
 	parts := strings.Split(dataURL, ",")
 	if len(parts) != 2 {
-		return "", fmt.Errorf("invalid data URL format")
+		return "", nil, fmt.Errorf("invalid data URL format")
 	}
 
 	// Extract MIME type
@@ -39,42 +56,118 @@ func (s *ImageGenService) downloadAndSaveImage(dataURL string) (string, error) {
 	// Decode base64 data
 	data, err := base64.StdEncoding.DecodeString(parts[1])
 	if err != nil {
-		return "", fmt.Errorf("failed to decode base64 data: %w", err)
+		return "", nil, fmt.Errorf("failed to decode base64 data: %w", err)
 	}
 
-	// Generate unique filename
-	downloadDir, err := s.resolveDownloadPath()
+	return mimeType, data, nil
+}
+
+// extensionForMIME returns the file extension (without dot) used to store an image of the given MIME type
+func extensionForMIME(mimeType string) string {
+	switch mimeType {
+	case "image/jpeg":
+		return "jpg"
+	case "image/gif":
+		return "gif"
+	case "image/webp":
+		return "webp"
+	default:
+		return "png"
+	}
+}
+
+// mimeForExtension returns the MIME type for an image file extension (with dot)
+func mimeForExtension(ext string) string {
+	switch strings.ToLower(ext) {
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	case ".gif":
+		return "image/gif"
+	case ".webp":
+		return "image/webp"
+	default:
+		return "image/png"
+	}
+}
+
+// imageFileToDataURL reads an image file and encodes it as a data URL
+func imageFileToDataURL(filePath string) (string, error) {
+	data, err := os.ReadFile(filePath)
 	if err != nil {
-		return "", fmt.Errorf("failed to resolve download path: %w", err)
+		return "", fmt.Errorf("failed to read image file: %w", err)
 	}
 
-	ext := "png"
-	if mimeType == "image/jpeg" {
-		ext = "jpg"
-	} else if mimeType == "image/gif" {
-		ext = "gif"
-	} else if mimeType == "image/webp" {
-		ext = "webp"
+	mimeType := mimeForExtension(filepath.Ext(filePath))
+	b64Data := base64.StdEncoding.EncodeToString(data)
+	return fmt.Sprintf("data:%s;base64,%s", mimeType, b64Data), nil
+}
+
+// downloadAndSaveImage saves an image given as a data URL or an http(s) URL to the asset store
+func (s *ImageGenService) downloadAndSaveImage(imageURL string) (string, error) {
+	var mimeType string
+	var data []byte
+	var err error
+	if strings.HasPrefix(imageURL, "http://") || strings.HasPrefix(imageURL, "https://") {
+		mimeType, data, err = fetchRemoteImage(imageURL)
+	} else {
+		mimeType, data, err = decodeDataURL(imageURL)
+	}
+	if err != nil {
+		return "", err
 	}
 
-	filename := fmt.Sprintf("image_%s.%s", time.Now().Format("20060102_150405"), ext)
-	fullPath := filepath.Join(downloadDir, filename)
+	// Store by content hash; the timestamped name is kept as the friendly name
+	ext := extensionForMIME(mimeType)
+	friendlyName := fmt.Sprintf("image_%s.%s", time.Now().Format("20060102_150405"), ext)
 
-	// Save image to file
-	if err := os.WriteFile(fullPath, data, 0644); err != nil {
+	relPath, err := s.assetStore.Put(data, ext, friendlyName)
+	if err != nil {
 		return "", fmt.Errorf("failed to save image: %w", err)
 	}
 
-	// Return relative path from download directory with forward slashes
-	relPath, err := filepath.Rel(downloadDir, fullPath)
+	return relPath, nil
+}
+
+// fetchRemoteImage downloads an image hosted by a provider.
+// The MIME type is sniffed from the content (the Content-Type header is not trusted),
+// and the bytes must fully decode as an image before they are returned, so a truncated or
+// corrupt body is never stored as an asset.
+func fetchRemoteImage(imageURL string) (string, []byte, error) {
+	client := &http.Client{Timeout: remoteImageTimeout}
+	resp, err := client.Get(imageURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to download image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("image download returned error status %d", resp.StatusCode)
+	}
+	if resp.ContentLength > maxRemoteImageBytes {
+		return "", nil, fmt.Errorf("image is too large (%d bytes, limit %d)", resp.ContentLength, maxRemoteImageBytes)
+	}
+
+	// Read one byte past the limit to detect oversized bodies without a Content-Length
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteImageBytes+1))
 	if err != nil {
-		return filename, nil
+		return "", nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	if int64(len(data)) > maxRemoteImageBytes {
+		return "", nil, fmt.Errorf("image is too large (limit %d bytes)", maxRemoteImageBytes)
 	}
 
-	// Convert backslashes to forward slashes for web compatibility
-	relPath = strings.ReplaceAll(relPath, "\\", "/")
+	mimeType := http.DetectContentType(data)
+	switch mimeType {
+	case "image/png", "image/jpeg", "image/gif", "image/webp":
+	default:
+		return "", nil, fmt.Errorf("downloaded content is not a supported image (%s)", mimeType)
+	}
 
-	return relPath, nil
+	if _, _, err := image.Decode(bytes.NewReader(data)); err != nil {
+		return "", nil, fmt.Errorf("downloaded image is corrupt: %w", err)
+	}
+
+	return mimeType, data, nil
 }
 
 // GetImageDataURL gets the data URL for an image file
@@ -85,30 +178,28 @@ func (s *ImageAssetService) GetImageDataURL(src string) (string, error) {
 		return "", err
 	}
 
-	// Read image file
-	data, err := os.ReadFile(filePath)
+	return imageFileToDataURL(filePath)
+}
+
+// GetImageProvenance returns the generation provenance recorded for an image
+func (s *ImageAssetService) GetImageProvenance(src string) (ImageProvenance, error) {
+	prov, err := readImageProvenance(s.configService, src)
 	if err != nil {
-		return "", fmt.Errorf("failed to read image file: %w", err)
+		return ImageProvenance{}, err
 	}
+	return *prov, nil
+}
 
-	// Determine MIME type based on file extension
-	ext := strings.ToLower(filepath.Ext(filePath))
-	mimeType := "image/png"
-
-	switch ext {
-	case ".jpg", ".jpeg":
-		mimeType = "image/jpeg"
-	case ".gif":
-		mimeType = "image/gif"
-	case ".webp":
-		mimeType = "image/webp"
+// GetImageAssetURL returns the AssetServer URL ("/assets/...") for a relative image path
+func (s *ImageAssetService) GetImageAssetURL(src string) (string, error) {
+	filePath, err := s.configService.ResolveContainedImagePath(src)
+	if err != nil {
+		return "", err
 	}
 
-	// Encode data to base64
-	b64Data := base64.StdEncoding.EncodeToString(data)
-
-	// Create data URL
-	dataURL := fmt.Sprintf("data:%s;base64,%s", mimeType, b64Data)
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		return "", fmt.Errorf("image file does not exist: %s", src)
+	}
 
-	return dataURL, nil
+	return assetURL(src), nil
 }
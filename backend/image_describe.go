@@ -0,0 +1,73 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ImageDescription is a caption and a longer description of an image, generated by the vision-capable LLM
+type ImageDescription struct {
+	Caption     string `json:"caption"`     // One short sentence, suitable as alt text
+	Description string `json:"description"` // Several sentences describing content, text and layout
+}
+
+// describeThumbnailEdge returns the longest edge of the image sent to the LLM for a detail level
+func describeThumbnailEdge(detail string) int {
+	if detail == "detailed" {
+		return 1024
+	}
+	return 512
+}
+
+// DescribeImage asks the LLM for a caption and description of an image.
+// detail is "brief" (default) or "detailed"; it controls the description length and the image resolution sent.
+func (s *ImageAssetService) DescribeImage(src string, detail string) (ImageDescription, error) {
+	if s.llmService == nil {
+		return ImageDescription{}, fmt.Errorf("LLM service is not available")
+	}
+
+	// A downscaled copy keeps the request small; the LLM does not need full resolution
+	dataURL, err := s.GetImageThumbnail(src, describeThumbnailEdge(detail))
+	if err != nil {
+		return ImageDescription{}, fmt.Errorf("failed to prepare image: %w", err)
+	}
+
+	length := "2-3 sentences"
+	if detail == "detailed" {
+		length = "one or two paragraphs, including any visible text, diagrams and layout"
+	}
+	prompt := "Describe the attached image. " +
+		"Write a caption of one short sentence usable as alt text, and a description of " + length + ". " +
+		`Respond with JSON only: {"caption": "...", "description": "..."}`
+
+	content, err := s.llmService.GenerateTextWithImages(prompt, "", []string{dataURL})
+	if err != nil {
+		return ImageDescription{}, fmt.Errorf("failed to describe image: %w", err)
+	}
+	return parseImageDescription(content)
+}
+
+// parseImageDescription extracts the caption and description from a DescribeImage response.
+// A plain text answer is split into its first line (caption) and the rest (description).
+func parseImageDescription(content string) (ImageDescription, error) {
+	content = strings.TrimSpace(content)
+	if start, end := strings.Index(content, "{"), strings.LastIndex(content, "}"); start >= 0 && end > start {
+		var desc ImageDescription
+		if err := json.Unmarshal([]byte(content[start:end+1]), &desc); err == nil && strings.TrimSpace(desc.Caption) != "" {
+			desc.Caption = strings.TrimSpace(desc.Caption)
+			desc.Description = strings.TrimSpace(desc.Description)
+			return desc, nil
+		}
+	}
+
+	if content == "" {
+		return ImageDescription{}, fmt.Errorf("no description generated from LLM")
+	}
+	lines := strings.SplitN(content, "\n", 2)
+	desc := ImageDescription{Caption: strings.TrimSpace(lines[0])}
+	if len(lines) == 2 {
+		desc.Description = strings.TrimSpace(lines[1])
+	}
+	return desc, nil
+}
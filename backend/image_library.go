@@ -0,0 +1,200 @@
+package backend
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultImagePageSize = 50
+	maxImagePageSize     = 200
+)
+
+// ImageListFilter narrows the result of ListImages. Empty fields match everything.
+type ImageListFilter struct {
+	Since    string `json:"since"`    // RFC3339 timestamp or "YYYY-MM-DD"
+	Until    string `json:"until"`    // RFC3339 timestamp or "YYYY-MM-DD" (the whole day is included)
+	Provider string `json:"provider"` // Image provider name from the provenance
+	Query    string `json:"query"`    // Case-insensitive text matched against the path and prompts
+}
+
+// ImageListPage selects a page of the ListImages result
+type ImageListPage struct {
+	Page     int `json:"page"`     // 1-based page number
+	PageSize int `json:"pageSize"` // Defaults to 50, at most 200
+}
+
+// ImageInfo describes an image in the download directory
+type ImageInfo struct {
+	Path      string `json:"path"` // Relative path from the download directory
+	Width     int    `json:"width"`
+	Height    int    `json:"height"`
+	Size      int64  `json:"size"`
+	Format    string `json:"format"`    // "png", "jpeg", "gif" or "webp"
+	CreatedAt string `json:"createdAt"` // RFC3339 timestamp; the generation time when provenance exists
+	Provider  string `json:"provider,omitempty"`
+	Model     string `json:"model,omitempty"`
+	Prompt    string `json:"prompt,omitempty"`
+	Parent    string `json:"parent,omitempty"` // Image this one was derived from by TransformImage
+}
+
+// ImageListResult is a page of images, newest first
+type ImageListResult struct {
+	Items    []ImageInfo `json:"items"`
+	Total    int         `json:"total"` // Number of images matching the filter
+	Page     int         `json:"page"`
+	PageSize int         `json:"pageSize"`
+}
+
+// ListImages lists the images in the download directory, newest first.
+// Thumbnails and trashed images are not listed. Dimensions are read from the image headers
+// of the returned page only, so large libraries stay cheap to page through.
+func (s *ImageAssetService) ListImages(filter ImageListFilter, page ImageListPage) (ImageListResult, error) {
+	result := ImageListResult{Items: []ImageInfo{}}
+
+	since, err := parseFilterTime(filter.Since, false)
+	if err != nil {
+		return result, err
+	}
+	until, err := parseFilterTime(filter.Until, true)
+	if err != nil {
+		return result, err
+	}
+
+	downloadPath, err := s.configService.ResolveDownloadPath()
+	if err != nil {
+		return result, fmt.Errorf("failed to resolve download path: %w", err)
+	}
+
+	files, err := listImageFiles(downloadPath)
+	if err != nil {
+		return result, err
+	}
+
+	query := strings.ToLower(strings.TrimSpace(filter.Query))
+	matches := []ImageInfo{}
+	for key, file := range files {
+		info := ImageInfo{
+			Path:      key,
+			Size:      file.Size,
+			CreatedAt: file.ModTime,
+		}
+
+		var searchText string
+		if prov, err := readImageProvenance(s.configService, key); err == nil {
+			if prov.Parent != "" {
+				// Edits are not provider output; older sidecars of edits still carry the parent's details
+				info.Parent = prov.Parent
+			} else {
+				info.Provider = prov.Provider
+				info.Model = prov.Model
+				info.Prompt = prov.Prompt
+				searchText = strings.Join([]string{prov.Prompt, prov.EnhancedPrompt, prov.NegativePrompt}, "\n")
+			}
+			if prov.CreatedAt != "" {
+				info.CreatedAt = prov.CreatedAt
+			}
+		}
+
+		if filter.Provider != "" && !strings.EqualFold(info.Provider, filter.Provider) {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(key+"\n"+searchText), query) {
+			continue
+		}
+		if !since.IsZero() || !until.IsZero() {
+			created, err := time.Parse(time.RFC3339, info.CreatedAt)
+			if err != nil || (!since.IsZero() && created.Before(since)) || (!until.IsZero() && !created.Before(until)) {
+				continue
+			}
+		}
+
+		matches = append(matches, info)
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].CreatedAt != matches[j].CreatedAt {
+			return matches[i].CreatedAt > matches[j].CreatedAt
+		}
+		return matches[i].Path < matches[j].Path
+	})
+
+	result.Page = page.Page
+	if result.Page < 1 {
+		result.Page = 1
+	}
+	result.PageSize = page.PageSize
+	if result.PageSize <= 0 {
+		result.PageSize = defaultImagePageSize
+	}
+	if result.PageSize > maxImagePageSize {
+		result.PageSize = maxImagePageSize
+	}
+	result.Total = len(matches)
+
+	start := (result.Page - 1) * result.PageSize
+	if start >= len(matches) {
+		return result, nil
+	}
+	end := start + result.PageSize
+	if end > len(matches) {
+		end = len(matches)
+	}
+
+	for _, info := range matches[start:end] {
+		// Format and dimensions come from the header; a broken file is still listed
+		if err := readImageHeader(filepath.Join(downloadPath, filepath.FromSlash(info.Path)), &info); err != nil {
+			fmt.Printf("Warning: failed to read image header of %s: %v\n", info.Path, err)
+		}
+		result.Items = append(result.Items, info)
+	}
+	return result, nil
+}
+
+// readImageHeader fills in the format and dimensions of an image without decoding the pixels
+func readImageHeader(filePath string, info *ImageInfo) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	cfg, format, err := image.DecodeConfig(f)
+	if err != nil {
+		info.Format = strings.TrimPrefix(strings.ToLower(filepath.Ext(filePath)), ".")
+		if info.Format == "jpg" {
+			info.Format = "jpeg"
+		}
+		return err
+	}
+	info.Format = format
+	info.Width = cfg.Width
+	info.Height = cfg.Height
+	return nil
+}
+
+// parseFilterTime parses a date filter. A date without time means the start of that day,
+// or the start of the next day when endOfDay is set, so "until" includes the whole day.
+func parseFilterTime(value string, endOfDay bool) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date: %s", value)
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
@@ -0,0 +1,253 @@
+package backend
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	// maxTransformEdge limits the width and height produced by a resize
+	maxTransformEdge = 16384
+	// defaultJPEGQuality is used when a conversion to JPEG does not specify a quality
+	defaultJPEGQuality = 90
+)
+
+// ImageOperation is a single step of TransformImage.
+// Only the fields relevant to Type are used.
+type ImageOperation struct {
+	Type string `json:"type"` // "crop", "rotate", "flip", "resize" or "convert"
+
+	// crop: rectangle in pixels of the current image
+	// resize: target size; a zero width or height keeps the aspect ratio
+	X      int `json:"x,omitempty"`
+	Y      int `json:"y,omitempty"`
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+
+	Scale     float64 `json:"scale,omitempty"`     // resize: factor used when width and height are zero
+	Angle     int     `json:"angle,omitempty"`     // rotate: 90, 180 or 270 degrees clockwise
+	Direction string  `json:"direction,omitempty"` // flip: "horizontal" or "vertical"
+	Format    string  `json:"format,omitempty"`    // convert: "png" or "jpeg"
+	Quality   int     `json:"quality,omitempty"`   // convert: JPEG quality 1-100 (default 90)
+}
+
+// TransformImage applies the operations in order to an image and stores the result as a new asset.
+// The original is never modified. The provenance of the new image records only the parent and
+// the operations; the generation details stay in the provenance of the parent.
+// The output is PNG unless the source is JPEG or a convert operation selects another format.
+func (s *ImageAssetService) TransformImage(src string, ops []ImageOperation) (string, error) {
+	if len(ops) == 0 {
+		return "", fmt.Errorf("no operations given")
+	}
+
+	filePath, err := s.configService.ResolveImagePath(src)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open image file: %w", err)
+	}
+	img, format, err := image.Decode(file)
+	file.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	outFormat := "png"
+	if format == "jpeg" {
+		outFormat = "jpeg"
+	}
+	quality := defaultJPEGQuality
+
+	for i, op := range ops {
+		switch op.Type {
+		case "crop":
+			img, err = cropImage(img, op)
+		case "rotate":
+			img, err = rotateImage(img, op.Angle)
+		case "flip":
+			img, err = flipImage(img, op.Direction)
+		case "resize":
+			width, height := op.Width, op.Height
+			if width == 0 && height == 0 && op.Scale > 0 {
+				width = max(1, int(float64(img.Bounds().Dx())*op.Scale))
+				height = max(1, int(float64(img.Bounds().Dy())*op.Scale))
+			}
+			img, err = resizeImage(img, width, height)
+		case "convert":
+			switch op.Format {
+			case "png":
+				outFormat = "png"
+			case "jpeg", "jpg":
+				outFormat = "jpeg"
+				if op.Quality != 0 {
+					if op.Quality < 1 || op.Quality > 100 {
+						err = fmt.Errorf("quality must be between 1 and 100")
+					}
+					quality = op.Quality
+				}
+			default:
+				err = fmt.Errorf("unsupported format: %s", op.Format)
+			}
+		default:
+			err = fmt.Errorf("unsupported operation: %s", op.Type)
+		}
+		if err != nil {
+			return "", fmt.Errorf("operation %d (%s): %w", i+1, op.Type, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	ext := "png"
+	if outFormat == "jpeg" {
+		ext = "jpg"
+		err = jpeg.Encode(&buf, flattenOnWhite(img), &jpeg.Options{Quality: quality})
+	} else {
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to encode image: %w", err)
+	}
+
+	name := s.assetStore.FriendlyName(src)
+	name = strings.TrimSuffix(name, filepath.Ext(name)) + "_edited." + ext
+	relPath, err := s.assetStore.Put(buf.Bytes(), ext, name)
+	if err != nil {
+		return "", err
+	}
+
+	// The store dedupes by content, so an edit can yield an image that already has a lineage,
+	// e.g. flipping an edit twice. Keep the first lineage so no image becomes its own ancestor.
+	if _, err := readImageProvenance(s.configService, relPath); err == nil {
+		return relPath, nil
+	}
+
+	prov := ImageProvenance{
+		Image:      relPath,
+		CreatedAt:  time.Now().Format(time.RFC3339),
+		Parent:     src,
+		Operations: ops,
+	}
+	if err := writeImageProvenance(s.configService, relPath, &prov); err != nil {
+		// The image itself is saved; only the lineage is lost
+		fmt.Printf("Warning: failed to record provenance of %s: %v\n", relPath, err)
+	}
+
+	return relPath, nil
+}
+
+// toRGBA copies img into a new RGBA image whose bounds start at the origin
+func toRGBA(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+	return dst
+}
+
+func cropImage(img image.Image, op ImageOperation) (image.Image, error) {
+	bounds := img.Bounds()
+	rect := image.Rect(op.X, op.Y, op.X+op.Width, op.Y+op.Height).Add(bounds.Min)
+	if op.Width <= 0 || op.Height <= 0 || !rect.In(bounds) {
+		return nil, fmt.Errorf("crop rectangle %dx%d+%d+%d is outside the %dx%d image",
+			op.Width, op.Height, op.X, op.Y, bounds.Dx(), bounds.Dy())
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, op.Width, op.Height))
+	draw.Draw(dst, dst.Bounds(), img, rect.Min, draw.Src)
+	return dst, nil
+}
+
+func rotateImage(img image.Image, angle int) (image.Image, error) {
+	src := toRGBA(img)
+	width, height := src.Rect.Dx(), src.Rect.Dy()
+
+	var dst *image.RGBA
+	var mapPixel func(x, y int) (int, int)
+	switch angle {
+	case 90:
+		dst = image.NewRGBA(image.Rect(0, 0, height, width))
+		mapPixel = func(x, y int) (int, int) { return height - 1 - y, x }
+	case 180:
+		dst = image.NewRGBA(image.Rect(0, 0, width, height))
+		mapPixel = func(x, y int) (int, int) { return width - 1 - x, height - 1 - y }
+	case 270:
+		dst = image.NewRGBA(image.Rect(0, 0, height, width))
+		mapPixel = func(x, y int) (int, int) { return y, width - 1 - x }
+	default:
+		return nil, fmt.Errorf("angle must be 90, 180 or 270")
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			dx, dy := mapPixel(x, y)
+			copy(dst.Pix[dst.PixOffset(dx, dy):dst.PixOffset(dx, dy)+4], src.Pix[src.PixOffset(x, y):src.PixOffset(x, y)+4])
+		}
+	}
+	return dst, nil
+}
+
+func flipImage(img image.Image, direction string) (image.Image, error) {
+	src := toRGBA(img)
+	width, height := src.Rect.Dx(), src.Rect.Dy()
+	dst := image.NewRGBA(src.Rect)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			dx, dy := x, y
+			switch direction {
+			case "horizontal":
+				dx = width - 1 - x
+			case "vertical":
+				dy = height - 1 - y
+			default:
+				return nil, fmt.Errorf("direction must be horizontal or vertical")
+			}
+			copy(dst.Pix[dst.PixOffset(dx, dy):dst.PixOffset(dx, dy)+4], src.Pix[src.PixOffset(x, y):src.PixOffset(x, y)+4])
+		}
+	}
+	return dst, nil
+}
+
+func resizeImage(img image.Image, width, height int) (image.Image, error) {
+	bounds := img.Bounds()
+	if width < 0 || height < 0 || (width == 0 && height == 0) {
+		return nil, fmt.Errorf("width, height or scale must be positive")
+	}
+	if width == 0 {
+		width = max(1, bounds.Dx()*height/bounds.Dy())
+	}
+	if height == 0 {
+		height = max(1, bounds.Dy()*width/bounds.Dx())
+	}
+	if width > maxTransformEdge || height > maxTransformEdge {
+		return nil, fmt.Errorf("size must not exceed %d pixels per edge", maxTransformEdge)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst, nil
+}
+
+// flattenOnWhite composites a possibly transparent image onto white, as JPEG has no alpha channel
+func flattenOnWhite(img image.Image) image.Image {
+	if isOpaque(img) {
+		return img
+	}
+	bounds := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Over)
+	return dst
+}
@@ -0,0 +1,84 @@
+package backend
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// asymmetricPNG returns a PNG that changes when flipped horizontally
+func asymmetricPNG(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestTransformImageKeepsFirstLineage(t *testing.T) {
+	service := newTestImageGenService(t)
+	assets := NewImageAssetService(service.configService, service.assetStore, service.llmService)
+	service.imageAssetService = assets
+
+	original, err := service.assetStore.Put(asymmetricPNG(t), "png", "original.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resized, err := assets.TransformImage(original, []ImageOperation{{Type: "resize", Scale: 2}})
+	if err != nil {
+		t.Fatalf("resize: %v", err)
+	}
+	flipped, err := assets.TransformImage(resized, []ImageOperation{{Type: "flip", Direction: "horizontal"}})
+	if err != nil {
+		t.Fatalf("flip: %v", err)
+	}
+	flippedBack, err := assets.TransformImage(flipped, []ImageOperation{{Type: "flip", Direction: "horizontal"}})
+	if err != nil {
+		t.Fatalf("flip back: %v", err)
+	}
+	if flippedBack != resized {
+		t.Fatalf("flipping twice gave %s, want the deduplicated %s", flippedBack, resized)
+	}
+
+	prov, err := readImageProvenance(service.configService, resized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prov.Parent != original || len(prov.Operations) != 1 || prov.Operations[0].Type != "resize" {
+		t.Errorf("lineage of %s = %s %v, want the original resize", resized, prov.Parent, prov.Operations)
+	}
+
+	// The original has no provenance, so regeneration fails, but it must not recurse forever
+	if _, err := service.RegenerateImage(resized); err == nil || !strings.Contains(err.Error(), "no provenance recorded") {
+		t.Errorf("RegenerateImage error = %v, want the missing provenance of the original", err)
+	}
+}
+
+func TestRegenerateImageRejectsLineageCycle(t *testing.T) {
+	service := newTestImageGenService(t)
+	first, err := service.assetStore.Put(testPNG(t), "png", "first.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := service.assetStore.Put(asymmetricPNG(t), "png", "second.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	flip := []ImageOperation{{Type: "flip", Direction: "horizontal"}}
+	if err := writeImageProvenance(service.configService, first, &ImageProvenance{Image: first, Parent: second, Operations: flip}); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeImageProvenance(service.configService, second, &ImageProvenance{Image: second, Parent: first, Operations: flip}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := service.RegenerateImage(first); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Fatalf("RegenerateImage error = %v, want a lineage cycle error", err)
+	}
+}
@@ -0,0 +1,314 @@
+package backend
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxDocxPartSize limits the uncompressed size of a part read from a DOCX file
+const maxDocxPartSize = 64 << 20
+
+// xmlElement is a generic XML element; names are local names without the namespace prefix
+type xmlElement struct {
+	Name     string
+	Attrs    map[string]string
+	Children []*xmlElement
+	Text     string // Character data directly inside the element
+}
+
+// child returns the first child element with the given name, or nil
+func (e *xmlElement) child(name string) *xmlElement {
+	if e == nil {
+		return nil
+	}
+	for _, c := range e.Children {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// attr returns the attribute with the given local name, e.g. "val" for w:val
+func (e *xmlElement) attr(name string) string {
+	if e == nil {
+		return ""
+	}
+	return e.Attrs[name]
+}
+
+// parseXMLTree reads a whole XML document into a tree
+func parseXMLTree(r io.Reader) (*xmlElement, error) {
+	decoder := xml.NewDecoder(r)
+	root := &xmlElement{}
+	stack := []*xmlElement{root}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse XML: %w", err)
+		}
+
+		parent := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			element := &xmlElement{Name: t.Name.Local, Attrs: map[string]string{}}
+			for _, a := range t.Attr {
+				element.Attrs[a.Name.Local] = a.Value
+			}
+			parent.Children = append(parent.Children, element)
+			stack = append(stack, element)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			parent.Text += string(t)
+		}
+	}
+	return root, nil
+}
+
+// readZipXML parses a part of a zip archive; a missing part returns nil without error
+func readZipXML(archive *zip.Reader, name string) (*xmlElement, error) {
+	for _, f := range archive.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", name, err)
+		}
+		defer rc.Close()
+		return parseXMLTree(io.LimitReader(rc, maxDocxPartSize))
+	}
+	return nil, nil
+}
+
+// docxConverter turns the body of word/document.xml into Markdown
+type docxConverter struct {
+	headingLevels map[string]int    // Paragraph style ID -> heading level (0 for the title)
+	numFormats    map[string]string // "<numId>/<ilvl>" -> numFmt such as "bullet" or "decimal"
+	listCounters  map[string]int    // "<numId>/<ilvl>" -> last number used
+}
+
+// docxToMarkdown converts a DOCX file to Markdown: headings, bulleted and numbered lists, tables,
+// and bold and italic text. Images and other embedded objects are left out.
+func docxToMarkdown(data []byte) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("failed to open DOCX: %w", err)
+	}
+
+	document, err := readZipXML(archive, "word/document.xml")
+	if err != nil {
+		return "", err
+	}
+	body := document.child("document").child("body")
+	if body == nil {
+		return "", fmt.Errorf("failed to open DOCX: word/document.xml has no body")
+	}
+
+	converter := &docxConverter{
+		headingLevels: map[string]int{},
+		numFormats:    map[string]string{},
+		listCounters:  map[string]int{},
+	}
+	// Styles and numbering are optional; without them headings and lists become plain paragraphs
+	if styles, err := readZipXML(archive, "word/styles.xml"); err == nil && styles != nil {
+		converter.readStyles(styles)
+	}
+	if numbering, err := readZipXML(archive, "word/numbering.xml"); err == nil && numbering != nil {
+		converter.readNumbering(numbering)
+	}
+
+	blocks := []string{}
+	inList := false
+	for _, element := range body.Children {
+		switch element.Name {
+		case "p":
+			text, isListItem := converter.paragraph(element)
+			if text == "" {
+				continue
+			}
+			// Consecutive list items form one block
+			if isListItem && inList {
+				blocks[len(blocks)-1] += "\n" + text
+			} else {
+				blocks = append(blocks, text)
+			}
+			inList = isListItem
+		case "tbl":
+			if table := converter.table(element); table != "" {
+				blocks = append(blocks, strings.TrimRight(table, "\n"))
+			}
+			inList = false
+		}
+	}
+	return strings.Join(blocks, "\n\n") + "\n", nil
+}
+
+// readStyles finds the heading styles. Style IDs are localized (e.g. "1" in Japanese Word),
+// so headings are recognized by the style name, which is always in English.
+func (c *docxConverter) readStyles(styles *xmlElement) {
+	for _, style := range styles.child("styles").Children {
+		if style.Name != "style" || style.attr("type") != "paragraph" {
+			continue
+		}
+		name := strings.ToLower(style.child("name").attr("val"))
+		switch {
+		case name == "title":
+			c.headingLevels[style.attr("styleId")] = 1
+		case strings.HasPrefix(name, "heading "):
+			if level, err := strconv.Atoi(strings.TrimPrefix(name, "heading ")); err == nil && level >= 1 {
+				c.headingLevels[style.attr("styleId")] = min(level, 6)
+			}
+		}
+	}
+}
+
+// readNumbering records the number format of each list level
+func (c *docxConverter) readNumbering(numbering *xmlElement) {
+	root := numbering.child("numbering")
+	abstractFormats := map[string]map[string]string{}
+	for _, abstract := range root.Children {
+		if abstract.Name != "abstractNum" {
+			continue
+		}
+		levels := map[string]string{}
+		for _, level := range abstract.Children {
+			if level.Name == "lvl" {
+				levels[level.attr("ilvl")] = level.child("numFmt").attr("val")
+			}
+		}
+		abstractFormats[abstract.attr("abstractNumId")] = levels
+	}
+	for _, num := range root.Children {
+		if num.Name != "num" {
+			continue
+		}
+		for ilvl, format := range abstractFormats[num.child("abstractNumId").attr("val")] {
+			c.numFormats[num.attr("numId")+"/"+ilvl] = format
+		}
+	}
+}
+
+// paragraph converts a w:p element; isListItem reports whether it is part of a list
+func (c *docxConverter) paragraph(p *xmlElement) (text string, isListItem bool) {
+	text = strings.TrimSpace(c.runs(p))
+	if text == "" {
+		return "", false
+	}
+
+	properties := p.child("pPr")
+	if level, ok := c.headingLevels[properties.child("pStyle").attr("val")]; ok {
+		return strings.Repeat("#", level) + " " + text, false
+	}
+
+	numPr := properties.child("numPr")
+	numID := numPr.child("numId").attr("val")
+	if numPr == nil || numID == "" || numID == "0" {
+		return text, false
+	}
+	ilvl := numPr.child("ilvl").attr("val")
+	depth, _ := strconv.Atoi(ilvl)
+	indent := strings.Repeat("  ", depth)
+
+	key := numID + "/" + ilvl
+	format := c.numFormats[key]
+	if format == "" || format == "bullet" || format == "none" {
+		return indent + "- " + text, true
+	}
+	c.listCounters[key]++
+	return fmt.Sprintf("%s%d. %s", indent, c.listCounters[key], text), true
+}
+
+// runs collects the text of the runs in a paragraph or hyperlink, marking bold and italic runs
+func (c *docxConverter) runs(parent *xmlElement) string {
+	var sb strings.Builder
+	for _, element := range parent.Children {
+		switch element.Name {
+		case "r":
+			sb.WriteString(runText(element))
+		case "hyperlink", "ins", "smartTag", "sdt", "sdtContent":
+			sb.WriteString(c.runs(element))
+		}
+	}
+	return sb.String()
+}
+
+func runText(r *xmlElement) string {
+	var sb strings.Builder
+	for _, element := range r.Children {
+		switch element.Name {
+		case "t":
+			sb.WriteString(element.Text)
+		case "tab":
+			sb.WriteString("\t")
+		case "br", "cr":
+			sb.WriteString("\n")
+		}
+	}
+	text := sb.String()
+	if strings.TrimSpace(text) == "" {
+		return text
+	}
+
+	properties := r.child("rPr")
+	if isToggleOn(properties.child("b")) {
+		text = "**" + text + "**"
+	}
+	if isToggleOn(properties.child("i")) {
+		text = "*" + text + "*"
+	}
+	return text
+}
+
+// isToggleOn reports whether a toggle property such as w:b is present and not switched off
+func isToggleOn(property *xmlElement) bool {
+	if property == nil {
+		return false
+	}
+	switch property.attr("val") {
+	case "0", "false", "off":
+		return false
+	}
+	return true
+}
+
+// table converts a w:tbl element; paragraphs inside a cell are joined with line breaks
+func (c *docxConverter) table(tbl *xmlElement) string {
+	rows := [][]string{}
+	for _, tr := range tbl.Children {
+		if tr.Name != "tr" {
+			continue
+		}
+		row := []string{}
+		for _, tc := range tr.Children {
+			if tc.Name != "tc" {
+				continue
+			}
+			lines := []string{}
+			for _, p := range tc.Children {
+				if p.Name == "p" {
+					if text := strings.TrimSpace(c.runs(p)); text != "" {
+						lines = append(lines, text)
+					}
+				}
+			}
+			row = append(row, strings.Join(lines, "\n"))
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return ""
+	}
+	return markdownTable(rows)
+}
@@ -0,0 +1,245 @@
+package backend
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	htmlSpaces     = regexp.MustCompile(`[ \t\r\n\f]+`)
+	markdownBlanks = regexp.MustCompile(`\n{3,}`)
+)
+
+// htmlToMarkdown converts an HTML document to Markdown: headings, paragraphs, lists, tables,
+// quotes, preformatted code, links, images, and bold, italic and inline code.
+// Scripts, styles and other non-content elements are dropped.
+func htmlToMarkdown(text string) (string, error) {
+	doc, err := html.Parse(strings.NewReader(text))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	root := doc
+	if body := findHTMLElement(doc, atom.Body); body != nil {
+		root = body
+	}
+
+	markdown := htmlChildren(root)
+	lines := strings.Split(markdown, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	markdown = markdownBlanks.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(markdown) + "\n", nil
+}
+
+func findHTMLElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findHTMLElement(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func htmlChildren(n *html.Node) string {
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(htmlNode(c))
+	}
+	return sb.String()
+}
+
+// htmlBlock surrounds block content with blank lines; empty blocks are dropped
+func htmlBlock(content string) string {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return ""
+	}
+	return "\n\n" + content + "\n\n"
+}
+
+// htmlInline wraps inline content in a Markdown marker, keeping surrounding spaces outside the marker
+func htmlInline(content, marker string) string {
+	trimmed := strings.TrimSpace(content)
+	if trimmed == "" {
+		return content
+	}
+	leading := content[:len(content)-len(strings.TrimLeft(content, " "))]
+	trailing := content[len(strings.TrimRight(content, " ")):]
+	return leading + marker + trimmed + marker + trailing
+}
+
+func htmlNode(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return htmlSpaces.ReplaceAllString(n.Data, " ")
+	case html.ElementNode:
+	default:
+		return ""
+	}
+
+	switch n.DataAtom {
+	case atom.Script, atom.Style, atom.Head, atom.Noscript, atom.Template, atom.Iframe, atom.Svg, atom.Button, atom.Select:
+		return ""
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level := int(n.Data[1] - '0')
+		text := strings.TrimSpace(strings.ReplaceAll(htmlChildren(n), "\n", " "))
+		if text == "" {
+			return ""
+		}
+		return htmlBlock(strings.Repeat("#", level) + " " + text)
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Header, atom.Footer, atom.Main, atom.Nav, atom.Aside, atom.Figure, atom.Figcaption, atom.Dl, atom.Dt, atom.Dd:
+		return htmlBlock(htmlChildren(n))
+	case atom.Br:
+		return "\n"
+	case atom.Hr:
+		return htmlBlock("---")
+	case atom.Strong, atom.B:
+		return htmlInline(htmlChildren(n), "**")
+	case atom.Em, atom.I:
+		return htmlInline(htmlChildren(n), "*")
+	case atom.Del, atom.S, atom.Strike:
+		return htmlInline(htmlChildren(n), "~~")
+	case atom.Code:
+		return htmlInline(htmlChildren(n), "`")
+	case atom.A:
+		text := htmlChildren(n)
+		href := htmlAttr(n, "href")
+		if href == "" || strings.HasPrefix(href, "javascript:") || strings.TrimSpace(text) == "" {
+			return text
+		}
+		return "[" + strings.TrimSpace(text) + "](" + href + ")"
+	case atom.Img:
+		src := htmlAttr(n, "src")
+		if src == "" || strings.HasPrefix(src, "data:") {
+			return ""
+		}
+		return "![" + htmlAttr(n, "alt") + "](" + src + ")"
+	case atom.Pre:
+		return htmlBlock(fencedCode(htmlText(n), htmlCodeLanguage(n)))
+	case atom.Blockquote:
+		content := strings.TrimSpace(markdownBlanks.ReplaceAllString(htmlChildren(n), "\n\n"))
+		if content == "" {
+			return ""
+		}
+		lines := strings.Split(content, "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight("> "+line, " ")
+		}
+		return htmlBlock(strings.Join(lines, "\n"))
+	case atom.Ul, atom.Ol:
+		return htmlBlock(htmlList(n))
+	case atom.Table:
+		return htmlBlock(htmlTable(n))
+	}
+	return htmlChildren(n)
+}
+
+// htmlList renders the li children of a ul or ol; nested lists are indented under their item
+func htmlList(list *html.Node) string {
+	ordered := list.DataAtom == atom.Ol
+	items := []string{}
+	number := 1
+	for c := list.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode || c.DataAtom != atom.Li {
+			continue
+		}
+		marker := "- "
+		if ordered {
+			marker = fmt.Sprintf("%d. ", number)
+			number++
+		}
+
+		content := strings.TrimSpace(markdownBlanks.ReplaceAllString(htmlChildren(c), "\n"))
+		content = strings.ReplaceAll(content, "\n\n", "\n")
+		lines := strings.Split(content, "\n")
+		for i := 1; i < len(lines); i++ {
+			lines[i] = strings.Repeat(" ", len(marker)) + lines[i]
+		}
+		items = append(items, marker+strings.Join(lines, "\n"))
+	}
+	return strings.Join(items, "\n")
+}
+
+// htmlTable renders the rows of a table; the first row is used as the header
+func htmlTable(table *html.Node) string {
+	rows := [][]string{}
+	var collect func(n *html.Node)
+	collect = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			switch c.DataAtom {
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				collect(c)
+			case atom.Tr:
+				row := []string{}
+				for cell := c.FirstChild; cell != nil; cell = cell.NextSibling {
+					if cell.Type == html.ElementNode && (cell.DataAtom == atom.Td || cell.DataAtom == atom.Th) {
+						text := strings.TrimSpace(markdownBlanks.ReplaceAllString(htmlChildren(cell), "\n"))
+						row = append(row, strings.ReplaceAll(text, "\n\n", "\n"))
+					}
+				}
+				rows = append(rows, row)
+			}
+		}
+	}
+	collect(table)
+	if len(rows) == 0 {
+		return ""
+	}
+	return markdownTable(rows)
+}
+
+// htmlText returns the raw text of an element, keeping whitespace, for preformatted content
+func htmlText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.DataAtom == atom.Br {
+			sb.WriteString("\n")
+			continue
+		}
+		sb.WriteString(htmlText(c))
+	}
+	return sb.String()
+}
+
+// htmlCodeLanguage reads the language of a pre block from a "language-xxx" class on it or its code element
+func htmlCodeLanguage(pre *html.Node) string {
+	candidates := []*html.Node{pre}
+	if code := findHTMLElement(pre, atom.Code); code != nil {
+		candidates = append(candidates, code)
+	}
+	for _, n := range candidates {
+		for _, class := range strings.Fields(htmlAttr(n, "class")) {
+			if lang, ok := strings.CutPrefix(class, "language-"); ok {
+				return lang
+			}
+			if lang, ok := strings.CutPrefix(class, "lang-"); ok {
+				return lang
+			}
+		}
+	}
+	return ""
+}
+
+func htmlAttr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
@@ -0,0 +1,379 @@
+package backend
+
+import (
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"fm-doc-canvas/backend/canvas"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// maxMarkdownFolderNotes limits the notes imported from one folder, as every note becomes a node
+const maxMarkdownFolderNotes = 1000
+
+var (
+	markdownHeading   = regexp.MustCompile(`^#{1,6}[ \t]+(.+?)[ \t#]*$`)
+	markdownFence     = regexp.MustCompile("(?s)(?:^|\n)(```|~~~)[^\n]*\n.*?(?:\n(```|~~~)[^\n]*|$)")
+	markdownCodeSpan  = regexp.MustCompile("`[^`\n]*`")
+	markdownWikiLink  = regexp.MustCompile(`(!?)\[\[([^\[\]\n]+)\]\]`)
+	markdownLink      = regexp.MustCompile(`(!?)\[[^\]\n]*\]\(\s*(<[^>\n]+>|[^)\s]+)[^)\n]*\)`)
+	markdownURLScheme = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*:`)
+)
+
+// markdownImageExts are the embedded files that become image nodes
+var markdownImageExts = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".webp": true}
+
+// markdownVault indexes the files of an imported folder by slash-separated path relative to the folder
+type markdownVault struct {
+	root   string
+	files  map[string]string   // Lowercase path -> path
+	byName map[string][]string // Lowercase file name -> paths, shortest first
+}
+
+// markdownLinkRef is a link or embed found in a note
+type markdownLinkRef struct {
+	target string
+	embed  bool
+	wiki   bool
+}
+
+// ImportMarkdownFolder reads a folder of Markdown notes, such as an Obsidian vault, as a new canvas.
+// Every note becomes a text node summarized by its first heading; [[wiki links]] and relative
+// Markdown links between notes become edges, and embedded images (![[image.png]] or ![](image.png))
+// are copied into the asset store as image nodes connected to the note. The nodes are laid out
+// by canvas.Layout. An empty dir opens a folder dialog; the result has no Canvas when it is cancelled.
+func (s *FileService) ImportMarkdownFolder(dir string) (LoadCanvasResult, error) {
+	if dir == "" {
+		if s.ctx == nil {
+			return LoadCanvasResult{}, fmt.Errorf("context not initialized")
+		}
+		selected, err := runtime.OpenDirectoryDialog(s.ctx, runtime.OpenDialogOptions{Title: "Import Markdown Folder"})
+		if err != nil {
+			return LoadCanvasResult{}, fmt.Errorf("failed to open directory dialog: %w", err)
+		}
+		if selected == "" {
+			return LoadCanvasResult{}, nil // User cancelled
+		}
+		dir = selected
+	}
+
+	vault, notes, warnings, err := scanMarkdownFolder(dir)
+	if err != nil {
+		return LoadCanvasResult{}, err
+	}
+	if len(notes) == 0 {
+		return LoadCanvasResult{}, fmt.Errorf("no Markdown files found in %s", dir)
+	}
+
+	summaryMaxChars := 0
+	if s.configService != nil {
+		summaryMaxChars = s.configService.GetConfig().Generation.SummaryMaxChars
+	}
+
+	c := &canvas.Canvas{Version: canvas.CurrentVersion, Nodes: []canvas.Node{}, Edges: []canvas.Edge{}}
+	idPrefix := fmt.Sprintf("node-%d", time.Now().UnixMilli())
+	textWidth, textHeight := 250.0, 150.0
+	imageWidth, imageHeight := 300.0, 200.0
+
+	// One text node per note, in path order
+	noteIDs := map[string]string{}
+	contents := map[string]string{}
+	for _, note := range notes {
+		content, err := readImportText(filepath.Join(vault.root, filepath.FromSlash(note)), maxImportTextSize)
+		if err != nil {
+			warnings = append(warnings, canvas.Warning{Code: canvas.WarnImportSkipped, Message: fmt.Sprintf("%s: %v", note, err)})
+			continue
+		}
+		id := fmt.Sprintf("%s-%d", idPrefix, len(c.Nodes))
+		noteIDs[note] = id
+		contents[note] = content
+		c.Nodes = append(c.Nodes, canvas.Node{
+			ID:     id,
+			Type:   canvas.TextNode,
+			Data:   canvas.NodeData{Content: content, Summary: noteSummary(note, content, summaryMaxChars)},
+			Width:  &textWidth,
+			Height: &textHeight,
+		})
+	}
+
+	edgeIDs := map[string]bool{}
+	addEdge := func(source, target string) {
+		id := "edge-" + source + "-" + target
+		if source == target || edgeIDs[id] {
+			return
+		}
+		edgeIDs[id] = true
+		c.Edges = append(c.Edges, canvas.Edge{
+			ID:           id,
+			Source:       source,
+			Target:       target,
+			SourceHandle: "right-source",
+			TargetHandle: "left-target",
+			Type:         "default",
+		})
+	}
+
+	// Links between notes become edges; embedded images become image nodes, shared between notes
+	imageIDs := map[string]string{}
+	for _, note := range notes {
+		sourceID, ok := noteIDs[note]
+		if !ok {
+			continue
+		}
+		for _, ref := range markdownLinks(contents[note]) {
+			target := vault.resolve(note, ref)
+			if target == "" {
+				continue
+			}
+			if targetID, ok := noteIDs[target]; ok {
+				addEdge(sourceID, targetID)
+				continue
+			}
+			if !ref.embed || !markdownImageExts[strings.ToLower(path.Ext(target))] {
+				continue
+			}
+
+			imageID, ok := imageIDs[target]
+			if !ok {
+				relPath, err := s.importMarkdownImage(vault.root, target)
+				if err != nil {
+					warnings = append(warnings, canvas.Warning{Code: canvas.WarnImportSkipped, Message: fmt.Sprintf("%s: %v", target, err)})
+					imageIDs[target] = ""
+					continue
+				}
+				imageID = fmt.Sprintf("%s-%d", idPrefix, len(c.Nodes))
+				imageIDs[target] = imageID
+				c.Nodes = append(c.Nodes, canvas.Node{
+					ID:     imageID,
+					Type:   canvas.ImageNode,
+					Data:   canvas.NodeData{Src: relPath, Alt: path.Base(target)},
+					Width:  &imageWidth,
+					Height: &imageHeight,
+				})
+			}
+			if imageID != "" {
+				addEdge(sourceID, imageID)
+			}
+		}
+	}
+
+	canvas.Layout(c)
+
+	// The folder is not a canvas file, so the imported canvas starts without a current file
+	s.SetCurrentCanvasPath("")
+	return LoadCanvasResult{Canvas: c, Warnings: warnings}, nil
+}
+
+// scanMarkdownFolder lists the Markdown notes of dir, sorted by path, and indexes all of its files.
+// Hidden folders such as .obsidian, .trash and .git are skipped.
+func scanMarkdownFolder(dir string) (*markdownVault, []string, []canvas.Warning, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to resolve folder: %w", err)
+	}
+
+	vault := &markdownVault{root: dir, files: map[string]string{}, byName: map[string][]string{}}
+	notes := []string{}
+	warnings := []canvas.Warning{}
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == dir {
+				return err
+			}
+			warnings = append(warnings, canvas.Warning{Code: canvas.WarnImportSkipped, Message: fmt.Sprintf("%s: %v", p, err)})
+			return nil
+		}
+		name := d.Name()
+		if p != dir && strings.HasPrefix(name, ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		vault.files[strings.ToLower(rel)] = rel
+		lowerName := strings.ToLower(name)
+		vault.byName[lowerName] = append(vault.byName[lowerName], rel)
+
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".md", ".markdown":
+			notes = append(notes, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to read folder: %w", err)
+	}
+
+	for _, paths := range vault.byName {
+		sort.Slice(paths, func(i, j int) bool {
+			if len(paths[i]) != len(paths[j]) {
+				return len(paths[i]) < len(paths[j])
+			}
+			return paths[i] < paths[j]
+		})
+	}
+	sort.Strings(notes)
+	if len(notes) > maxMarkdownFolderNotes {
+		warnings = append(warnings, canvas.Warning{
+			Code:    canvas.WarnImportSkipped,
+			Message: fmt.Sprintf("only the first %d of %d notes were imported", maxMarkdownFolderNotes, len(notes)),
+		})
+		notes = notes[:maxMarkdownFolderNotes]
+	}
+	return vault, notes, warnings, nil
+}
+
+// markdownLinks returns the wiki links, Markdown links and embeds of a note, in order.
+// Links inside code blocks and code spans are ignored.
+func markdownLinks(content string) []markdownLinkRef {
+	text := markdownFence.ReplaceAllString(content, "\n")
+	text = markdownCodeSpan.ReplaceAllString(text, "")
+
+	type found struct {
+		pos int
+		ref markdownLinkRef
+	}
+	refs := []found{}
+	for _, m := range markdownWikiLink.FindAllStringSubmatchIndex(text, -1) {
+		target := text[m[4]:m[5]]
+		// [[Note|alias]], [[Note#Heading]] and ![[image.png|300]] all point at the part before | or #
+		if i := strings.IndexAny(target, "|#^"); i >= 0 {
+			target = target[:i]
+		}
+		refs = append(refs, found{m[0], markdownLinkRef{target: strings.TrimSpace(target), embed: m[3] > m[2], wiki: true}})
+	}
+	for _, m := range markdownLink.FindAllStringSubmatchIndex(text, -1) {
+		target := strings.TrimSuffix(strings.TrimPrefix(text[m[4]:m[5]], "<"), ">")
+		if markdownURLScheme.MatchString(target) || strings.HasPrefix(target, "#") {
+			continue
+		}
+		if i := strings.IndexAny(target, "#?"); i >= 0 {
+			target = target[:i]
+		}
+		if unescaped, err := url.PathUnescape(target); err == nil {
+			target = unescaped
+		}
+		refs = append(refs, found{m[0], markdownLinkRef{target: target, embed: m[3] > m[2]}})
+	}
+
+	sort.SliceStable(refs, func(i, j int) bool { return refs[i].pos < refs[j].pos })
+	result := make([]markdownLinkRef, 0, len(refs))
+	for _, r := range refs {
+		if r.ref.target != "" {
+			result = append(result, r.ref)
+		}
+	}
+	return result
+}
+
+// resolve finds the file a link in note points to, or returns "". Markdown links are relative to
+// the note (or to the folder when they start with /). Wiki links are tried relative to the folder
+// and the note, then by file name anywhere in the folder, as Obsidian does; the extension may be omitted.
+func (v *markdownVault) resolve(note string, ref markdownLinkRef) string {
+	target := strings.ReplaceAll(ref.target, `\`, "/")
+	noteDir := path.Dir(note)
+
+	candidates := []string{}
+	if ref.wiki {
+		candidates = append(candidates, path.Clean(target), path.Join(noteDir, target))
+	} else if strings.HasPrefix(target, "/") {
+		candidates = append(candidates, path.Clean(strings.TrimPrefix(target, "/")))
+	} else {
+		candidates = append(candidates, path.Join(noteDir, target))
+	}
+
+	withExt := func(p string) []string {
+		if path.Ext(p) == "" {
+			return []string{p + ".md", p + ".markdown", p}
+		}
+		return []string{p}
+	}
+	for _, candidate := range candidates {
+		if candidate == ".." || strings.HasPrefix(candidate, "../") {
+			continue
+		}
+		for _, p := range withExt(candidate) {
+			if found, ok := v.files[strings.ToLower(p)]; ok {
+				return found
+			}
+		}
+	}
+
+	if ref.wiki {
+		for _, p := range withExt(path.Base(target)) {
+			if paths := v.byName[strings.ToLower(p)]; len(paths) > 0 {
+				return paths[0]
+			}
+		}
+	}
+	return ""
+}
+
+// importMarkdownImage copies an image of the folder into the asset store
+func (s *FileService) importMarkdownImage(dir, rel string) (string, error) {
+	data, err := readImportFile(filepath.Join(dir, filepath.FromSlash(rel)), maxImportImageSize)
+	if err != nil {
+		return "", err
+	}
+	relPath, err := s.assetStore.Put(data, path.Ext(rel), path.Base(rel))
+	if err != nil {
+		return "", fmt.Errorf("failed to write imported image: %w", err)
+	}
+	return relPath, nil
+}
+
+// noteSummary returns the first heading of a note, or its file name when it has none,
+// shortened to maxChars characters (no limit when maxChars is 0)
+func noteSummary(note, content string, maxChars int) string {
+	summary := ""
+	inFence := false
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	// YAML front matter is not part of the note text
+	if len(lines) > 0 && strings.TrimSpace(lines[0]) == "---" {
+		for i := 1; i < len(lines); i++ {
+			if t := strings.TrimSpace(lines[i]); t == "---" || t == "..." {
+				lines = lines[i+1:]
+				break
+			}
+		}
+	}
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		if m := markdownHeading.FindStringSubmatch(trimmed); m != nil {
+			summary = strings.TrimSpace(m[1])
+			break
+		}
+	}
+	if summary == "" {
+		summary = strings.TrimSuffix(path.Base(note), path.Ext(note))
+	}
+	if maxChars > 0 && utf8.RuneCountInString(summary) > maxChars {
+		summary = string([]rune(summary)[:maxChars])
+	}
+	return summary
+}
@@ -0,0 +1,472 @@
+package backend
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+const (
+	// maxImportPDFSize limits PDF files, which may carry large images besides the text
+	maxImportPDFSize = 100 << 20
+
+	// maxImportPDFPages limits the number of pages read from one PDF
+	maxImportPDFPages = 500
+
+	// headingScale is how much larger than the body text a line must be to count as a heading
+	headingScale = 1.2
+
+	// scannedPageMinChars is the text length below which a page is treated as scanned
+	scannedPageMinChars = 20
+)
+
+// PDF split modes (ImportConfig.PDFSplit)
+const (
+	PDFSplitAuto    = "auto"    // By heading when headings are found, otherwise by page
+	PDFSplitPage    = "page"    // One text node per page
+	PDFSplitHeading = "heading" // One text node per section starting at a heading
+)
+
+// pdfLine is a line of text reconstructed from the glyphs of a page
+type pdfLine struct {
+	text     string
+	y        float64 // Baseline, increasing bottom to top
+	fontSize float64
+	page     int
+}
+
+// pdfSection is a part of the document that becomes one text node
+type pdfSection struct {
+	title string
+	lines []pdfLine
+}
+
+// importPDF converts a PDF into text items in reading order, split by page or by heading.
+// With pageImages, pages without text (scanned pages) are imported as images instead.
+func (s *FileService) importPDF(filePath string, split string, pageImages bool) (ImportFileResult, error) {
+	data, err := readImportFile(filePath, maxImportPDFSize)
+	if err != nil {
+		return ImportFileResult{}, err
+	}
+
+	reader, err := openPDF(data)
+	if err != nil {
+		return ImportFileResult{}, err
+	}
+
+	result := ImportFileResult{Items: []ImportedItem{}, Chain: true, Warnings: []string{}}
+	numPages := reader.NumPage()
+	if numPages > maxImportPDFPages {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("Only the first %d of %d pages were imported.", maxImportPDFPages, numPages))
+		numPages = maxImportPDFPages
+	}
+
+	stem := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath))
+	pages := make([][]pdfLine, 0, numPages)
+	scanned := map[int]bool{}
+	for i := 1; i <= numPages; i++ {
+		lines, err := pdfPageLines(reader, i)
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("Page %d: %v", i, err))
+		}
+		pages = append(pages, lines)
+		if pageImages && pdfTextLength(lines) < scannedPageMinChars {
+			scanned[i] = true
+		}
+	}
+
+	// Scanned pages become image items in page order; text sections keep their position between them
+	bodySize := pdfBodyFontSize(pages)
+	addText := func(title string, lines []pdfLine) {
+		text := pdfLinesText(lines, bodySize)
+		if strings.TrimSpace(text) == "" {
+			return
+		}
+		result.Items = append(result.Items, ImportedItem{Type: "text", Content: text, Title: title})
+	}
+	addPageImage := func(page int) {
+		relPath, err := s.importPDFPageImage(reader, data, page, stem)
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("Page %d: %v", page, err))
+			return
+		}
+		result.Items = append(result.Items, ImportedItem{Type: "image", Content: relPath, Title: fmt.Sprintf("%s p.%d", stem, page)})
+	}
+
+	sections := pdfSplitByHeading(pages, scanned, bodySize)
+	useHeadings := (split == PDFSplitHeading && len(sections) > 0) || (split != PDFSplitPage && len(sections) >= 2)
+	if split == PDFSplitHeading && !useHeadings {
+		result.Warnings = append(result.Warnings, "No headings were found; the PDF was split by page.")
+	}
+
+	if useHeadings {
+		page := 1
+		for _, section := range sections {
+			// Emit scanned pages that come before the section
+			for ; page <= numPages && page < section.lines[0].page; page++ {
+				if scanned[page] {
+					addPageImage(page)
+				}
+			}
+			addText(section.title, section.lines)
+		}
+		for ; page <= numPages; page++ {
+			if scanned[page] {
+				addPageImage(page)
+			}
+		}
+	} else {
+		for i, lines := range pages {
+			page := i + 1
+			if scanned[page] {
+				addPageImage(page)
+				continue
+			}
+			addText(fmt.Sprintf("%s p.%d", stem, page), lines)
+		}
+	}
+
+	if len(result.Items) == 0 {
+		return result, fmt.Errorf("no text found in the PDF; it may be a scanned document (enable page images in the settings)")
+	}
+	return result, nil
+}
+
+// openPDF opens a PDF; the parser panics on some malformed files, which is reported as an error
+func openPDF(data []byte) (reader *pdf.Reader, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to read PDF: %v", r)
+		}
+	}()
+	reader, err = pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+	return reader, nil
+}
+
+// pdfPageLines reconstructs the lines of a page from its glyphs, top to bottom
+func pdfPageLines(reader *pdf.Reader, pageNum int) (lines []pdfLine, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			lines, err = nil, fmt.Errorf("failed to extract text: %v", r)
+		}
+	}()
+
+	page := reader.Page(pageNum)
+	if page.V.IsNull() {
+		return nil, nil
+	}
+	texts := page.Content().Text
+
+	// Sort top to bottom, then left to right
+	sort.SliceStable(texts, func(i, j int) bool {
+		if math.Abs(texts[i].Y-texts[j].Y) > 0.5 {
+			return texts[i].Y > texts[j].Y
+		}
+		return texts[i].X < texts[j].X
+	})
+
+	type pendingLine struct {
+		texts    []pdf.Text
+		y        float64
+		fontSize float64
+	}
+	var groups []*pendingLine
+	for _, t := range texts {
+		if t.S == "" {
+			continue
+		}
+		size := math.Max(t.FontSize, 1)
+		if n := len(groups); n > 0 && math.Abs(groups[n-1].y-t.Y) < size*0.5 {
+			g := groups[n-1]
+			g.texts = append(g.texts, t)
+			g.fontSize = math.Max(g.fontSize, size)
+			continue
+		}
+		groups = append(groups, &pendingLine{texts: []pdf.Text{t}, y: t.Y, fontSize: size})
+	}
+
+	for _, g := range groups {
+		sort.SliceStable(g.texts, func(i, j int) bool { return g.texts[i].X < g.texts[j].X })
+		var sb strings.Builder
+		end := math.Inf(-1)
+		for _, t := range g.texts {
+			// Glyphs are often drawn one by one; a visible gap between them is a space
+			if end != math.Inf(-1) && t.X-end > g.fontSize*0.2 && !strings.HasSuffix(sb.String(), " ") && !strings.HasPrefix(t.S, " ") {
+				sb.WriteString(" ")
+			}
+			sb.WriteString(t.S)
+			end = t.X + t.W
+		}
+		text := strings.TrimSpace(sb.String())
+		if text != "" {
+			lines = append(lines, pdfLine{text: text, y: g.y, fontSize: g.fontSize, page: pageNum})
+		}
+	}
+	return lines, nil
+}
+
+func pdfTextLength(lines []pdfLine) int {
+	n := 0
+	for _, line := range lines {
+		n += len([]rune(line.text))
+	}
+	return n
+}
+
+// pdfBodyFontSize returns the font size used for most of the text
+func pdfBodyFontSize(pages [][]pdfLine) float64 {
+	chars := map[float64]int{}
+	for _, lines := range pages {
+		for _, line := range lines {
+			chars[math.Round(line.fontSize*2)/2] += len([]rune(line.text))
+		}
+	}
+	body, most := 0.0, -1
+	for size, n := range chars {
+		if n > most || (n == most && size < body) {
+			body, most = size, n
+		}
+	}
+	return body
+}
+
+// pdfSplitByHeading splits the text into sections at lines set clearly larger than the body text.
+// Consecutive heading lines (a heading wrapped over two lines) form one title.
+func pdfSplitByHeading(pages [][]pdfLine, skip map[int]bool, bodySize float64) []pdfSection {
+	sections := []pdfSection{}
+	current := pdfSection{}
+	lastWasHeading := false
+	for i, lines := range pages {
+		if skip[i+1] {
+			continue
+		}
+		for _, line := range lines {
+			isHeading := bodySize > 0 && line.fontSize >= bodySize*headingScale && len([]rune(line.text)) <= 120
+			if !isHeading {
+				current.lines = append(current.lines, line)
+				lastWasHeading = false
+				continue
+			}
+			if lastWasHeading {
+				current.title += " " + line.text
+				current.lines = append(current.lines, line)
+				continue
+			}
+			if len(current.lines) > 0 {
+				sections = append(sections, current)
+			}
+			current = pdfSection{title: line.text, lines: []pdfLine{line}}
+			lastWasHeading = true
+		}
+	}
+	if len(current.lines) > 0 {
+		sections = append(sections, current)
+	}
+
+	headings := 0
+	for _, section := range sections {
+		if section.title != "" {
+			headings++
+		}
+	}
+	if headings == 0 {
+		return nil
+	}
+	return sections
+}
+
+// pdfLinesText joins lines into Markdown: heading lines become "##" headings and
+// a vertical gap larger than the line height starts a new paragraph
+func pdfLinesText(lines []pdfLine, bodySize float64) string {
+	var sb strings.Builder
+	for i, line := range lines {
+		heading := bodySize > 0 && line.fontSize >= bodySize*headingScale
+		if i > 0 {
+			prev := lines[i-1]
+			gap := prev.y - line.y
+			prevHeading := bodySize > 0 && prev.fontSize >= bodySize*headingScale
+			switch {
+			case heading && prevHeading:
+				sb.WriteString(" ")
+				sb.WriteString(line.text)
+				continue
+			case heading || prevHeading || line.page != prev.page || gap > line.fontSize*1.8 || gap < 0:
+				sb.WriteString("\n\n")
+			default:
+				sb.WriteString("\n")
+			}
+		}
+		if heading {
+			sb.WriteString("## ")
+		}
+		sb.WriteString(line.text)
+	}
+	return strings.TrimSpace(sb.String()) + "\n"
+}
+
+// xobjectRefPattern matches the entries of a printed XObject dictionary, e.g. "/Im0 12 0 R" or "/Im0 {12 0}"
+var xobjectRefPattern = regexp.MustCompile(`/([^\s/<>\[\]{}]+)\s*(?:\{(\d+) (\d+)\}|(\d+) (\d+) R)`)
+
+// importPDFPageImage stores the largest image of a page in the asset store.
+// JPEG images (the usual format of scanners) are stored as they are; 8-bit RGB and gray images are stored as PNG.
+func (s *FileService) importPDFPageImage(reader *pdf.Reader, data []byte, pageNum int, stem string) (relPath string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			relPath, err = "", fmt.Errorf("failed to read page image: %v", r)
+		}
+	}()
+
+	xobjects := reader.Page(pageNum).Resources().Key("XObject")
+	var best pdf.Value
+	var bestName string
+	for _, name := range xobjects.Keys() {
+		x := xobjects.Key(name)
+		if x.Key("Subtype").Name() != "Image" {
+			continue
+		}
+		if best.IsNull() || x.Key("Width").Int64()*x.Key("Height").Int64() > best.Key("Width").Int64()*best.Key("Height").Int64() {
+			best, bestName = x, name
+		}
+	}
+	if best.IsNull() {
+		return "", fmt.Errorf("no text or image found on the page")
+	}
+
+	var imageData []byte
+	var ext string
+	switch filter := pdfFilterNames(best); {
+	case len(filter) == 1 && filter[0] == "DCTDecode":
+		imageData, err = pdfRawStream(data, xobjects, bestName, best.Key("Length").Int64())
+		ext = "jpg"
+	case len(filter) == 0 || (len(filter) == 1 && filter[0] == "FlateDecode"):
+		imageData, err = pdfRasterToPNG(best)
+		ext = "png"
+	default:
+		return "", fmt.Errorf("unsupported image format %v", filter)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	relPath, err = s.assetStore.Put(imageData, ext, fmt.Sprintf("%s_p%d.%s", stem, pageNum, ext))
+	if err != nil {
+		return "", fmt.Errorf("failed to write page image: %w", err)
+	}
+	return relPath, nil
+}
+
+func pdfFilterNames(v pdf.Value) []string {
+	filter := v.Key("Filter")
+	switch filter.Kind() {
+	case pdf.Name:
+		return []string{filter.Name()}
+	case pdf.Array:
+		names := []string{}
+		for i := 0; i < filter.Len(); i++ {
+			names = append(names, filter.Index(i).Name())
+		}
+		return names
+	}
+	return nil
+}
+
+// pdfRawStream returns the undecoded data of an image stream. The PDF library cannot decode
+// DCTDecode streams, so the object is located in the file by the reference printed in its parent dictionary.
+func pdfRawStream(data []byte, parent pdf.Value, name string, length int64) ([]byte, error) {
+	var objNum, genNum string
+	for _, m := range xobjectRefPattern.FindAllStringSubmatch(parent.String(), -1) {
+		if m[1] == name {
+			objNum, genNum = m[2]+m[4], m[3]+m[5]
+		}
+	}
+	if objNum == "" || length <= 0 {
+		return nil, fmt.Errorf("failed to locate image data")
+	}
+
+	// The last definition wins when the file was updated incrementally
+	header := regexp.MustCompile(`(?:^|[\r\n\s])` + objNum + `\s+` + genNum + `\s+obj\b`)
+	matches := header.FindAllIndex(data, -1)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("failed to locate image data")
+	}
+	rest := data[matches[len(matches)-1][1]:]
+	idx := bytes.Index(rest, []byte("stream"))
+	if idx < 0 {
+		return nil, fmt.Errorf("failed to locate image data")
+	}
+	start := idx + len("stream")
+	if start < len(rest) && rest[start] == '\r' {
+		start++
+	}
+	if start < len(rest) && rest[start] == '\n' {
+		start++
+	}
+	if int64(len(rest)-start) < length {
+		return nil, fmt.Errorf("image data is truncated")
+	}
+	raw := rest[start : int64(start)+length]
+	if !bytes.HasPrefix(raw, []byte{0xFF, 0xD8}) {
+		return nil, fmt.Errorf("image data is not a JPEG")
+	}
+	return raw, nil
+}
+
+// pdfRasterToPNG encodes an uncompressed or Flate-compressed 8-bit RGB or gray image as PNG
+func pdfRasterToPNG(v pdf.Value) ([]byte, error) {
+	width, height := int(v.Key("Width").Int64()), int(v.Key("Height").Int64())
+	if width <= 0 || height <= 0 || v.Key("BitsPerComponent").Int64() != 8 {
+		return nil, fmt.Errorf("unsupported image format")
+	}
+
+	components := 0
+	switch v.Key("ColorSpace").Name() {
+	case "DeviceRGB":
+		components = 3
+	case "DeviceGray":
+		components = 1
+	default:
+		return nil, fmt.Errorf("unsupported color space %s", v.Key("ColorSpace"))
+	}
+
+	rc := v.Reader()
+	defer rc.Close()
+	pixels, err := io.ReadAll(io.LimitReader(rc, int64(width*height*components)+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image data: %w", err)
+	}
+	if len(pixels) < width*height*components {
+		return nil, fmt.Errorf("image data is truncated (%d bytes)", len(pixels))
+	}
+
+	var img image.Image
+	if components == 1 {
+		gray := image.NewGray(image.Rect(0, 0, width, height))
+		copy(gray.Pix, pixels)
+		img = gray
+	} else {
+		rgba := image.NewRGBA(image.Rect(0, 0, width, height))
+		for i := 0; i < width*height; i++ {
+			rgba.SetRGBA(i%width, i/width, color.RGBA{pixels[i*3], pixels[i*3+1], pixels[i*3+2], 0xFF})
+		}
+		img = rgba
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
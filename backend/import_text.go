@@ -0,0 +1,244 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// Size limits for imported files. Text becomes the content of a single node,
+// so anything larger would make the canvas unusable.
+const (
+	maxImportTextSize     = 2 << 20  // Plain text, Markdown, CSV, JSON/YAML and source code
+	maxImportDocumentSize = 20 << 20 // DOCX and HTML, which carry markup besides the text
+	maxImportImageSize    = 50 << 20
+	maxImportCSVRows      = 500
+)
+
+// codeLanguages maps source-code extensions to the language tag of the fenced block
+var codeLanguages = map[string]string{
+	".json":  "json",
+	".yaml":  "yaml",
+	".yml":   "yaml",
+	".toml":  "toml",
+	".xml":   "xml",
+	".ini":   "ini",
+	".go":    "go",
+	".py":    "python",
+	".js":    "javascript",
+	".mjs":   "javascript",
+	".jsx":   "jsx",
+	".ts":    "typescript",
+	".tsx":   "tsx",
+	".java":  "java",
+	".kt":    "kotlin",
+	".scala": "scala",
+	".c":     "c",
+	".h":     "c",
+	".cpp":   "cpp",
+	".cc":    "cpp",
+	".hpp":   "cpp",
+	".cs":    "csharp",
+	".rb":    "ruby",
+	".rs":    "rust",
+	".php":   "php",
+	".swift": "swift",
+	".dart":  "dart",
+	".lua":   "lua",
+	".r":     "r",
+	".sh":    "bash",
+	".bash":  "bash",
+	".ps1":   "powershell",
+	".bat":   "bat",
+	".sql":   "sql",
+	".css":   "css",
+	".scss":  "scss",
+	".vue":   "vue",
+}
+
+// readImportFile reads a file to import, refusing files larger than limit
+func readImportFile(filePath string, limit int64) ([]byte, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if info.Size() > limit {
+		return nil, fmt.Errorf("file is too large to import (%d MB, limit %d MB)", info.Size()>>20, limit>>20)
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return data, nil
+}
+
+// readImportText reads a text file of any common encoding as UTF-8
+func readImportText(filePath string, limit int64) (string, error) {
+	data, err := readImportFile(filePath, limit)
+	if err != nil {
+		return "", err
+	}
+	return decodeText(data)
+}
+
+// decodeText converts text to UTF-8. UTF-8 and UTF-16 are recognized by their BOM (UTF-16 also by
+// its zero bytes); other text that is not valid UTF-8 is decoded as Shift_JIS or EUC-JP,
+// whichever yields fewer invalid characters.
+func decodeText(data []byte) (string, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return string(data[3:]), nil
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}), bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return decodeWith(unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM), data)
+	}
+
+	if endian, ok := guessUTF16(data); ok {
+		return decodeWith(unicode.UTF16(endian, unicode.IgnoreBOM), data)
+	}
+	if utf8.Valid(data) {
+		return string(data), nil
+	}
+
+	best, bestInvalid := "", -1
+	for _, enc := range []encoding.Encoding{japanese.ShiftJIS, japanese.EUCJP} {
+		text, err := decodeWith(enc, data)
+		if err != nil {
+			continue
+		}
+		invalid := strings.Count(text, "\uFFFD")
+		if bestInvalid < 0 || invalid < bestInvalid {
+			best, bestInvalid = text, invalid
+		}
+	}
+	if bestInvalid < 0 {
+		return "", fmt.Errorf("failed to detect the text encoding")
+	}
+	return best, nil
+}
+
+// guessUTF16 detects UTF-16 without BOM: mostly ASCII text has a zero byte in every other position
+func guessUTF16(data []byte) (unicode.Endianness, bool) {
+	if len(data) < 4 || len(data)%2 != 0 {
+		return unicode.LittleEndian, false
+	}
+	sample := data[:min(len(data), 1024)]
+	evenZeros, oddZeros := 0, 0
+	for i := 0; i < len(sample); i += 2 {
+		if sample[i] == 0 {
+			evenZeros++
+		}
+		if sample[i+1] == 0 {
+			oddZeros++
+		}
+	}
+	pairs := len(sample) / 2
+	switch {
+	case oddZeros > pairs*2/3 && evenZeros < pairs/10:
+		return unicode.LittleEndian, true
+	case evenZeros > pairs*2/3 && oddZeros < pairs/10:
+		return unicode.BigEndian, true
+	}
+	return unicode.LittleEndian, false
+}
+
+func decodeWith(enc encoding.Encoding, data []byte) (string, error) {
+	decoded, err := io.ReadAll(transform.NewReader(bytes.NewReader(data), enc.NewDecoder()))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode text: %w", err)
+	}
+	return strings.TrimPrefix(string(decoded), "\uFEFF"), nil
+}
+
+// fencedCode wraps text in a fenced code block tagged with lang.
+// The fence is longer than any run of backticks in the text, so the block cannot end early.
+func fencedCode(text, lang string) string {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	return fence + lang + "\n" + strings.TrimRight(text, "\r\n") + "\n" + fence + "\n"
+}
+
+// csvToMarkdown renders CSV (or TSV when comma is '\t') as a Markdown table with the first row as header.
+// Rows beyond maxImportCSVRows are left out with a note.
+func csvToMarkdown(text string, comma rune) (string, error) {
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.Comma = comma
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	rows := [][]string{}
+	truncated := false
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to parse CSV: %w", err)
+		}
+		if len(rows) > maxImportCSVRows {
+			truncated = true
+			break
+		}
+		rows = append(rows, record)
+	}
+	if len(rows) == 0 {
+		return "", nil
+	}
+
+	table := markdownTable(rows)
+	if truncated {
+		table += fmt.Sprintf("\n(Only the first %d rows were imported.)\n", maxImportCSVRows)
+	}
+	return table, nil
+}
+
+// markdownTable renders rows as a Markdown table; the first row is the header.
+// Short rows are padded, and pipes and line breaks in cells are escaped.
+func markdownTable(rows [][]string) string {
+	columns := 0
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	if columns == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	writeRow := func(row []string) {
+		sb.WriteString("|")
+		for i := 0; i < columns; i++ {
+			cell := ""
+			if i < len(row) {
+				cell = strings.TrimSpace(row[i])
+				cell = strings.ReplaceAll(cell, "|", `\|`)
+				cell = strings.ReplaceAll(strings.ReplaceAll(cell, "\r\n", "\n"), "\n", "<br>")
+			}
+			sb.WriteString(" " + cell + " |")
+		}
+		sb.WriteString("\n")
+	}
+
+	writeRow(rows[0])
+	sb.WriteString("|" + strings.Repeat(" --- |", columns) + "\n")
+	for _, row := range rows[1:] {
+		writeRow(row)
+	}
+	return sb.String()
+}
@@ -138,6 +138,54 @@ func (s *LLMService) GenerateSummary(text string) (string, error) {
 	return s.callChatAPI(cfg, messages)
 }
 
+// EnhanceImagePrompt rewrites a user prompt and its Markdown context into a concise visual prompt
+// for image generation. It returns the rewritten prompt and a negative prompt (which may be empty).
+func (s *LLMService) EnhanceImagePrompt(prompt string, contextData string, style string) (string, string, error) {
+	cfg := s.configService.GetConfig()
+
+	systemPrompt := "You write prompts for image generation models. " +
+		"Rewrite the user's request and the supporting context into a single concise visual description " +
+		"(subject, composition, style, lighting, colors) of at most 120 words. " +
+		"Do not include Markdown, explanations or text that should not be drawn. " +
+		"Also list things the image should avoid as a short comma separated negative prompt (may be empty). " +
+		`Respond with JSON only: {"prompt": "...", "negativePrompt": "..."}`
+	if style != "" {
+		systemPrompt += fmt.Sprintf("\nApply this style: %s", style)
+	}
+	userMessage := fmt.Sprintf("Context:\n%s\n\nUser Prompt:\n%s", contextData, prompt)
+
+	messages := []ChatMessage{
+		{Role: "system", Content: systemPrompt},
+		{Role: "user", Content: userMessage},
+	}
+
+	content, err := s.callChatAPI(cfg, messages)
+	if err != nil {
+		return "", "", err
+	}
+	return parseEnhancedPrompt(content)
+}
+
+// parseEnhancedPrompt extracts the prompt and negative prompt from an EnhanceImagePrompt response.
+// Models sometimes wrap the JSON in a code fence or answer in plain text; plain text is used as the prompt.
+func parseEnhancedPrompt(content string) (string, string, error) {
+	content = strings.TrimSpace(content)
+	if start, end := strings.Index(content, "{"), strings.LastIndex(content, "}"); start >= 0 && end > start {
+		var result struct {
+			Prompt         string `json:"prompt"`
+			NegativePrompt string `json:"negativePrompt"`
+		}
+		if err := json.Unmarshal([]byte(content[start:end+1]), &result); err == nil && strings.TrimSpace(result.Prompt) != "" {
+			return strings.TrimSpace(result.Prompt), strings.TrimSpace(result.NegativePrompt), nil
+		}
+	}
+
+	if content == "" {
+		return "", "", fmt.Errorf("no prompt generated from LLM")
+	}
+	return content, "", nil
+}
+
 func (s *LLMService) callChatAPI(cfg Config, messages []ChatMessage) (string, error) {
 	// Convert messages to use string content for compatibility
 	stringMessages := make([]ChatMessage, len(messages))
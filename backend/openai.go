@@ -10,6 +10,33 @@ import (
 	"time"
 )
 
+func init() {
+	RegisterImageProvider(ImageProviderRegistration{
+		Name:        "openai",
+		DisplayName: "OpenAI",
+		Default: func() ProviderConfig {
+			return &OpenAIConfig{
+				BaseURL: "https://api.openai.com/v1",
+				Model:   "gpt-image-1.5",
+			}
+		},
+		FromLegacy: func(baseURL string, model string, apiKey string) ProviderConfig {
+			return &OpenAIConfig{
+				BaseURL: baseURL,
+				Model:   model,
+				APIKey:  apiKey,
+			}
+		},
+		New: func(cfg ProviderConfig, baseCfg *ImageGenConfig, service *ImageGenService) ImageGenProvider {
+			return &OpenAIProvider{
+				config:  cfg.(*OpenAIConfig),
+				baseCfg: baseCfg,
+				service: service,
+			}
+		},
+	})
+}
+
 type OpenAIProvider struct {
 	config  *OpenAIConfig
 	baseCfg *ImageGenConfig
@@ -18,13 +45,31 @@ type OpenAIProvider struct {
 
 
 
+// Capabilities implements ImageGenProvider.Capabilities for OpenAIProvider.
+// Sizes and qualities depend on the model: DALL-E models take their own values.
+func (p *OpenAIProvider) Capabilities() ImageGenCapabilities {
+	caps := ImageGenCapabilities{
+		MaxReferenceImages: 5,
+		Sizes:              []string{"1024x1024", "1536x1024", "1024x1536"},
+		Qualities:          []string{"low", "medium", "high", "auto"},
+		Formats:            []string{"image/png", "image/jpeg", "image/webp"},
+		SupportsEdit:       true,
+	}
+	switch p.config.Model {
+	case "dall-e-3":
+		caps.Sizes = []string{"1024x1024", "1792x1024", "1024x1792"}
+		caps.Qualities = []string{"standard", "hd"}
+	case "dall-e-2":
+		caps.Sizes = []string{"256x256", "512x512", "1024x1024"}
+		caps.Qualities = []string{"standard"}
+	}
+	return caps
+}
+
 // Generate implements ImageGenProvider.Generate for OpenAIProvider
-func (p *OpenAIProvider) generateImage(prompt string, contextData string) (string, error) {
+func (p *OpenAIProvider) generateImage(request ImageGenRequest) (string, error) {
 	// Combine prompt and context for better generation
-	fullPrompt := prompt
-	if contextData != "" {
-		fullPrompt = fmt.Sprintf("Context information:\n%s\n\nBased on the above context, generate an image for: %s", contextData, prompt)
-	}
+	fullPrompt := request.FullPrompt()
 
 	// Prepare the request payload for OpenAI Image Generation (DALL-E)
 	payload := map[string]interface{}{
@@ -32,6 +77,12 @@ func (p *OpenAIProvider) generateImage(prompt string, contextData string) (strin
 		"prompt": fullPrompt,
 		"n":      1,
 	}
+	if request.Size != "" {
+		payload["size"] = request.Size
+	}
+	if request.Quality != "" {
+		payload["quality"] = request.Quality
+	}
 	// Add response_format for OpenAI Image API when using dall-e models
 	if !strings.Contains(p.config.BaseURL, "api.openai.com") || (strings.Contains(p.config.BaseURL, "api.openai.com") && (p.config.Model == "dall-e-3" || p.config.Model == "dall-e-2")) {
 		payload["response_format"] = "b64_json"
@@ -99,7 +150,7 @@ func (p *OpenAIProvider) generateImage(prompt string, contextData string) (strin
 	return p.service.downloadAndSaveImage(dataURL)
 }
 
-func (p *OpenAIProvider) generateWithChatCompletion(prompt string, contextData string, refImages []string) (string, error) {
+func (p *OpenAIProvider) generateWithChatCompletion(request ImageGenRequest) (string, error) {
 	// NOTE:
 	// Phase 4: reference images are handled via the Responses API (/v1/responses),
 	// not via /chat/completions and not via /images/edits.
@@ -115,15 +166,7 @@ func (p *OpenAIProvider) generateWithChatCompletion(prompt string, contextData s
 	// response.output[].type === "image_generation_call" with output[].result (base64 image).
 
 	// Combine prompt and context
-	fullPrompt := prompt
-	if contextData != "" {
-		fullPrompt = fmt.Sprintf("Context information:\n%s\n\nBased on the above context, generate an image for: %s", contextData, prompt)
-	}
-
-	// Enforce max 5 reference images (as per spec)
-	if len(refImages) > 5 {
-		refImages = refImages[:5]
-	}
+	fullPrompt := request.FullPrompt()
 
 	// Build Responses API input content
 	type responsesInputContent struct {
@@ -144,14 +187,14 @@ func (p *OpenAIProvider) generateWithChatCompletion(prompt string, contextData s
 		ToolChoice interface{}              `json:"tool_choice,omitempty"`
 	}
 
-	content := make([]responsesInputContent, 0, 1+len(refImages))
+	content := make([]responsesInputContent, 0, 1+len(request.RefImages))
 	// Strongly steer the model to call the image_generation tool (otherwise it may answer in text).
 	// Keep this instruction inside the user content to work across OpenAI-compatible providers.
 	content = append(content, responsesInputContent{
 		Type: "input_text",
 		Text: "You MUST generate an image by calling the image_generation tool exactly once. Do not answer with text.\n\n" + fullPrompt,
 	})
-	for _, img := range refImages {
+	for _, img := range request.RefImages {
 		// Expect img to be a data URL: data:image/...;base64,...
 		content = append(content, responsesInputContent{
 			Type:     "input_image",
@@ -218,8 +261,8 @@ func (p *OpenAIProvider) generateWithChatCompletion(prompt string, contextData s
 			// (This is separate from the controller `model`.)
 			if imageToolModel != "" {
 				tool["model"] = imageToolModel
-				tool["quality"] = "medium"
-				tool["size"] = "1536x1024"
+				tool["quality"] = valueOrDefault(request.Quality, "medium")
+				tool["size"] = valueOrDefault(request.Size, "1536x1024")
 			}
 
 			reqBody := responsesRequest{
@@ -317,10 +360,10 @@ func (p *OpenAIProvider) generateWithChatCompletion(prompt string, contextData s
 	return "", fmt.Errorf("failed to generate image: no model candidates")
 }
 
-func (p *OpenAIProvider) Generate(prompt string, contextData string, refImages []string) (string, error) {
-	if len(refImages) > 0 {
-		return p.generateWithChatCompletion(prompt, contextData, refImages)
+func (p *OpenAIProvider) Generate(request ImageGenRequest) (string, error) {
+	if len(request.RefImages) > 0 {
+		return p.generateWithChatCompletion(request)
 	} else {
-		return p.generateImage(prompt, contextData)
+		return p.generateImage(request)
 	}
 }
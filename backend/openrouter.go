@@ -11,29 +11,62 @@ import (
 	"time"
 )
 
+func init() {
+	RegisterImageProvider(ImageProviderRegistration{
+		Name:        "openrouter",
+		DisplayName: "OpenRouter",
+		Default: func() ProviderConfig {
+			return &OpenRouterConfig{
+				BaseURL: "https://openrouter.ai/api/v1",
+				Model:   "sourceful/riverflow-v2-standard-preview",
+			}
+		},
+		FromLegacy: func(baseURL string, model string, apiKey string) ProviderConfig {
+			return &OpenRouterConfig{
+				BaseURL: baseURL,
+				Model:   model,
+				APIKey:  apiKey,
+			}
+		},
+		New: func(cfg ProviderConfig, baseCfg *ImageGenConfig, service *ImageGenService) ImageGenProvider {
+			return &OpenRouterProvider{
+				config:  cfg.(*OpenRouterConfig),
+				baseCfg: baseCfg,
+				service: service,
+			}
+		},
+	})
+}
+
 type OpenRouterProvider struct {
 	config    *OpenRouterConfig
 	baseCfg   *ImageGenConfig
 	service   *ImageGenService
 }
 
+// Capabilities implements ImageGenProvider.Capabilities for OpenRouterProvider
+func (p *OpenRouterProvider) Capabilities() ImageGenCapabilities {
+	return ImageGenCapabilities{
+		MaxReferenceImages: -1,
+		Formats:            []string{"image/png", "image/jpeg", "image/webp"},
+		SupportsEdit:       true,
+	}
+}
+
 // Generate implements ImageGenProvider.Generate for OpenRouterProvider
-func (p *OpenRouterProvider) Generate(prompt string, contextData string, refImages []string) (string, error) {
+func (p *OpenRouterProvider) Generate(request ImageGenRequest) (string, error) {
 	// Combine prompt and context for better generation
-	fullPrompt := prompt
-	if contextData != "" {
-		fullPrompt = fmt.Sprintf("Context information:\n%s\n\nBased on the above context, generate an image for: %s", contextData, prompt)
-	}
+	fullPrompt := request.FullPrompt()
 
 	// Prepare the message content
 	var messageContent interface{} = fullPrompt
-	if len(refImages) > 0 {
-		contentParts := make([]ContentPart, 0, 1+len(refImages))
+	if len(request.RefImages) > 0 {
+		contentParts := make([]ContentPart, 0, 1+len(request.RefImages))
 		contentParts = append(contentParts, ContentPart{
 			Type: "text",
 			Text: fullPrompt,
 		})
-		for _, imgURL := range refImages {
+		for _, imgURL := range request.RefImages {
 			contentParts = append(contentParts, ContentPart{
 				Type: "image_url",
 				ImageURL: &ImageURL{
@@ -0,0 +1,119 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ImageProvenance records how a generated image was produced.
+// It is stored as a JSON sidecar next to the image ("<image>.json").
+type ImageProvenance struct {
+	Image           string                 `json:"image"`     // Relative path of the generated image
+	CreatedAt       string                 `json:"createdAt"` // RFC3339 timestamp
+	Provider        string                 `json:"provider"`
+	Model           string                 `json:"model"`
+	Prompt          string                 `json:"prompt"` // Prompt as entered by the user
+	Context         string                 `json:"context"`
+	EnhancedPrompt  string                 `json:"enhancedPrompt,omitempty"` // LLM rewrite that was sent instead of prompt and context
+	NegativePrompt  string                 `json:"negativePrompt,omitempty"`
+	RefImages       []string               `json:"refImages"` // Relative paths of the stored reference images
+	Parameters      map[string]interface{} `json:"parameters,omitempty"`
+	RegeneratedFrom string                 `json:"regeneratedFrom,omitempty"`
+	Parent          string                 `json:"parent,omitempty"`     // Image this one was derived from by TransformImage
+	Operations      []ImageOperation       `json:"operations,omitempty"` // Operations applied to the parent
+}
+
+// provenanceSidecarPath returns the sidecar path for an image file
+func provenanceSidecarPath(imagePath string) string {
+	return imagePath + ".json"
+}
+
+// readImageProvenance loads the provenance sidecar of the image at the given relative path
+func readImageProvenance(configService *ConfigService, src string) (*ImageProvenance, error) {
+	imagePath, err := configService.ResolveImagePath(src)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(provenanceSidecarPath(imagePath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no provenance recorded for image: %s", src)
+		}
+		return nil, fmt.Errorf("failed to read provenance: %w", err)
+	}
+
+	var prov ImageProvenance
+	if err := json.Unmarshal(data, &prov); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal provenance: %w", err)
+	}
+	return &prov, nil
+}
+
+// writeProvenance stores the provenance sidecar for a generated image
+func (s *ImageGenService) writeProvenance(relPath string, prov *ImageProvenance) error {
+	return writeImageProvenance(s.configService, relPath, prov)
+}
+
+// writeImageProvenance stores the provenance sidecar of the image at the given relative path
+func writeImageProvenance(configService *ConfigService, relPath string, prov *ImageProvenance) error {
+	imagePath, err := configService.ResolveImagePath(relPath)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(prov, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal provenance: %w", err)
+	}
+
+	if err := os.WriteFile(provenanceSidecarPath(imagePath), data, 0644); err != nil {
+		return fmt.Errorf("failed to write provenance: %w", err)
+	}
+	return nil
+}
+
+// saveReferenceImages stores the reference images of a request in the asset store
+// so the request can be replayed later. References already on the canvas dedupe
+// against their existing asset.
+func (s *ImageGenService) saveReferenceImages(refImages []string) ([]string, error) {
+	relPaths := make([]string, 0, len(refImages))
+	for _, dataURL := range refImages {
+		mimeType, data, err := decodeDataURL(dataURL)
+		if err != nil {
+			return relPaths, err
+		}
+
+		relPath, err := s.assetStore.Put(data, extensionForMIME(mimeType), "")
+		if err != nil {
+			return relPaths, fmt.Errorf("failed to save reference image: %w", err)
+		}
+		relPaths = append(relPaths, relPath)
+	}
+
+	return relPaths, nil
+}
+
+// loadReferenceImages reads stored reference images back into data URLs
+func (s *ImageGenService) loadReferenceImages(relPaths []string) ([]string, error) {
+	refImages := make([]string, 0, len(relPaths))
+	for _, relPath := range relPaths {
+		if strings.Contains(relPath, "..") {
+			return nil, fmt.Errorf("path traversal is not allowed")
+		}
+
+		fullPath, err := s.configService.ResolveImagePath(relPath)
+		if err != nil {
+			return nil, err
+		}
+
+		dataURL, err := imageFileToDataURL(fullPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load reference image %s: %w", relPath, err)
+		}
+		refImages = append(refImages, dataURL)
+	}
+	return refImages, nil
+}
@@ -0,0 +1,251 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// ImageProviderRegistration wires an image generation provider into ImageGenConfig and ImageGenService.
+// Providers register themselves from an init function with RegisterImageProvider; their settings are
+// stored in ImageGenConfig.Providers under Name, and the settings UI is built from the config schema.
+type ImageProviderRegistration struct {
+	Name        string // Value of ImageGenConfig.Provider and key in ImageGenConfig.Providers
+	DisplayName string
+
+	// Default returns the settings used for a fresh configuration.
+	// It must return a pointer to a struct, which is also the type stored settings are decoded into.
+	Default func() ProviderConfig
+	// FromLegacy builds settings from the pre-multi-provider baseURL/model/apiKey fields (optional)
+	FromLegacy func(baseURL string, model string, apiKey string) ProviderConfig
+	// New creates a provider instance for a single request
+	New func(cfg ProviderConfig, baseCfg *ImageGenConfig, service *ImageGenService) ImageGenProvider
+}
+
+// ImageProviderInfo describes a registered provider and its config schema for the frontend
+type ImageProviderInfo struct {
+	Name        string                `json:"name"`
+	DisplayName string                `json:"displayName"`
+	Fields      []ProviderConfigField `json:"fields"`
+}
+
+// ProviderConfigField describes a single provider setting, derived from the config struct
+type ProviderConfigField struct {
+	Key     string      `json:"key"`   // JSON key in the provider config
+	Label   string      `json:"label"` // Human readable label
+	Type    string      `json:"type"`  // "string", "secret", "number", "boolean" or "list"
+	Default interface{} `json:"default,omitempty"`
+}
+
+var (
+	imageProvidersMu sync.RWMutex
+	imageProviders   = map[string]ImageProviderRegistration{}
+)
+
+// RegisterImageProvider makes an image provider available under reg.Name.
+// It panics on incomplete or duplicate registrations, as those are programming errors.
+func RegisterImageProvider(reg ImageProviderRegistration) {
+	if reg.Name == "" || reg.Default == nil || reg.New == nil {
+		panic(fmt.Sprintf("incomplete image provider registration: %q", reg.Name))
+	}
+
+	imageProvidersMu.Lock()
+	defer imageProvidersMu.Unlock()
+
+	if _, exists := imageProviders[reg.Name]; exists {
+		panic(fmt.Sprintf("image provider registered twice: %q", reg.Name))
+	}
+	imageProviders[reg.Name] = reg
+}
+
+// lookupImageProvider returns the registration for a provider name
+func lookupImageProvider(name string) (ImageProviderRegistration, bool) {
+	imageProvidersMu.RLock()
+	defer imageProvidersMu.RUnlock()
+	reg, ok := imageProviders[name]
+	return reg, ok
+}
+
+// registeredImageProviders returns all registrations sorted by display name
+func registeredImageProviders() []ImageProviderRegistration {
+	imageProvidersMu.RLock()
+	defer imageProvidersMu.RUnlock()
+
+	regs := make([]ImageProviderRegistration, 0, len(imageProviders))
+	for _, reg := range imageProviders {
+		regs = append(regs, reg)
+	}
+	sort.Slice(regs, func(i, j int) bool {
+		return regs[i].DisplayName < regs[j].DisplayName
+	})
+	return regs
+}
+
+// ListImageProviders returns the registered providers with their config schema
+func ListImageProviders() []ImageProviderInfo {
+	regs := registeredImageProviders()
+	infos := make([]ImageProviderInfo, 0, len(regs))
+	for _, reg := range regs {
+		infos = append(infos, ImageProviderInfo{
+			Name:        reg.Name,
+			DisplayName: reg.DisplayName,
+			Fields:      configSchema(reg.Default()),
+		})
+	}
+	return infos
+}
+
+// configSchema derives the field list from the JSON tags of a provider config struct.
+// Fields named "apiKey" are reported as secrets; fields tagged `schema:"-"` are not shown.
+func configSchema(cfg ProviderConfig) []ProviderConfigField {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	t := v.Type()
+	fields := make([]ProviderConfigField, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		key := strings.Split(sf.Tag.Get("json"), ",")[0]
+		if !sf.IsExported() || key == "" || key == "-" || sf.Tag.Get("schema") == "-" {
+			continue
+		}
+
+		field := ProviderConfigField{
+			Key:   key,
+			Label: fieldLabel(sf.Name),
+		}
+		switch sf.Type.Kind() {
+		case reflect.String:
+			field.Type = "string"
+			if key == "apiKey" {
+				field.Type = "secret"
+			}
+		case reflect.Int, reflect.Int32, reflect.Int64, reflect.Float32, reflect.Float64:
+			field.Type = "number"
+		case reflect.Bool:
+			field.Type = "boolean"
+		case reflect.Slice:
+			field.Type = "list"
+		default:
+			continue
+		}
+
+		if fv := v.Field(i); !fv.IsZero() && field.Type != "secret" {
+			field.Default = fv.Interface()
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// secretValues returns the non-empty values of the secret fields ("apiKey") of a provider config
+func secretValues(cfg ProviderConfig) []string {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var secrets []string
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		key := strings.Split(sf.Tag.Get("json"), ",")[0]
+		if key != "apiKey" || sf.Type.Kind() != reflect.String {
+			continue
+		}
+		if value := v.Field(i).String(); value != "" {
+			secrets = append(secrets, value)
+		}
+	}
+	return secrets
+}
+
+// recordedSettings returns a provider config as a JSON object for provenance, without its secret fields
+func recordedSettings(cfg ProviderConfig) map[string]interface{} {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil
+	}
+	var settings map[string]interface{}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil
+	}
+	delete(settings, "apiKey")
+	return settings
+}
+
+// fieldLabel turns a Go field name into a label, e.g. "BaseURL" -> "Base URL", "CFGScale" -> "CFG Scale"
+func fieldLabel(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prevLower := unicode.IsLower(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				b.WriteRune(' ')
+			}
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// cloneWithModel returns a copy of a provider config with its "Model" field replaced, if it has one
+func cloneWithModel(cfg ProviderConfig, model string) ProviderConfig {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return cfg
+	}
+
+	cp := reflect.New(v.Elem().Type())
+	cp.Elem().Set(v.Elem())
+	if f := cp.Elem().FieldByName("Model"); f.IsValid() && f.Kind() == reflect.String && f.CanSet() {
+		f.SetString(model)
+	}
+	return cp.Interface().(ProviderConfig)
+}
+
+// decodeProviderConfig reads stored provider settings into the registered config type.
+// Missing fields keep their defaults.
+func decodeProviderConfig(reg ImageProviderRegistration, data []byte) (ProviderConfig, error) {
+	cfg := reg.Default()
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s config: %w", reg.Name, err)
+	}
+	return cfg, nil
+}
+
+// rawProviderConfig keeps the settings of a provider that is not registered (e.g. removed in this build)
+// so they survive saving the configuration
+type rawProviderConfig struct {
+	name string
+	data json.RawMessage
+}
+
+func (c *rawProviderConfig) GetProvider() string {
+	return c.name
+}
+
+func (c *rawProviderConfig) GetModel() string {
+	return ""
+}
+
+func (c *rawProviderConfig) MarshalJSON() ([]byte, error) {
+	return c.data, nil
+}
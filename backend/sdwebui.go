@@ -0,0 +1,185 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterImageProvider(ImageProviderRegistration{
+		Name:        "sdwebui",
+		DisplayName: "Stable Diffusion WebUI (Local)",
+		Default: func() ProviderConfig {
+			return &SDWebUIConfig{
+				BaseURL:           "http://127.0.0.1:7860",
+				Steps:             20,
+				Width:             1024,
+				Height:            1024,
+				CFGScale:          7,
+				SamplerName:       "Euler a",
+				DenoisingStrength: 0.6,
+				Seed:              -1,
+			}
+		},
+		FromLegacy: func(baseURL string, model string, apiKey string) ProviderConfig {
+			return &SDWebUIConfig{
+				BaseURL: baseURL,
+				Model:   model,
+				Seed:    -1,
+			}
+		},
+		New: func(cfg ProviderConfig, baseCfg *ImageGenConfig, service *ImageGenService) ImageGenProvider {
+			return &SDWebUIProvider{
+				config:  cfg.(*SDWebUIConfig),
+				baseCfg: baseCfg,
+				service: service,
+			}
+		},
+	})
+}
+
+type SDWebUIProvider struct {
+	config  *SDWebUIConfig
+	baseCfg *ImageGenConfig
+	service *ImageGenService
+}
+
+// Capabilities implements ImageGenProvider.Capabilities for SDWebUIProvider
+func (p *SDWebUIProvider) Capabilities() ImageGenCapabilities {
+	return ImageGenCapabilities{
+		MaxReferenceImages:     1, // img2img takes a single init image
+		Formats:                []string{"image/png"},
+		SupportsEdit:           true,
+		SupportsNegativePrompt: true,
+	}
+}
+
+// resolve implements resolvableProviderConfig: a random seed is picked here instead of by the WebUI
+func (c *SDWebUIConfig) resolve() (ProviderConfig, error) {
+	resolved := *c
+	if resolved.Seed < 0 {
+		resolved.Seed = rand.Int63n(1 << 32)
+	}
+	return &resolved, nil
+}
+
+// Generate implements ImageGenProvider.Generate for SDWebUIProvider
+func (p *SDWebUIProvider) Generate(request ImageGenRequest) (string, error) {
+	// Combine prompt and context for better generation
+	fullPrompt := request.FullPrompt()
+
+	// Prepare the request payload, falling back to WebUI defaults for unset values
+	payload := map[string]interface{}{
+		"prompt":          fullPrompt,
+		"negative_prompt": request.NegativePrompt,
+		"steps":           valueOrDefault(p.config.Steps, 20),
+		"width":           valueOrDefault(p.config.Width, 1024),
+		"height":          valueOrDefault(p.config.Height, 1024),
+		"cfg_scale":       valueOrDefault(p.config.CFGScale, 7),
+		"sampler_name":    valueOrDefault(p.config.SamplerName, "Euler a"),
+		"seed":            p.config.Seed,
+		"batch_size":      1,
+		"n_iter":          1,
+	}
+	if width, height, ok := parseImageSize(request.Size); ok {
+		payload["width"] = width
+		payload["height"] = height
+	}
+	if p.config.Model != "" {
+		payload["override_settings"] = map[string]interface{}{
+			"sd_model_checkpoint": p.config.Model,
+		}
+	}
+
+	endpoint := "txt2img"
+	if len(request.RefImages) > 0 {
+		// img2img uses a single init image
+		_, data, err := splitDataURL(request.RefImages[0])
+		if err != nil {
+			return "", fmt.Errorf("invalid reference image: %w", err)
+		}
+		payload["init_images"] = []string{data}
+		payload["denoising_strength"] = valueOrDefault(p.config.DenoisingStrength, 0.6)
+		endpoint = "img2img"
+	}
+
+	// Convert payload to JSON
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request payload: %w", err)
+	}
+
+	// Create HTTP request
+	baseURL := strings.TrimSuffix(valueOrDefault(p.config.BaseURL, "http://127.0.0.1:7860"), "/")
+	url := fmt.Sprintf("%s/sdapi/v1/%s", baseURL, endpoint)
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	// Send request (local generation can be slow on large images)
+	client := &http.Client{Timeout: 600 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request to Stable Diffusion WebUI: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Read response
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Stable Diffusion WebUI returned error status %d: %s", resp.StatusCode, string(body))
+	}
+
+	// Parse response
+	var result struct {
+		Images []string `json:"images"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if len(result.Images) == 0 || result.Images[0] == "" {
+		return "", fmt.Errorf("no image data in response")
+	}
+
+	// WebUI returns PNG images as plain base64
+	dataURL := fmt.Sprintf("data:image/png;base64,%s", result.Images[0])
+	return p.service.downloadAndSaveImage(dataURL)
+}
+
+// splitDataURL returns the MIME type and the raw base64 payload of a data URL
+func splitDataURL(dataURL string) (string, string, error) {
+	if !strings.HasPrefix(dataURL, "data:") {
+		return "", "", fmt.Errorf("invalid data URL format")
+	}
+
+	parts := strings.SplitN(dataURL, ",", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("invalid data URL format")
+	}
+
+	header := strings.Split(parts[0], ";")[0]
+	return strings.TrimPrefix(header, "data:"), parts[1], nil
+}
+
+// valueOrDefault returns value unless it is the zero value, in which case def is returned
+func valueOrDefault[T comparable](value T, def T) T {
+	var zero T
+	if value == zero {
+		return def
+	}
+	return value
+}
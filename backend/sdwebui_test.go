@@ -0,0 +1,233 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestImageGenService returns an ImageGenService that stores images in a temporary directory
+func newTestImageGenService(t *testing.T) *ImageGenService {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := defaultConfig()
+	cfg.ImageGen.DownloadPath = dir
+	configService := &ConfigService{config: cfg, configPath: filepath.Join(dir, "config.json")}
+	return NewImageGenService(configService, NewAssetStore(configService), NewLLMService(configService), nil)
+}
+
+// testPNG returns the bytes of a small PNG image
+func testPNG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// assertStoredImage checks that a relative image path returned by a provider exists
+func assertStoredImage(t *testing.T, service *ImageGenService, relPath string) {
+	t.Helper()
+	imagePath, err := service.configService.ResolveImagePath(relPath)
+	if err != nil {
+		t.Fatalf("ResolveImagePath(%q): %v", relPath, err)
+	}
+	if _, err := os.Stat(imagePath); err != nil {
+		t.Fatalf("generated image not stored: %v", err)
+	}
+}
+
+// sdWebUITestServer records the endpoint and payload of the last request and answers with a PNG
+func sdWebUITestServer(t *testing.T, endpoint *string, payload *map[string]interface{}) *httptest.Server {
+	t.Helper()
+	pngData := testPNG(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*endpoint = r.URL.Path
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, payload); err != nil {
+			t.Errorf("request body is not JSON: %v", err)
+		}
+		fmt.Fprintf(w, `{"images":[%q]}`, base64.StdEncoding.EncodeToString(pngData))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSDWebUITxt2ImgPayload(t *testing.T) {
+	var endpoint string
+	var payload map[string]interface{}
+	server := sdWebUITestServer(t, &endpoint, &payload)
+
+	service := newTestImageGenService(t)
+	provider := &SDWebUIProvider{
+		config: &SDWebUIConfig{
+			BaseURL:     server.URL + "/",
+			Model:       "sdxl.safetensors",
+			Steps:       12,
+			Width:       768,
+			Height:      512,
+			CFGScale:    5.5,
+			SamplerName: "DPM++ 2M",
+			Seed:        42,
+		},
+		service: service,
+	}
+
+	relPath, err := provider.Generate(ImageGenRequest{Prompt: "a cat", NegativePrompt: "blurry"})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	assertStoredImage(t, service, relPath)
+
+	if endpoint != "/sdapi/v1/txt2img" {
+		t.Errorf("endpoint = %q, want /sdapi/v1/txt2img", endpoint)
+	}
+	want := map[string]interface{}{
+		"prompt":          "a cat",
+		"negative_prompt": "blurry",
+		"steps":           12.0,
+		"width":           768.0,
+		"height":          512.0,
+		"cfg_scale":       5.5,
+		"sampler_name":    "DPM++ 2M",
+		"seed":            42.0,
+		"batch_size":      1.0,
+		"n_iter":          1.0,
+	}
+	for key, value := range want {
+		if payload[key] != value {
+			t.Errorf("payload[%q] = %v, want %v", key, payload[key], value)
+		}
+	}
+	override, _ := payload["override_settings"].(map[string]interface{})
+	if override["sd_model_checkpoint"] != "sdxl.safetensors" {
+		t.Errorf("override_settings = %v, want the configured checkpoint", payload["override_settings"])
+	}
+	if _, ok := payload["init_images"]; ok {
+		t.Error("txt2img payload must not contain init_images")
+	}
+}
+
+func TestSDWebUITxt2ImgDefaults(t *testing.T) {
+	var endpoint string
+	var payload map[string]interface{}
+	server := sdWebUITestServer(t, &endpoint, &payload)
+
+	provider := &SDWebUIProvider{
+		config:  &SDWebUIConfig{BaseURL: server.URL, Seed: -1},
+		service: newTestImageGenService(t),
+	}
+	if _, err := provider.Generate(ImageGenRequest{Prompt: "a cat", Context: "notes"}); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"steps":        20.0,
+		"width":        1024.0,
+		"height":       1024.0,
+		"cfg_scale":    7.0,
+		"sampler_name": "Euler a",
+		"seed":         -1.0,
+	}
+	for key, value := range want {
+		if payload[key] != value {
+			t.Errorf("payload[%q] = %v, want %v", key, payload[key], value)
+		}
+	}
+	if prompt, _ := payload["prompt"].(string); !strings.Contains(prompt, "notes") || !strings.Contains(prompt, "a cat") {
+		t.Errorf("prompt = %q, want prompt and context combined", prompt)
+	}
+	if _, ok := payload["override_settings"]; ok {
+		t.Error("override_settings must be omitted without a model")
+	}
+}
+
+func TestSDWebUIImg2ImgPayload(t *testing.T) {
+	var endpoint string
+	var payload map[string]interface{}
+	server := sdWebUITestServer(t, &endpoint, &payload)
+
+	service := newTestImageGenService(t)
+	provider := &SDWebUIProvider{
+		config:  &SDWebUIConfig{BaseURL: server.URL, DenoisingStrength: 0.35},
+		service: service,
+	}
+
+	initImage := base64.StdEncoding.EncodeToString(testPNG(t))
+	relPath, err := provider.Generate(ImageGenRequest{
+		Prompt:    "a cat",
+		RefImages: []string{"data:image/png;base64," + initImage},
+		Size:      "512x768",
+	})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	assertStoredImage(t, service, relPath)
+
+	if endpoint != "/sdapi/v1/img2img" {
+		t.Errorf("endpoint = %q, want /sdapi/v1/img2img", endpoint)
+	}
+	initImages, _ := payload["init_images"].([]interface{})
+	if len(initImages) != 1 || initImages[0] != initImage {
+		t.Errorf("init_images = %v, want the raw base64 of the reference image", payload["init_images"])
+	}
+	if payload["denoising_strength"] != 0.35 {
+		t.Errorf("denoising_strength = %v, want 0.35", payload["denoising_strength"])
+	}
+	if payload["width"] != 512.0 || payload["height"] != 768.0 {
+		t.Errorf("size = %vx%v, want the requested 512x768", payload["width"], payload["height"])
+	}
+}
+
+func TestSDWebUIErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{"error status", http.StatusInternalServerError, `{"error":"CUDA out of memory"}`, "error status 500"},
+		{"no images", http.StatusOK, `{"images":[]}`, "no image data"},
+		{"invalid JSON", http.StatusOK, `not json`, "failed to unmarshal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			}))
+			defer server.Close()
+
+			provider := &SDWebUIProvider{
+				config:  &SDWebUIConfig{BaseURL: server.URL},
+				service: newTestImageGenService(t),
+			}
+			_, err := provider.Generate(ImageGenRequest{Prompt: "a cat"})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Generate error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSDWebUIInvalidReferenceImage(t *testing.T) {
+	provider := &SDWebUIProvider{
+		config:  &SDWebUIConfig{BaseURL: "http://127.0.0.1:1"},
+		service: newTestImageGenService(t),
+	}
+	_, err := provider.Generate(ImageGenRequest{Prompt: "a cat", RefImages: []string{"not a data URL"}})
+	if err == nil || !strings.Contains(err.Error(), "invalid reference image") {
+		t.Fatalf("Generate error = %v, want an invalid reference image error", err)
+	}
+}
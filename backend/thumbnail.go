@@ -0,0 +1,127 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+
+	_ "image/gif" // Register GIF decoder
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // Register WebP decoder
+)
+
+const (
+	// thumbDirName is the directory (relative to the download path) holding cached thumbnails
+	thumbDirName = "Thumbs"
+
+	minThumbnailEdge = 16
+	maxThumbnailEdge = 4096
+)
+
+// GetImageThumbnail returns a data URL of the image downscaled so that its longest edge is at most maxEdge.
+// Thumbnails are cached on disk by source content hash and size, so a changed source gets a fresh thumbnail.
+func (s *ImageAssetService) GetImageThumbnail(src string, maxEdge int) (string, error) {
+	if maxEdge < minThumbnailEdge || maxEdge > maxThumbnailEdge {
+		return "", fmt.Errorf("maxEdge must be between %d and %d", minThumbnailEdge, maxThumbnailEdge)
+	}
+
+	filePath, err := s.configService.ResolveImagePath(src)
+	if err != nil {
+		return "", err
+	}
+
+	hash, err := s.hashCache.hash(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	downloadPath, err := s.configService.ResolveDownloadPath()
+	if err != nil {
+		return "", err
+	}
+	thumbDir := filepath.Join(downloadPath, thumbDirName)
+
+	// Serve from cache when available
+	for _, ext := range []string{".jpg", ".png"} {
+		cached := filepath.Join(thumbDir, fmt.Sprintf("%s_%d%s", hash, maxEdge, ext))
+		if _, err := os.Stat(cached); err == nil {
+			return imageFileToDataURL(cached)
+		}
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open image file: %w", err)
+	}
+	img, _, err := image.Decode(file)
+	file.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	// Small images are served as-is
+	bounds := img.Bounds()
+	if bounds.Dx() <= maxEdge && bounds.Dy() <= maxEdge {
+		return imageFileToDataURL(filePath)
+	}
+
+	thumb := resizeToFit(img, maxEdge)
+
+	var buf bytes.Buffer
+	ext := ".png"
+	mimeType := "image/png"
+	if isOpaque(thumb) {
+		ext = ".jpg"
+		mimeType = "image/jpeg"
+		err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 85})
+	} else {
+		err = png.Encode(&buf, thumb)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+
+	// Caching is best effort; the thumbnail is returned even if it cannot be written
+	if err := os.MkdirAll(thumbDir, 0755); err != nil {
+		fmt.Printf("Warning: failed to create thumbnail directory: %v\n", err)
+	} else {
+		cached := filepath.Join(thumbDir, fmt.Sprintf("%s_%d%s", hash, maxEdge, ext))
+		if err := writeFileAtomic(cached, buf.Bytes(), 0644); err != nil {
+			fmt.Printf("Warning: failed to cache thumbnail: %v\n", err)
+		}
+	}
+
+	return fmt.Sprintf("data:%s;base64,%s", mimeType, base64.StdEncoding.EncodeToString(buf.Bytes())), nil
+}
+
+// resizeToFit scales img down so that its longest edge equals maxEdge, keeping the aspect ratio
+func resizeToFit(img image.Image, maxEdge int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if width >= height {
+		height = max(1, height*maxEdge/width)
+		width = maxEdge
+	} else {
+		width = max(1, width*maxEdge/height)
+		height = maxEdge
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Src, nil)
+	return dst
+}
+
+// isOpaque reports whether every pixel of img is fully opaque
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
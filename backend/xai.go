@@ -6,22 +6,58 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 )
 
+// defaultXAIBaseURL is the xAI API endpoint used when XAIConfig.BaseURL is empty
+const defaultXAIBaseURL = "https://api.x.ai/v1"
+
+func init() {
+	RegisterImageProvider(ImageProviderRegistration{
+		Name:        "xai",
+		DisplayName: "xAI",
+		Default: func() ProviderConfig {
+			return &XAIConfig{
+				BaseURL: defaultXAIBaseURL,
+				Model:   "grok-imagine-image",
+			}
+		},
+		FromLegacy: func(baseURL string, model string, apiKey string) ProviderConfig {
+			return &XAIConfig{
+				Model:  model,
+				APIKey: apiKey,
+			}
+		},
+		New: func(cfg ProviderConfig, baseCfg *ImageGenConfig, service *ImageGenService) ImageGenProvider {
+			return &XAIProvider{
+				config:  cfg.(*XAIConfig),
+				baseCfg: baseCfg,
+				service: service,
+			}
+		},
+	})
+}
+
 type XAIProvider struct {
 	config  *XAIConfig
 	baseCfg *ImageGenConfig
 	service *ImageGenService
 }
 
+// Capabilities implements ImageGenProvider.Capabilities for XAIProvider
+func (p *XAIProvider) Capabilities() ImageGenCapabilities {
+	return ImageGenCapabilities{
+		MaxReferenceImages: 1,
+		Formats:            []string{"image/jpeg"},
+		SupportsEdit:       true,
+	}
+}
+
 // Generate implements ImageGenProvider.Generate for XAIProvider
-func (p *XAIProvider) Generate(prompt string, contextData string, refImages []string) (string, error) {
+func (p *XAIProvider) Generate(request ImageGenRequest) (string, error) {
 	// Combine prompt and context for better generation
-	fullPrompt := prompt
-	if contextData != "" {
-		fullPrompt = fmt.Sprintf("Context information:\n%s\n\nBased on the above context, generate an image for: %s", contextData, prompt)
-	}
+	fullPrompt := request.FullPrompt()
 
 	// Prepare the request payload for xAI Image Generation
 	payload := map[string]interface{}{
@@ -31,11 +67,11 @@ func (p *XAIProvider) Generate(prompt string, contextData string, refImages []st
 		"n":               1,
 	}
 
-	// Add reference image if provided (xAI supports only 1 reference image)
-	if len(refImages) > 0 {
+	// Add reference image if provided (xAI supports only 1 reference image, see Capabilities)
+	if len(request.RefImages) > 0 {
 		// xAI API accepts data URL format (e.g., "data:image/jpeg;base64,...")
 		payload["image"] = map[string]interface{}{
-			"url": refImages[0],
+			"url": request.RefImages[0],
 		}
 	}
 	// If no reference image, do not include "image" parameter at all
@@ -48,10 +84,11 @@ func (p *XAIProvider) Generate(prompt string, contextData string, refImages []st
 
 	// Create HTTP request
 	// Use different endpoint for image editing vs generation
-	url := "https://api.x.ai/v1/images/generations"
-	if len(refImages) > 0 {
+	baseURL := strings.TrimSuffix(valueOrDefault(p.config.BaseURL, defaultXAIBaseURL), "/")
+	url := baseURL + "/images/generations"
+	if len(request.RefImages) > 0 {
 		// Use edits endpoint when reference image is provided
-		url = "https://api.x.ai/v1/images/edits"
+		url = baseURL + "/images/edits"
 	}
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
@@ -11,6 +11,9 @@ import SettingsDrawer from "./components/drawer/SettingsDrawer";
 import LayoutButton from "./components/layout/LayoutButton";
 
 import { useAppStore } from "./store/useAppStore";
+import { EventsOn } from "../wailsjs/runtime/runtime";
+import * as AppBackend from "../wailsjs/go/main/App";
+import { CanvasFileChange } from "./types";
 
 import { Save, FolderOpen, Plus, Menu } from "lucide-react";
 
@@ -21,18 +24,43 @@ function App() {
     addNode,
     addEmptyNode,
     nodes,
+    edges,
     loadConfig,
     saveCanvas,
     loadCanvas,
     setSettingsOpen,
+    pushAutosave,
+    recoverSession,
+    currentFilePath,
+    handleExternalChange,
   } = useAppStore();
   const initialNodeAdded = useRef(false);
+  const recoveryChecked = useRef(false);
+  const configWarningsShown = useRef(false);
+  const [autosaveReady, setAutosaveReady] = useState(false);
 
   // Load configuration on startup
   useEffect(() => {
     loadConfig();
   }, [loadConfig]);
 
+  // Tell the user when the settings file was damaged and restored or reset
+  useEffect(() => {
+    if (configWarningsShown.current) return; // StrictMode runs effects twice
+    configWarningsShown.current = true;
+    AppBackend.GetConfigWarnings()
+      .then((warnings) => {
+        if (warnings && warnings.length > 0) {
+          alert(
+            `Settings warnings:\n${warnings.map((w) => `- ${w}`).join("\n")}`,
+          );
+        }
+      })
+      .catch((error) =>
+        console.error("Failed to get config warnings:", error),
+      );
+  }, []);
+
   // Add an initial node if the canvas is empty (for demo/development)
   useEffect(() => {
     if (nodes.length === 0 && !initialNodeAdded.current) {
@@ -53,6 +81,31 @@ function App() {
     }
   }, [addNode, nodes.length]);
 
+  // Offer to restore unsaved work from a previous run before autosave starts
+  useEffect(() => {
+    if (recoveryChecked.current) return; // StrictMode runs effects twice
+    recoveryChecked.current = true;
+    recoverSession().finally(() => setAutosaveReady(true));
+  }, [recoverSession]);
+
+  // Push canvas changes to the backend autosave (debounced; the backend throttles writes)
+  useEffect(() => {
+    if (!autosaveReady) return;
+    const timer = setTimeout(() => {
+      pushAutosave();
+    }, 1000);
+    return () => clearTimeout(timer);
+  }, [nodes, edges, autosaveReady, pushAutosave]);
+
+  // The backend watches the current canvas file and reports changes made by other programs
+  useEffect(() => {
+    return EventsOn("canvas:file-changed", (change: CanvasFileChange) => {
+      handleExternalChange(change).catch((error) =>
+        console.error("Failed to reload canvas:", error),
+      );
+    });
+  }, [handleExternalChange]);
+
   const handleSave = async () => {
     try {
       const path = await saveCanvas();
@@ -84,6 +137,14 @@ function App() {
             FM
           </div>
           <h1 className="text-sm font-bold tracking-tight">FM Doc Canvas</h1>
+          <span
+            className="text-xs text-gray-500 truncate max-w-xs"
+            title={currentFilePath || undefined}
+          >
+            {currentFilePath
+              ? currentFilePath.split(/[\\/]/).pop()
+              : "Untitled"}
+          </span>
         </div>
 
         <div className="flex items-center gap-1">
@@ -14,7 +14,8 @@ import { useAppStore } from "../../store/useAppStore";
 import CustomNode from "./CustomNode";
 import ImageNode from "./ImageNode";
 import ContextMenu from "../ui/ContextMenu";
-import { AppNode, AppEdge } from "../../types";
+import { IMAGE_LIBRARY_DRAG_TYPE } from "../drawer/ImageLibrary";
+import { AppNode, AppEdge, ImportFileResult } from "../../types";
 import * as AppBackend from "../../../wailsjs/go/main/App";
 import { OnFileDrop, OnFileDropOff } from "../../../wailsjs/runtime/runtime";
 
@@ -43,7 +44,7 @@ const CanvasArea: React.FC = () => {
     type: "node" | "edge";
   } | null>(null);
   const reactFlowInstance = useReactFlow();
-  const { addNode } = useAppStore();
+  const { addNode, describeImageNode } = useAppStore();
 
   // Memoize addNode to prevent unnecessary re-renders
   const memoizedAddNode = useCallback(
@@ -64,7 +65,9 @@ const CanvasArea: React.FC = () => {
       // and then adding the appropriate node (TextNode or ImageNode) to the canvas
       paths.forEach(async (filePath) => {
         try {
-          const result: any = await AppBackend.ImportFile(filePath);
+          const result = (await AppBackend.ImportFile(
+            filePath,
+          )) as unknown as ImportFileResult;
 
           // Convert screen coordinates to flow coordinates
           const flowPosition = reactFlowInstance.screenToFlowPosition({
@@ -72,40 +75,76 @@ const CanvasArea: React.FC = () => {
             y,
           });
 
-          if (result.type === "text") {
-            // Add a new text node
-            const newNode: AppNode = {
-              id: `node-${Date.now()}-${Math.random().toString(36).substr(2, 9)}`,
-              type: "customNode",
-              position: flowPosition,
-              data: {
-                content: result.content,
-                summary:
-                  result.content.substring(0, 100) +
-                  (result.content.length > 100 ? "..." : ""),
-              },
-              width: 250,
-              height: 150,
+          // Items of one file are placed left to right from the drop position
+          const nodeIds: string[] = [];
+          let offsetX = 0;
+          result.items.forEach((item) => {
+            const id = `node-${Date.now()}-${Math.random().toString(36).substr(2, 9)}`;
+            const position = {
+              x: flowPosition.x + offsetX,
+              y: flowPosition.y,
             };
-            memoizedAddNode(newNode);
-          } else if (result.type === "image") {
-            // Add a new image node
-            const newNode: AppNode = {
-              id: `node-${Date.now()}-${Math.random().toString(36).substr(2, 9)}`,
-              type: "imageNode",
-              position: flowPosition,
-              data: {
-                src: result.content, // This should be the relative path
-                alt: `Imported image from ${filePath}`,
-              },
-              width: 300,
-              height: 200,
-            };
-            memoizedAddNode(newNode);
+            if (item.type === "text") {
+              // Add a new text node
+              const preview =
+                item.content.substring(0, 100) +
+                (item.content.length > 100 ? "..." : "");
+              const newNode: AppNode = {
+                id,
+                type: "customNode",
+                position,
+                data: {
+                  content: item.content,
+                  summary: item.title ? `${item.title}: ${preview}` : preview,
+                },
+                width: 250,
+                height: 150,
+              };
+              memoizedAddNode(newNode);
+              offsetX += 300;
+            } else if (item.type === "image") {
+              // Add a new image node
+              const newNode: AppNode = {
+                id,
+                type: "imageNode",
+                position,
+                data: {
+                  src: item.content, // This should be the relative path
+                  alt: item.title || `Imported image from ${filePath}`,
+                },
+                width: 300,
+                height: 200,
+              };
+              memoizedAddNode(newNode);
+              describeImageNode(id);
+              offsetX += 350;
+            } else {
+              return;
+            }
+            nodeIds.push(id);
+          });
+
+          // Parts of one document are connected in reading order
+          if (result.chain) {
+            for (let i = 1; i < nodeIds.length; i++) {
+              onConnect({
+                source: nodeIds[i - 1],
+                target: nodeIds[i],
+                sourceHandle: "right-source",
+                targetHandle: "left-target",
+              });
+            }
+          }
+
+          if (result.warnings && result.warnings.length > 0) {
+            alert(
+              `Some parts of ${filePath} were not imported:\n` +
+                result.warnings.join("\n"),
+            );
           }
         } catch (error) {
           console.error("Failed to import file:", filePath, error);
-          alert(`Failed to import file: ${filePath}`);
+          alert(`Failed to import file: ${filePath}\n${error}`);
         }
       });
     };
@@ -118,7 +157,38 @@ const CanvasArea: React.FC = () => {
     return () => {
       OnFileDropOff();
     };
-  }, [memoizedAddNode, reactFlowInstance]);
+  }, [memoizedAddNode, describeImageNode, onConnect, reactFlowInstance]);
+
+  // Images dragged from the image library become image nodes
+  const onDragOver = useCallback((event: React.DragEvent) => {
+    if (event.dataTransfer.types.includes(IMAGE_LIBRARY_DRAG_TYPE)) {
+      event.preventDefault();
+      event.dataTransfer.dropEffect = "copy";
+    }
+  }, []);
+
+  const onDrop = useCallback(
+    (event: React.DragEvent) => {
+      const payload = event.dataTransfer.getData(IMAGE_LIBRARY_DRAG_TYPE);
+      if (!payload) return;
+      event.preventDefault();
+
+      const { src, alt } = JSON.parse(payload);
+      const newNode: AppNode = {
+        id: `node-${Date.now()}-${Math.random().toString(36).substr(2, 9)}`,
+        type: "imageNode",
+        position: reactFlowInstance.screenToFlowPosition({
+          x: event.clientX,
+          y: event.clientY,
+        }),
+        data: { src, alt },
+        width: 300,
+        height: 200,
+      };
+      memoizedAddNode(newNode);
+    },
+    [memoizedAddNode, reactFlowInstance],
+  );
 
   const onNodeClick = useCallback(
     (_: React.MouseEvent, node: AppNode) => {
@@ -241,6 +311,8 @@ const CanvasArea: React.FC = () => {
         onPaneClick={onPaneClick}
         onNodeContextMenu={onNodeContextMenu}
         onEdgeContextMenu={onEdgeContextMenu}
+        onDragOver={onDragOver}
+        onDrop={onDrop}
         nodeTypes={nodeTypes}
         fitView
         // Enable file drop target
@@ -1,12 +1,27 @@
 import React, { memo, useEffect, useState } from "react";
-import { Handle, Position, NodeProps, NodeResizer } from "@xyflow/react";
-import { ImageNodeData } from "../../types";
+import {
+  Handle,
+  Position,
+  NodeProps,
+  NodeResizer,
+  NodeToolbar,
+  useReactFlow,
+} from "@xyflow/react";
+import {
+  RotateCw,
+  FlipHorizontal,
+  FlipVertical,
+  Minimize2,
+  FileImage,
+} from "lucide-react";
+import { AppNode, ImageNodeData, ImageOperation } from "../../types";
 import { useAppStore } from "../../store/useAppStore";
 import * as AppBackend from "../../../wailsjs/go/main/App";
 import ImageOverlay from "./ImageOverlay/ImageOverlay";
 
 const ImageNode = ({ id, data, selected, width, height }: NodeProps<any>) => {
-  const { updateNodeDimensions } = useAppStore();
+  const { updateNodeDimensions, addNode } = useAppStore();
+  const reactFlowInstance = useReactFlow();
   const [imageSrc, setImageSrc] = useState<string | null>(null);
   const [loading, setLoading] = useState<boolean>(true);
   const [error, setError] = useState<string | null>(null);
@@ -18,12 +33,13 @@ const ImageNode = ({ id, data, selected, width, height }: NodeProps<any>) => {
     }
   }, [selected, isOverlayOpen]);
 
-  const getImageDataURL = async (src: string) => {
+  // AssetServer 経由の URL で表示する（Data URL を IPC で受け渡さない）
+  const getImageAssetURL = async (src: string) => {
     try {
-      const dataURL = await AppBackend.GetImageDataURL(src);
-      return dataURL;
+      const assetURL = await AppBackend.GetImageAssetURL(src);
+      return assetURL;
     } catch (error) {
-      console.error("Failed to get image data URL:", error);
+      console.error("Failed to get image asset URL:", error);
       throw error;
     }
   };
@@ -39,8 +55,8 @@ const ImageNode = ({ id, data, selected, width, height }: NodeProps<any>) => {
       try {
         setLoading(true);
         setError(null);
-        const dataURL = await getImageDataURL((data as ImageNodeData).src);
-        setImageSrc(dataURL);
+        const assetURL = await getImageAssetURL((data as ImageNodeData).src);
+        setImageSrc(assetURL);
       } catch (err: any) {
         console.error("Failed to load image:", err);
         setError(`Failed to load image: ${err.message || "Unknown error"}`);
@@ -52,8 +68,82 @@ const ImageNode = ({ id, data, selected, width, height }: NodeProps<any>) => {
     fetchImage();
   }, [(data as ImageNodeData)?.src]);
 
+  // 元画像は変更せず、変換結果を新しい画像ノードとして右隣に追加する
+  const handleTransform = async (ops: ImageOperation[]) => {
+    const src = (data as ImageNodeData).src;
+    try {
+      const newSrc = await AppBackend.TransformImage(src, ops);
+      const node = reactFlowInstance.getNode(id);
+      const newNode: AppNode = {
+        id: `node-${Date.now()}-${Math.random().toString(36).substr(2, 9)}`,
+        type: "imageNode",
+        position: {
+          x: (node?.position.x ?? 0) + (width || 200) + 40,
+          y: node?.position.y ?? 0,
+        },
+        data: {
+          src: newSrc,
+          alt: (data as ImageNodeData).alt,
+          description: (data as ImageNodeData).description,
+        },
+        width: width || 300,
+        height: height || 200,
+      };
+      addNode(newNode);
+    } catch (err: any) {
+      console.error("Failed to transform image:", err);
+      alert(`Failed to transform image: ${err.message || err}`);
+    }
+  };
+
+  const transformActions: {
+    title: string;
+    icon: React.ReactNode;
+    ops: ImageOperation[];
+  }[] = [
+    {
+      title: "Rotate 90°",
+      icon: <RotateCw size={14} />,
+      ops: [{ type: "rotate", angle: 90 }],
+    },
+    {
+      title: "Flip horizontally",
+      icon: <FlipHorizontal size={14} />,
+      ops: [{ type: "flip", direction: "horizontal" }],
+    },
+    {
+      title: "Flip vertically",
+      icon: <FlipVertical size={14} />,
+      ops: [{ type: "flip", direction: "vertical" }],
+    },
+    {
+      title: "Resize to 50%",
+      icon: <Minimize2 size={14} />,
+      ops: [{ type: "resize", scale: 0.5 }],
+    },
+    {
+      title: "Convert to JPEG",
+      icon: <FileImage size={14} />,
+      ops: [{ type: "convert", format: "jpeg", quality: 90 }],
+    },
+  ];
+
   return (
     <>
+      <NodeToolbar isVisible={selected && !!imageSrc} position={Position.Top}>
+        <div className="flex gap-1 bg-white border border-gray-200 rounded-md shadow-sm p-1">
+          {transformActions.map((action) => (
+            <button
+              key={action.title}
+              title={action.title}
+              onClick={() => handleTransform(action.ops)}
+              className="p-1 text-gray-600 hover:bg-gray-100 rounded"
+            >
+              {action.icon}
+            </button>
+          ))}
+        </div>
+      </NodeToolbar>
       <div
         className={`shadow-md rounded-md border-2 transition-colors relative ${
           selected ? "border-blue-500 ring-2 ring-blue-200" : "border-gray-200"
@@ -0,0 +1,187 @@
+import React, { useEffect, useState } from "react";
+import { RefreshCw } from "lucide-react";
+import * as AppBackend from "../../../wailsjs/go/main/App";
+import { useAppStore } from "../../store/useAppStore";
+import { CanvasDiff, CanvasVersion, NodeChange } from "../../types";
+
+// Shortens node text for the diff list
+const excerpt = (text?: string) => {
+  if (!text) return '""';
+  const line = text.replace(/\s+/g, " ").trim();
+  return line.length > 40 ? `"${line.slice(0, 40)}..."` : `"${line}"`;
+};
+
+const describeChange = (change: NodeChange) => {
+  if (change.from && change.to) {
+    return `${change.nodeId} moved (${Math.round(change.from.x)}, ${Math.round(change.from.y)}) → (${Math.round(change.to.x)}, ${Math.round(change.to.y)})`;
+  }
+  if (change.field) {
+    return `${change.nodeId} ${change.field}: ${excerpt(change.oldValue)} → ${excerpt(change.newValue)}`;
+  }
+  return `${change.nodeId} (${change.type === "imageNode" ? "image" : "text"})`;
+};
+
+// Lists the saved versions of the current canvas file, with a diff against the saved file and restore
+const CanvasHistory: React.FC = () => {
+  const { currentFilePath, isSettingsOpen, restoreCanvasVersion } =
+    useAppStore();
+  const [versions, setVersions] = useState<CanvasVersion[]>([]);
+  const [diff, setDiff] = useState<{ id: string; diff: CanvasDiff } | null>(
+    null,
+  );
+
+  const refresh = async () => {
+    setDiff(null);
+    if (!currentFilePath) {
+      setVersions([]);
+      return;
+    }
+    try {
+      const list = (await AppBackend.ListCanvasVersions(
+        currentFilePath,
+      )) as CanvasVersion[];
+      setVersions(list || []);
+    } catch (error) {
+      console.error("Failed to list canvas versions:", error);
+    }
+  };
+
+  // Reload when the drawer is opened, since every save adds a version
+  useEffect(() => {
+    if (isSettingsOpen) refresh();
+  }, [currentFilePath, isSettingsOpen]);
+
+  const handleDiff = async (id: string) => {
+    if (diff?.id === id) {
+      setDiff(null);
+      return;
+    }
+    try {
+      const result = (await AppBackend.DiffCanvasVersions(
+        currentFilePath,
+        id,
+        "",
+      )) as unknown as CanvasDiff;
+      setDiff({ id, diff: result });
+    } catch (error) {
+      console.error("Failed to compare canvas versions:", error);
+      alert(`Failed to compare versions: ${error}`);
+    }
+  };
+
+  const handleRestore = async (version: CanvasVersion) => {
+    const savedAt = new Date(version.savedAt).toLocaleString();
+    if (
+      !confirm(
+        `Restore the version saved at ${savedAt}? Unsaved changes on the canvas will be lost.`,
+      )
+    ) {
+      return;
+    }
+    try {
+      await restoreCanvasVersion(version.id);
+      await refresh();
+    } catch (error) {
+      alert(`Failed to restore version: ${error}`);
+    }
+  };
+
+  if (!currentFilePath) {
+    return (
+      <p className="text-xs text-gray-500">
+        Save the canvas to a file to keep a history of its versions.
+      </p>
+    );
+  }
+
+  const renderDiff = (d: CanvasDiff) => {
+    const sections: [string, string[]][] = [
+      ["Added", d.added.map(describeChange)],
+      ["Removed", d.removed.map(describeChange)],
+      ["Moved", d.moved.map(describeChange)],
+      ["Changed", d.changed.map(describeChange)],
+      [
+        "Edges",
+        [
+          ...d.edgesAdded.map((e) => `+ ${e.source} → ${e.target}`),
+          ...d.edgesRemoved.map((e) => `- ${e.source} → ${e.target}`),
+        ],
+      ],
+    ];
+    const nonEmpty = sections.filter(([, lines]) => lines.length > 0);
+    if (nonEmpty.length === 0) {
+      return (
+        <p className="text-xs text-gray-500">Same as the saved file.</p>
+      );
+    }
+    return (
+      <div className="space-y-1">
+        {nonEmpty.map(([title, lines]) => (
+          <div key={title}>
+            <p className="text-xs font-medium text-gray-600">{title}</p>
+            <ul className="text-[11px] text-gray-600 break-all">
+              {lines.map((line, i) => (
+                <li key={i}>{line}</li>
+              ))}
+            </ul>
+          </div>
+        ))}
+      </div>
+    );
+  };
+
+  return (
+    <div className="space-y-2">
+      <div className="flex items-center justify-between">
+        <p className="text-xs text-gray-500">
+          {versions.length} versions. Diff compares with the saved file.
+        </p>
+        <button
+          onClick={refresh}
+          className="p-1 text-gray-500 hover:bg-gray-100 rounded"
+          title="Refresh"
+        >
+          <RefreshCw size={14} />
+        </button>
+      </div>
+      <ul className="space-y-1">
+        {versions.map((version) => (
+          <li
+            key={version.id}
+            className="bg-white border border-gray-200 rounded p-2 text-xs"
+          >
+            <div className="flex items-center justify-between gap-2">
+              <span className="truncate">
+                {new Date(version.savedAt).toLocaleString()}
+                <span className="text-gray-400 ml-1">
+                  {version.nodeCount} nodes, {version.edgeCount} edges
+                </span>
+              </span>
+              <div className="flex gap-1 shrink-0">
+                <button
+                  onClick={() => handleDiff(version.id)}
+                  className="px-2 py-0.5 border border-gray-200 rounded hover:bg-gray-50"
+                >
+                  Diff
+                </button>
+                <button
+                  onClick={() => handleRestore(version)}
+                  className="px-2 py-0.5 border border-gray-200 rounded hover:bg-gray-50"
+                >
+                  Restore
+                </button>
+              </div>
+            </div>
+            {diff?.id === version.id && (
+              <div className="mt-2 border-t border-gray-100 pt-2">
+                {renderDiff(diff.diff)}
+              </div>
+            )}
+          </li>
+        ))}
+      </ul>
+    </div>
+  );
+};
+
+export default CanvasHistory;
@@ -0,0 +1,176 @@
+import React, { useEffect, useState } from "react";
+import { Search } from "lucide-react";
+import * as AppBackend from "../../../wailsjs/go/main/App";
+import { ImageInfo, ImageListFilter, ImageListResult } from "../../types";
+
+// ドラッグでキャンバスに画像ノードを追加する際のデータ形式
+export const IMAGE_LIBRARY_DRAG_TYPE = "application/x-fm-image";
+
+const PAGE_SIZE = 24;
+
+// Thumbnail of a library image, loaded lazily from the backend cache
+const LibraryThumbnail: React.FC<{ image: ImageInfo }> = ({ image }) => {
+  const [dataURL, setDataURL] = useState<string | null>(null);
+
+  useEffect(() => {
+    let cancelled = false;
+    AppBackend.GetImageThumbnail(image.path, 128)
+      .then((url) => {
+        if (!cancelled) setDataURL(url);
+      })
+      .catch((error) => {
+        console.error("Failed to load thumbnail:", image.path, error);
+      });
+    return () => {
+      cancelled = true;
+    };
+  }, [image.path]);
+
+  const handleDragStart = (event: React.DragEvent) => {
+    event.dataTransfer.setData(
+      IMAGE_LIBRARY_DRAG_TYPE,
+      JSON.stringify({ src: image.path, alt: image.prompt || image.path }),
+    );
+    event.dataTransfer.effectAllowed = "copy";
+  };
+
+  const title = [
+    image.path,
+    `${image.width}x${image.height} ${image.format}`,
+    new Date(image.createdAt).toLocaleString(),
+    image.provider,
+    image.prompt,
+    image.parent && `Edited from ${image.parent}`,
+  ]
+    .filter(Boolean)
+    .join("\n");
+
+  return (
+    <div
+      draggable
+      onDragStart={handleDragStart}
+      title={title}
+      className="aspect-square bg-white border border-gray-200 rounded overflow-hidden cursor-grab flex items-center justify-center"
+    >
+      {dataURL ? (
+        <img
+          src={dataURL}
+          alt={image.prompt || image.path}
+          className="max-w-full max-h-full object-contain pointer-events-none"
+        />
+      ) : (
+        <span className="text-[10px] text-gray-400">...</span>
+      )}
+    </div>
+  );
+};
+
+const ImageLibrary: React.FC = () => {
+  const [filter, setFilter] = useState<ImageListFilter>({
+    since: "",
+    until: "",
+    provider: "",
+    query: "",
+  });
+  const [result, setResult] = useState<ImageListResult | null>(null);
+
+  const loadPage = async (page: number) => {
+    try {
+      const list = (await AppBackend.ListImages(filter, {
+        page,
+        pageSize: PAGE_SIZE,
+      })) as ImageListResult;
+      setResult(list);
+    } catch (error) {
+      console.error("Failed to list images:", error);
+      alert(`Failed to list images: ${error}`);
+    }
+  };
+
+  const pageCount = result
+    ? Math.max(1, Math.ceil(result.total / result.pageSize))
+    : 1;
+
+  return (
+    <div className="space-y-2">
+      <div className="flex gap-2">
+        <input
+          type="text"
+          value={filter.query}
+          onChange={(e) => setFilter({ ...filter, query: e.target.value })}
+          onKeyDown={(e) => {
+            if (e.key === "Enter") loadPage(1);
+          }}
+          className="flex-1 p-2 border border-gray-300 rounded-md text-sm"
+          placeholder="Search prompt or file name"
+        />
+        <button
+          onClick={() => loadPage(1)}
+          className="p-2 bg-white border border-gray-200 rounded-lg hover:bg-gray-50 transition-colors"
+          title="Search"
+        >
+          <Search size={16} />
+        </button>
+      </div>
+      <div className="grid grid-cols-3 gap-2">
+        <input
+          type="date"
+          value={filter.since}
+          onChange={(e) => setFilter({ ...filter, since: e.target.value })}
+          className="p-1 border border-gray-300 rounded-md text-xs"
+          title="Since"
+        />
+        <input
+          type="date"
+          value={filter.until}
+          onChange={(e) => setFilter({ ...filter, until: e.target.value })}
+          className="p-1 border border-gray-300 rounded-md text-xs"
+          title="Until"
+        />
+        <input
+          type="text"
+          value={filter.provider}
+          onChange={(e) => setFilter({ ...filter, provider: e.target.value })}
+          className="p-1 border border-gray-300 rounded-md text-xs"
+          placeholder="Provider"
+        />
+      </div>
+
+      {result && (
+        <>
+          <p className="text-xs text-gray-500">
+            {result.total} images. Drag an image onto the canvas to add it.
+          </p>
+          <div className="grid grid-cols-4 gap-2">
+            {result.items.map((image) => (
+              <LibraryThumbnail key={image.path} image={image} />
+            ))}
+          </div>
+          {pageCount > 1 && (
+            <div className="flex items-center justify-between text-xs text-gray-600">
+              <button
+                onClick={() => loadPage(result.page - 1)}
+                disabled={result.page <= 1}
+                className="px-2 py-1 border border-gray-200 rounded disabled:opacity-40"
+              >
+                Prev
+              </button>
+              <span>
+                {result.page} / {pageCount}
+              </span>
+              <button
+                onClick={() => loadPage(result.page + 1)}
+                disabled={result.page >= pageCount}
+                className="px-2 py-1 border border-gray-200 rounded disabled:opacity-40"
+              >
+                Next
+              </button>
+            </div>
+          )}
+        </>
+      )}
+    </div>
+  );
+};
+
+export default ImageLibrary;
@@ -0,0 +1,170 @@
+import React, { useEffect, useState } from "react";
+import * as AppBackend from "../../../wailsjs/go/main/App";
+import {
+  AppConfig,
+  ImageProviderInfo,
+  ProviderConfigField,
+  ProviderSettings,
+} from "../../types";
+
+type ImageGenConfig = AppConfig["imageGen"];
+
+interface ImageProviderSettingsProps {
+  imageGen: ImageGenConfig;
+  onChange: (imageGen: ImageGenConfig) => void;
+}
+
+const inputClassName =
+  "w-full p-2 text-sm border border-gray-200 rounded bg-white focus:outline-none focus:ring-1 focus:ring-blue-300";
+
+// スキーマの既定値からプロバイダの設定を作る
+const defaultSettings = (info: ImageProviderInfo): ProviderSettings => {
+  const settings: ProviderSettings = {};
+  info.fields.forEach((field) => {
+    if (field.default !== undefined && field.default !== null) {
+      settings[field.key] = field.default;
+    }
+  });
+  return settings;
+};
+
+// Provider selection and settings, rendered from the schema registered in the backend
+const ImageProviderSettings: React.FC<ImageProviderSettingsProps> = ({
+  imageGen,
+  onChange,
+}) => {
+  const [providers, setProviders] = useState<ImageProviderInfo[]>([]);
+
+  useEffect(() => {
+    AppBackend.GetImageProviders()
+      .then((infos) =>
+        setProviders((infos || []) as unknown as ImageProviderInfo[]),
+      )
+      .catch((error) =>
+        console.error("Failed to load image providers:", error),
+      );
+  }, []);
+
+  const current = providers.find((p) => p.name === imageGen.provider);
+  const settings: ProviderSettings =
+    (imageGen.providers || {})[imageGen.provider] ||
+    (current ? defaultSettings(current) : {});
+
+  const handleProviderChange = (name: string) => {
+    const info = providers.find((p) => p.name === name);
+    const stored = (imageGen.providers || {})[name];
+    onChange({
+      ...imageGen,
+      provider: name,
+      providers: {
+        ...imageGen.providers,
+        [name]: stored || (info ? defaultSettings(info) : {}),
+      },
+    });
+  };
+
+  const handleFieldChange = (key: string, value: unknown) => {
+    onChange({
+      ...imageGen,
+      providers: {
+        ...imageGen.providers,
+        [imageGen.provider]: { ...settings, [key]: value },
+      },
+    });
+  };
+
+  const renderField = (field: ProviderConfigField) => {
+    const value = settings[field.key];
+    const placeholder =
+      field.default !== undefined && field.default !== null
+        ? String(field.default)
+        : "";
+
+    switch (field.type) {
+      case "boolean":
+        return (
+          <label className="flex items-center gap-2 text-xs font-medium text-gray-500">
+            <input
+              type="checkbox"
+              checked={Boolean(value)}
+              onChange={(e) => handleFieldChange(field.key, e.target.checked)}
+            />
+            {field.label}
+          </label>
+        );
+      case "number":
+        return (
+          <input
+            type="number"
+            value={typeof value === "number" ? value : ""}
+            onChange={(e) =>
+              handleFieldChange(
+                field.key,
+                e.target.value === "" ? 0 : Number(e.target.value),
+              )
+            }
+            placeholder={placeholder}
+            className={inputClassName}
+          />
+        );
+      case "list":
+        return (
+          <textarea
+            rows={3}
+            value={(Array.isArray(value) ? value : []).join("\n")}
+            onChange={(e) =>
+              handleFieldChange(field.key, e.target.value.split("\n"))
+            }
+            placeholder="One item per line"
+            className={inputClassName}
+          />
+        );
+      default:
+        return (
+          <input
+            type={field.type === "secret" ? "password" : "text"}
+            value={typeof value === "string" ? value : ""}
+            onChange={(e) => handleFieldChange(field.key, e.target.value)}
+            placeholder={placeholder}
+            className={inputClassName}
+          />
+        );
+    }
+  };
+
+  return (
+    <>
+      <div>
+        <label className="block text-xs font-medium text-gray-500 mb-1">
+          Provider
+        </label>
+        <select
+          value={imageGen.provider}
+          onChange={(e) => handleProviderChange(e.target.value)}
+          className={inputClassName}
+        >
+          {!current && (
+            <option value={imageGen.provider}>{imageGen.provider}</option>
+          )}
+          {providers.map((p) => (
+            <option key={p.name} value={p.name}>
+              {p.displayName}
+            </option>
+          ))}
+        </select>
+      </div>
+      {current?.fields.map((field) => (
+        <div key={field.key}>
+          {field.type !== "boolean" && (
+            <label className="block text-xs font-medium text-gray-500 mb-1">
+              {field.label}
+            </label>
+          )}
+          {renderField(field)}
+        </div>
+      ))}
+    </>
+  );
+};
+
+export default ImageProviderSettings;
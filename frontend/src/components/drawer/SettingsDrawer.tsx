@@ -1,24 +1,60 @@
 import React, { useState, useCallback, useEffect } from "react";
-import { X, Trash2, Save, FolderOpen, Download, Upload } from "lucide-react";
+import {
+  X,
+  Trash2,
+  Save,
+  FolderOpen,
+  Download,
+  Upload,
+  Image as ImageIcon,
+  Package,
+  PackageOpen,
+  History,
+  FileInput,
+  FolderInput,
+} from "lucide-react";
 import { useAppStore } from "../../store/useAppStore";
-import { OpenAIConfig, GoogleConfig, XAIConfig } from "../../types";
+import * as AppBackend from "../../../wailsjs/go/main/App";
+import ImageLibrary from "./ImageLibrary";
+import CanvasHistory from "./CanvasHistory";
+import ImageProviderSettings from "./ImageProviderSettings";
+import {
+  AssetReport,
+  AssetFileInfo,
+  TrashBatch,
+} from "../../types";
+
+const formatBytes = (bytes: number): string => {
+  if (bytes < 1024) return `${bytes} B`;
+  if (bytes < 1024 * 1024) return `${(bytes / 1024).toFixed(1)} KB`;
+  return `${(bytes / (1024 * 1024)).toFixed(1)} MB`;
+};
 
 const SettingsDrawer: React.FC = () => {
   const {
+    nodes,
     config,
     setConfig,
     saveConfig,
     loadConfig,
     saveCanvas,
+    saveCanvasAs,
     loadCanvas,
-    setNodes,
-    setEdges,
-    setActiveNode,
+    openCanvasPath,
+    newCanvas,
+    exportBundle,
+    importBundle,
+    importMarkdownFolder,
     setSettingsOpen,
     isSettingsOpen,
   } = useAppStore();
 
   const [localConfig, setLocalConfig] = useState(config);
+  const [assetReport, setAssetReport] = useState<AssetReport | null>(null);
+  const [orphans, setOrphans] = useState<AssetFileInfo[]>([]);
+  const [trashBatches, setTrashBatches] = useState<TrashBatch[]>([]);
+  const [includeRecent, setIncludeRecent] = useState(true);
+  const [recentCanvases, setRecentCanvases] = useState<string[]>([]);
 
   // Load config when component mounts
   useEffect(() => {
@@ -50,6 +86,26 @@ const SettingsDrawer: React.FC = () => {
     }
   };
 
+  const handleSaveCanvasAs = async () => {
+    try {
+      await saveCanvasAs();
+      setSettingsOpen(false);
+    } catch (error) {
+      console.error("Failed to save canvas:", error);
+      alert("Failed to save canvas");
+    }
+  };
+
+  const handleOpenRecent = async (path: string) => {
+    try {
+      await openCanvasPath(path);
+      setSettingsOpen(false);
+    } catch (error) {
+      console.error("Failed to open canvas:", error);
+      alert(`Failed to open canvas: ${error}`);
+    }
+  };
+
   const handleLoadCanvas = async () => {
     try {
       await loadCanvas();
@@ -60,11 +116,128 @@ const SettingsDrawer: React.FC = () => {
     }
   };
 
-  const handleClearCanvas = () => {
+  const refreshTrash = async () => {
+    try {
+      setTrashBatches((await AppBackend.ListTrash()) || []);
+    } catch (error) {
+      console.error("Failed to list trash:", error);
+    }
+  };
+
+  // Refresh the trash and recent lists whenever the drawer is opened
+  useEffect(() => {
+    if (isSettingsOpen) {
+      refreshTrash();
+      AppBackend.GetRecentCanvases()
+        .then((paths) => setRecentCanvases(paths || []))
+        .catch((error) =>
+          console.error("Failed to get recent canvases:", error),
+        );
+    }
+  }, [isSettingsOpen]);
+
+  const handleScanAssets = async () => {
+    try {
+      // With recent canvases included, selecting more files is optional
+      const canvasPaths = (await AppBackend.SelectCanvasFiles()) || [];
+      if (canvasPaths.length === 0 && !includeRecent) {
+        return; // User cancelled
+      }
+      const report = (await AppBackend.ScanAssets(
+        canvasPaths,
+        includeRecent,
+      )) as AssetReport;
+      // Images on the current (possibly unsaved) canvas are never orphans
+      const inUse = new Set(
+        nodes
+          .filter((node) => node.type === "imageNode")
+          .map((node) => String(node.data.src)),
+      );
+      setAssetReport(report);
+      setOrphans(report.orphaned.filter((file) => !inUse.has(file.path)));
+      await refreshTrash();
+    } catch (error) {
+      console.error("Failed to scan assets:", error);
+      alert(`Failed to scan assets: ${error}`);
+    }
+  };
+
+  const handleCleanupOrphans = async () => {
+    if (orphans.length === 0) return;
+    const size = orphans.reduce((sum, file) => sum + file.size, 0);
+    if (
+      !confirm(
+        `Move ${orphans.length} orphaned images (${formatBytes(size)}) to the trash?`,
+      )
+    ) {
+      return;
+    }
+    try {
+      await AppBackend.CleanupOrphans(orphans.map((file) => file.path));
+      setAssetReport(null);
+      setOrphans([]);
+      await refreshTrash();
+    } catch (error) {
+      console.error("Failed to clean up orphans:", error);
+      alert(`Failed to clean up orphans: ${error}`);
+    }
+  };
+
+  const handleRestoreTrash = async (batchID: string) => {
+    try {
+      await AppBackend.RestoreTrash(batchID);
+    } catch (error) {
+      console.error("Failed to restore trash:", error);
+      alert(`Failed to restore trash: ${error}`);
+    }
+    await refreshTrash();
+  };
+
+  const handlePurgeTrash = async (batchID: string) => {
+    if (!confirm("Permanently delete these images?")) return;
+    try {
+      await AppBackend.PurgeTrash(batchID);
+    } catch (error) {
+      console.error("Failed to purge trash:", error);
+      alert(`Failed to purge trash: ${error}`);
+    }
+    await refreshTrash();
+  };
+
+  const handleExportBundle = async () => {
+    try {
+      await exportBundle();
+      setSettingsOpen(false);
+    } catch (error) {
+      console.error("Failed to export bundle:", error);
+      alert(`Failed to export bundle: ${error}`);
+    }
+  };
+
+  const handleImportBundle = async () => {
+    try {
+      await importBundle();
+      setSettingsOpen(false);
+    } catch (error) {
+      console.error("Failed to import bundle:", error);
+      alert(`Failed to import bundle: ${error}`);
+    }
+  };
+
+  const handleImportMarkdownFolder = async () => {
+    try {
+      await importMarkdownFolder();
+      setSettingsOpen(false);
+    } catch (error) {
+      console.error("Failed to import Markdown folder:", error);
+      alert(`Failed to import Markdown folder: ${error}`);
+    }
+  };
+
+  const handleClearCanvas = async () => {
     // Clear canvas without confirmation as per specification
-    setNodes([]);
-    setEdges([]);
-    setActiveNode(null);
+    // The cleared canvas is a new canvas, so the next save asks for a path
+    await newCanvas();
     setSettingsOpen(false);
   };
 
@@ -122,6 +295,13 @@ const SettingsDrawer: React.FC = () => {
                 <Download size={20} className="text-blue-500 mb-1" />
                 <span className="text-xs font-medium">Save Canvas</span>
               </button>
+              <button
+                onClick={handleSaveCanvasAs}
+                className="flex flex-col items-center justify-center p-3 bg-white border border-gray-200 rounded-lg hover:bg-gray-50 transition-colors"
+              >
+                <Save size={20} className="text-blue-500 mb-1" />
+                <span className="text-xs font-medium">Save Canvas As</span>
+              </button>
               <button
                 onClick={handleLoadCanvas}
                 className="flex flex-col items-center justify-center p-3 bg-white border border-gray-200 rounded-lg hover:bg-gray-50 transition-colors"
@@ -129,7 +309,59 @@ const SettingsDrawer: React.FC = () => {
                 <Upload size={20} className="text-green-500 mb-1" />
                 <span className="text-xs font-medium">Load Canvas</span>
               </button>
+              <button
+                onClick={handleExportBundle}
+                className="flex flex-col items-center justify-center p-3 bg-white border border-gray-200 rounded-lg hover:bg-gray-50 transition-colors"
+              >
+                <Package size={20} className="text-purple-500 mb-1" />
+                <span className="text-xs font-medium">Export Bundle</span>
+              </button>
+              <button
+                onClick={handleImportBundle}
+                className="flex flex-col items-center justify-center p-3 bg-white border border-gray-200 rounded-lg hover:bg-gray-50 transition-colors"
+              >
+                <PackageOpen size={20} className="text-orange-500 mb-1" />
+                <span className="text-xs font-medium">Import Bundle</span>
+              </button>
+              <button
+                onClick={handleImportMarkdownFolder}
+                className="flex flex-col items-center justify-center p-3 bg-white border border-gray-200 rounded-lg hover:bg-gray-50 transition-colors"
+              >
+                <FolderInput size={20} className="text-teal-500 mb-1" />
+                <span className="text-xs font-medium">
+                  Import Markdown Folder
+                </span>
+              </button>
             </div>
+            {recentCanvases.length > 0 && (
+              <div className="mt-3">
+                <p className="text-xs font-medium text-gray-600 mb-1">
+                  Recent Canvases
+                </p>
+                <ul className="space-y-1">
+                  {recentCanvases.map((path) => (
+                    <li key={path}>
+                      <button
+                        onClick={() => handleOpenRecent(path)}
+                        className="w-full text-left text-xs text-blue-600 hover:underline truncate"
+                        title={path}
+                      >
+                        {path.split(/[\\/]/).pop()}
+                      </button>
+                    </li>
+                  ))}
+                </ul>
+              </div>
+            )}
+          </div>
+
+          {/* Canvas History */}
+          <div className="bg-gray-50 p-4 rounded-lg">
+            <h3 className="font-bold text-gray-700 mb-3 flex items-center gap-2">
+              <History size={16} />
+              Canvas History
+            </h3>
+            <CanvasHistory />
           </div>
 
           {/* LLM Configuration */}
@@ -245,315 +477,63 @@ const SettingsDrawer: React.FC = () => {
               Image Generation
             </h3>
             <div className="space-y-3">
+              <ImageProviderSettings
+                imageGen={localConfig.imageGen}
+                onChange={(imageGen) =>
+                  setLocalConfig({ ...localConfig, imageGen })
+                }
+              />
+
+              {/* Prompt Enhancement Settings */}
               <div>
-                <label className="block text-xs font-medium text-gray-500 mb-1">
-                  Provider
+                <label className="flex items-center gap-2 text-xs font-medium text-gray-500">
+                  <input
+                    type="checkbox"
+                    checked={
+                      localConfig.imageGen.promptEnhancement?.enabled || false
+                    }
+                    onChange={(e) =>
+                      setLocalConfig({
+                        ...localConfig,
+                        imageGen: {
+                          ...localConfig.imageGen,
+                          promptEnhancement: {
+                            style: "",
+                            ...localConfig.imageGen.promptEnhancement,
+                            enabled: e.target.checked,
+                          },
+                        },
+                      })
+                    }
+                  />
+                  Enhance prompts with LLM
                 </label>
-                <select
-                  value={localConfig.imageGen.provider}
-                  onChange={(e) =>
-                    setLocalConfig({
-                      ...localConfig,
-                      imageGen: {
-                        ...localConfig.imageGen,
-                        provider: e.target.value,
-                        // Reset provider-specific settings when changing provider
-                        openrouter:
-                          e.target.value === "openrouter"
-                            ? localConfig.imageGen.openrouter || {
-                                baseURL: "",
-                                model: "",
-                                apiKey: "",
-                              }
-                            : undefined,
-
-                        openai:
-                          e.target.value === "openai"
-                            ? localConfig.imageGen.openai || {
-                                baseURL: "",
-                                model: "",
-                                apiKey: "",
-                              }
-                            : undefined,
-                        google:
-                          e.target.value === "google"
-                            ? localConfig.imageGen.google || {
-                                model: "",
-                                apiKey: "",
-                              }
-                            : undefined,
-                        xai:
-                          e.target.value === "xai"
-                            ? localConfig.imageGen.xai || {
-                                model: "grok-imagine-image",
-                                apiKey: "",
-                              }
-                            : undefined,
-                      },
-                    })
-                  }
-                >
-                  <option value="openrouter">OpenRouter</option>
-                  <option value="openai">OpenAI</option>
-                  <option value="google">Google</option>
-                  <option value="xai">xAI</option>
-                </select>
               </div>
-              {/* OpenRouter Settings */}
-              {localConfig.imageGen.provider === "openrouter" && (
-                <>
-                  <div>
-                    <label className="block text-xs font-medium text-gray-500 mb-1">
-                      API Base URL
-                    </label>
-                    <input
-                      type="text"
-                      value={localConfig.imageGen.openrouter?.baseURL || ""}
-                      onChange={(e) =>
-                        setLocalConfig({
-                          ...localConfig,
-                          imageGen: {
-                            ...localConfig.imageGen,
-                            openrouter: {
-                              baseURL: e.target.value,
-                              model:
-                                localConfig.imageGen.openrouter?.model || "",
-                              apiKey:
-                                localConfig.imageGen.openrouter?.apiKey || "",
-                            },
-                          },
-                        })
-                      }
-                      className="w-full p-2 text-sm border border-gray-200 rounded bg-white focus:outline-none focus:ring-1 focus:ring-blue-300"
-                    />
-                  </div>
-                  <div>
-                    <label className="block text-xs font-medium text-gray-500 mb-1">
-                      Model
-                    </label>
-                    <input
-                      type="text"
-                      value={localConfig.imageGen.openrouter?.model || ""}
-                      onChange={(e) =>
-                        setLocalConfig({
-                          ...localConfig,
-                          imageGen: {
-                            ...localConfig.imageGen,
-                            openrouter: {
-                              baseURL:
-                                localConfig.imageGen.openrouter?.baseURL || "",
-                              model: e.target.value,
-                              apiKey:
-                                localConfig.imageGen.openrouter?.apiKey || "",
-                            },
-                          },
-                        })
-                      }
-                      className="w-full p-2 text-sm border border-gray-200 rounded bg-white focus:outline-none focus:ring-1 focus:ring-blue-300"
-                    />
-                  </div>
-                  <div>
-                    <label className="block text-xs font-medium text-gray-500 mb-1">
-                      API Key
-                    </label>
-                    <input
-                      type="password"
-                      value={localConfig.imageGen.openrouter?.apiKey || ""}
-                      onChange={(e) =>
-                        setLocalConfig({
-                          ...localConfig,
-                          imageGen: {
-                            ...localConfig.imageGen,
-                            openrouter: {
-                              baseURL:
-                                localConfig.imageGen.openrouter?.baseURL || "",
-                              model:
-                                localConfig.imageGen.openrouter?.model || "",
-                              apiKey: e.target.value,
-                            },
-                          },
-                        })
-                      }
-                      className="w-full p-2 text-sm border border-gray-200 rounded bg-white focus:outline-none focus:ring-1 focus:ring-blue-300"
-                    />
-                  </div>
-                </>
-              )}
-
-              {/* Stability AI Settings */}
-
-              {/* OpenAI Settings */}
-              {localConfig.imageGen.provider === "openai" && (
-                <>
-                  <div>
-                    <label className="block text-xs font-medium text-gray-500 mb-1">
-                      Base URL
-                    </label>
-                    <input
-                      type="text"
-                      value={localConfig.imageGen.openai?.baseURL || ""}
-                      onChange={(e) =>
-                        setLocalConfig({
-                          ...localConfig,
-                          imageGen: {
-                            ...localConfig.imageGen,
-                            openai: {
-                              ...localConfig.imageGen.openai,
-                              baseURL: e.target.value,
-                            } as OpenAIConfig,
-                          },
-                        })
-                      }
-                      className="w-full p-2 text-sm border border-gray-200 rounded bg-white focus:outline-none focus:ring-1 focus:ring-blue-300"
-                    />
-                  </div>
-                  <div>
-                    <label className="block text-xs font-medium text-gray-500 mb-1">
-                      Model
-                    </label>
-                    <input
-                      type="text"
-                      value={localConfig.imageGen.openai?.model || ""}
-                      onChange={(e) =>
-                        setLocalConfig({
-                          ...localConfig,
-                          imageGen: {
-                            ...localConfig.imageGen,
-                            openai: {
-                              ...localConfig.imageGen.openai,
-                              model: e.target.value,
-                            } as OpenAIConfig,
-                          },
-                        })
-                      }
-                      className="w-full p-2 text-sm border border-gray-200 rounded bg-white focus:outline-none focus:ring-1 focus:ring-blue-300"
-                    />
-                  </div>
-                  <div>
-                    <label className="block text-xs font-medium text-gray-500 mb-1">
-                      API Key
-                    </label>
-                    <input
-                      type="password"
-                      value={localConfig.imageGen.openai?.apiKey || ""}
-                      onChange={(e) =>
-                        setLocalConfig({
-                          ...localConfig,
-                          imageGen: {
-                            ...localConfig.imageGen,
-                            openai: {
-                              ...localConfig.imageGen.openai,
-                              apiKey: e.target.value,
-                            } as OpenAIConfig,
-                          },
-                        })
-                      }
-                      className="w-full p-2 text-sm border border-gray-200 rounded bg-white focus:outline-none focus:ring-1 focus:ring-blue-300"
-                    />
-                  </div>
-                </>
-              )}
-
-              {/* Google Settings */}
-              {localConfig.imageGen.provider === "google" && (
-                <>
-                  <div>
-                    <label className="block text-xs font-medium text-gray-500 mb-1">
-                      Model
-                    </label>
-                    <input
-                      type="text"
-                      value={localConfig.imageGen.google?.model || ""}
-                      onChange={(e) =>
-                        setLocalConfig({
-                          ...localConfig,
-                          imageGen: {
-                            ...localConfig.imageGen,
-                            google: {
-                              ...localConfig.imageGen.google,
-                              model: e.target.value,
-                            } as GoogleConfig,
-                          },
-                        })
-                      }
-                      className="w-full p-2 text-sm border border-gray-200 rounded bg-white focus:outline-none focus:ring-1 focus:ring-blue-300"
-                    />
-                  </div>
-                  <div>
-                    <label className="block text-xs font-medium text-gray-500 mb-1">
-                      API Key
-                    </label>
-                    <input
-                      type="password"
-                      value={localConfig.imageGen.google?.apiKey || ""}
-                      onChange={(e) =>
-                        setLocalConfig({
-                          ...localConfig,
-                          imageGen: {
-                            ...localConfig.imageGen,
-                            google: {
-                              ...localConfig.imageGen.google,
-                              apiKey: e.target.value,
-                            } as GoogleConfig,
+              {localConfig.imageGen.promptEnhancement?.enabled && (
+                <div>
+                  <label className="block text-xs font-medium text-gray-500 mb-1">
+                    Prompt Style
+                  </label>
+                  <input
+                    type="text"
+                    value={localConfig.imageGen.promptEnhancement?.style || ""}
+                    onChange={(e) =>
+                      setLocalConfig({
+                        ...localConfig,
+                        imageGen: {
+                          ...localConfig.imageGen,
+                          promptEnhancement: {
+                            enabled: true,
+                            ...localConfig.imageGen.promptEnhancement,
+                            style: e.target.value,
                           },
-                        })
-                      }
-                      className="w-full p-2 text-sm border border-gray-200 rounded bg-white focus:outline-none focus:ring-1 focus:ring-blue-300"
-                    />
-                  </div>
-                </>
-              )}
-
-              {/* xAI Settings */}
-              {localConfig.imageGen.provider === "xai" && (
-                <>
-                  <div>
-                    <label className="block text-xs font-medium text-gray-500 mb-1">
-                      Model
-                    </label>
-                    <input
-                      type="text"
-                      value={localConfig.imageGen.xai?.model || ""}
-                      onChange={(e) =>
-                        setLocalConfig({
-                          ...localConfig,
-                          imageGen: {
-                            ...localConfig.imageGen,
-                            xai: {
-                              ...localConfig.imageGen.xai,
-                              model: e.target.value,
-                            } as XAIConfig,
-                          },
-                        })
-                      }
-                      placeholder="grok-imagine-image"
-                      className="w-full p-2 text-sm border border-gray-200 rounded bg-white focus:outline-none focus:ring-1 focus:ring-blue-300"
-                    />
-                  </div>
-                  <div>
-                    <label className="block text-xs font-medium text-gray-500 mb-1">
-                      API Key
-                    </label>
-                    <input
-                      type="password"
-                      value={localConfig.imageGen.xai?.apiKey || ""}
-                      onChange={(e) =>
-                        setLocalConfig({
-                          ...localConfig,
-                          imageGen: {
-                            ...localConfig.imageGen,
-                            xai: {
-                              ...localConfig.imageGen.xai,
-                              apiKey: e.target.value,
-                            } as XAIConfig,
-                          },
-                        })
-                      }
-                      placeholder="xai-..."
-                      className="w-full p-2 text-sm border border-gray-200 rounded bg-white focus:outline-none focus:ring-1 focus:ring-blue-300"
-                    />
-                  </div>
-                </>
+                        },
+                      })
+                    }
+                    placeholder="(optional) e.g. flat illustration"
+                    className="w-full p-2 text-sm border border-gray-200 rounded bg-white focus:outline-none focus:ring-1 focus:ring-blue-300"
+                  />
+                </div>
               )}
 
               {/* Local Settings */}
@@ -618,6 +598,176 @@ const SettingsDrawer: React.FC = () => {
                 className="w-full p-2 text-sm border border-gray-200 rounded bg-white focus:outline-none focus:ring-1 focus:ring-blue-300"
               />
             </div>
+            <div className="mt-3">
+              <label className="flex items-center gap-2 text-xs font-medium text-gray-500">
+                <input
+                  type="checkbox"
+                  checked={localConfig.generation.autoDescribeImages || false}
+                  onChange={(e) =>
+                    setLocalConfig({
+                      ...localConfig,
+                      generation: {
+                        ...localConfig.generation,
+                        autoDescribeImages: e.target.checked,
+                      },
+                    })
+                  }
+                />
+                Describe imported and generated images with LLM
+              </label>
+            </div>
+          </div>
+
+          {/* File Import */}
+          <div className="bg-gray-50 p-4 rounded-lg">
+            <h3 className="font-bold text-gray-700 mb-3 flex items-center gap-2">
+              <FileInput size={16} />
+              File Import
+            </h3>
+            <div>
+              <label className="block text-xs font-medium text-gray-500 mb-1">
+                Split PDF Into Nodes
+              </label>
+              <select
+                value={localConfig.import?.pdfSplit || "auto"}
+                onChange={(e) =>
+                  setLocalConfig({
+                    ...localConfig,
+                    import: {
+                      pdfPageImages: localConfig.import?.pdfPageImages || false,
+                      pdfSplit: e.target.value,
+                    },
+                  })
+                }
+                className="w-full p-2 text-sm border border-gray-200 rounded bg-white focus:outline-none focus:ring-1 focus:ring-blue-300"
+              >
+                <option value="auto">Auto (by heading if found)</option>
+                <option value="page">By page</option>
+                <option value="heading">By heading</option>
+              </select>
+            </div>
+            <div className="mt-3">
+              <label className="flex items-center gap-2 text-xs font-medium text-gray-500">
+                <input
+                  type="checkbox"
+                  checked={localConfig.import?.pdfPageImages || false}
+                  onChange={(e) =>
+                    setLocalConfig({
+                      ...localConfig,
+                      import: {
+                        pdfSplit: localConfig.import?.pdfSplit || "auto",
+                        pdfPageImages: e.target.checked,
+                      },
+                    })
+                  }
+                />
+                Import scanned PDF pages as images
+              </label>
+            </div>
+          </div>
+
+          {/* Image Library */}
+          <div className="bg-gray-50 p-4 rounded-lg">
+            <h3 className="font-bold text-gray-700 mb-3 flex items-center gap-2">
+              <ImageIcon size={16} />
+              Image Library
+            </h3>
+            <ImageLibrary />
+          </div>
+
+          {/* Image Assets */}
+          <div className="bg-gray-50 p-4 rounded-lg">
+            <h3 className="font-bold text-gray-700 mb-3 flex items-center gap-2">
+              <ImageIcon size={16} />
+              Image Assets
+            </h3>
+            <button
+              onClick={handleScanAssets}
+              className="w-full flex items-center justify-center gap-2 p-2 bg-white border border-gray-200 rounded-lg hover:bg-gray-50 transition-colors text-sm"
+            >
+              <FolderOpen size={16} />
+              Scan Canvases for Unused Images
+            </button>
+            <label className="flex items-center gap-2 mt-2 text-xs text-gray-600">
+              <input
+                type="checkbox"
+                checked={includeRecent}
+                onChange={(e) => setIncludeRecent(e.target.checked)}
+              />
+              Include recent canvases
+            </label>
+            {assetReport && (
+              <div className="mt-3 text-xs text-gray-600 space-y-1">
+                <p>
+                  Scanned {assetReport.canvases.length} canvases. Total{" "}
+                  {formatBytes(assetReport.totalSize)}.
+                </p>
+                <p>Referenced: {assetReport.referenced.length}</p>
+                <p>
+                  Orphaned: {orphans.length} (
+                  {formatBytes(
+                    orphans.reduce((sum, file) => sum + file.size, 0),
+                  )}
+                  )
+                </p>
+                {assetReport.missing.length > 0 && (
+                  <div className="text-red-600">
+                    <p>Missing: {assetReport.missing.length}</p>
+                    <ul className="list-disc pl-4 max-h-24 overflow-y-auto">
+                      {assetReport.missing.map((item) => (
+                        <li key={`${item.canvas}:${item.path}`}>
+                          {item.path}
+                        </li>
+                      ))}
+                    </ul>
+                  </div>
+                )}
+                {assetReport.warnings.map((warning) => (
+                  <p key={warning} className="text-amber-600">
+                    {warning}
+                  </p>
+                ))}
+                {orphans.length > 0 && (
+                  <button
+                    onClick={handleCleanupOrphans}
+                    className="w-full flex items-center justify-center gap-2 p-2 mt-2 bg-white border border-gray-200 rounded-lg hover:bg-gray-50 transition-colors"
+                  >
+                    <Trash2 size={14} />
+                    Move Orphans to Trash
+                  </button>
+                )}
+              </div>
+            )}
+            {trashBatches.length > 0 && (
+              <div className="mt-3 text-xs text-gray-600 space-y-1">
+                <p className="font-medium">Trash</p>
+                {trashBatches.map((batch) => (
+                  <div
+                    key={batch.id}
+                    className="flex items-center justify-between gap-2"
+                  >
+                    <span>
+                      {new Date(batch.createdAt).toLocaleString()} (
+                      {batch.paths.length} files, {formatBytes(batch.size)})
+                    </span>
+                    <span className="flex gap-2 shrink-0">
+                      <button
+                        onClick={() => handleRestoreTrash(batch.id)}
+                        className="text-blue-600 hover:underline"
+                      >
+                        Restore
+                      </button>
+                      <button
+                        onClick={() => handlePurgeTrash(batch.id)}
+                        className="text-red-600 hover:underline"
+                      >
+                        Delete
+                      </button>
+                    </span>
+                  </div>
+                ))}
+              </div>
+            )}
           </div>
 
           {/* Dangerous Zone */}
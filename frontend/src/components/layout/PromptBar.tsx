@@ -15,10 +15,22 @@ import { AppNode } from "../../types";
 import { traverseContextBackwards } from "../../utils/graphUtils";
 import * as AppBackend from "../../../wailsjs/go/main/App";
 
+// コンテキストに含めるノードのテキスト（画像ノードは LLM による説明がある場合のみ）
+const nodeContextText = (node: AppNode): string => {
+  if (node.type === "imageNode") {
+    const data = node.data as any;
+    return data.description
+      ? `[Image: ${data.alt || data.src}]\n${data.description}`
+      : "";
+  }
+  return (node.data as any).content;
+};
+
 const PromptBar: React.FC = () => {
   const [prompt, setPrompt] = useState("");
   const [isLoading, setIsLoading] = useState(false);
   const [mode, setMode] = useState<"text" | "image">("text"); // モード切替用
+  const [stylePreset, setStylePreset] = useState(""); // 画像生成のスタイルプリセット
 
   const {
     nodes,
@@ -34,6 +46,10 @@ const PromptBar: React.FC = () => {
     generateImage,
 
     getImageDataURL,
+
+    describeImageNode,
+
+    config,
   } = useAppStore();
 
   const selectedNodes = nodes.filter((n) => n.selected);
@@ -78,10 +94,9 @@ const PromptBar: React.FC = () => {
           contextNodes = selectedNodes;
         }
 
-        // For text nodes, combine content
+        // For text nodes, combine content (image nodes contribute their description)
         const contextText = contextNodes
-          .filter((n) => n.type === "customNode") // Only text nodes for text generation
-          .map((n) => (n.data as any).content)
+          .map(nodeContextText)
           .filter((content) => !!content)
           .join("\n\n---\n\n");
 
@@ -180,10 +195,9 @@ const PromptBar: React.FC = () => {
           contextNodes = selectedNodes;
         }
 
-        // For text nodes, combine content
+        // For text nodes, combine content (image nodes contribute their description)
         const context = contextNodes
-          .filter((n) => n.type === "customNode") // Only text nodes for text generation
-          .map((n) => (n.data as any).content)
+          .map(nodeContextText)
           .filter((content) => !!content)
           .join("\n\n---\n\n");
 
@@ -198,8 +212,19 @@ const PromptBar: React.FC = () => {
         console.log("=== End Context Debug Info ===");
 
         // 2. Construct reference images from context
+        const refImageNodes = contextNodes.filter((n) => n.type === "imageNode");
+        const capabilities = await AppBackend.GetImageProviderCapabilities("");
+        if (
+          capabilities.maxReferenceImages >= 0 &&
+          refImageNodes.length > capabilities.maxReferenceImages
+        ) {
+          throw new Error(
+            `The current image provider accepts at most ${capabilities.maxReferenceImages} reference image(s), but ${refImageNodes.length} are selected. Reduce the selected image nodes.`,
+          );
+        }
+
         const refImages: string[] = [];
-        for (const node of contextNodes.filter((n) => n.type === "imageNode")) {
+        for (const node of refImageNodes) {
           try {
             const dataURL = await getImageDataURL((node.data as any).src);
             refImages.push(dataURL);
@@ -209,7 +234,12 @@ const PromptBar: React.FC = () => {
         }
 
         // 3. Generate image from LLM via Backend
-        const imageSrc = await generateImage(prompt, context, refImages);
+        const result = await generateImage(
+          prompt,
+          context,
+          refImages,
+          stylePreset,
+        );
 
         // 4. Determine position for the new node
         let position = { x: 400, y: 300 };
@@ -227,7 +257,7 @@ const PromptBar: React.FC = () => {
           type: "imageNode",
           position,
           data: {
-            src: imageSrc,
+            src: result.src,
             alt: prompt,
           },
           width: 300,
@@ -235,6 +265,7 @@ const PromptBar: React.FC = () => {
         };
 
         addNode(newNode);
+        describeImageNode(newNode.id);
 
         // 6. Create a text node with the prompt used for image generation
         const promptNode: AppNode = {
@@ -245,7 +276,14 @@ const PromptBar: React.FC = () => {
             y: position.y + 250, // Position below the image node
           },
           data: {
-            content: `**Prompt used for image generation:**\n\n${prompt}`,
+            content:
+              result.prompt !== result.originalPrompt || result.negativePrompt
+                ? `**Prompt used for image generation:**\n\n${result.prompt}` +
+                  (result.negativePrompt
+                    ? `\n\n**Negative prompt:**\n\n${result.negativePrompt}`
+                    : "") +
+                  `\n\n**Original prompt:**\n\n${result.originalPrompt}`
+                : `**Prompt used for image generation:**\n\n${prompt}`,
             summary: "Image generation prompt",
           },
           width: 300,
@@ -300,6 +338,22 @@ const PromptBar: React.FC = () => {
             </button>
           </div>
 
+          {mode === "image" &&
+            (config.imageGen.stylePresets || []).length > 0 && (
+              <select
+                value={stylePreset}
+                onChange={(e) => setStylePreset(e.target.value)}
+                className="text-xs border border-gray-200 rounded px-2 py-1 bg-white text-gray-600 focus:outline-none focus:ring-1 focus:ring-blue-300"
+              >
+                <option value="">No style preset</option>
+                {(config.imageGen.stylePresets || []).map((preset) => (
+                  <option key={preset.name} value={preset.name}>
+                    {preset.name}
+                  </option>
+                ))}
+              </select>
+            )}
+
           {selectedNodesCount > 0 && (
             <span className="flex items-center gap-1 text-[10px] font-bold bg-blue-100 text-blue-600 px-2 py-0.5 rounded-full uppercase tracking-tighter animate-pulse">
               <Sparkles size={10} />
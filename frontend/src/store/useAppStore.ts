@@ -4,11 +4,17 @@ import {
   AppNode,
   AppEdge,
   AppConfig,
-  CanvasFileV1_0,
-  CanvasFileV1_1,
   TextNodeData,
   ImageNodeData,
   CanvasFile,
+  CanvasFileV1_1,
+  CanvasWarning,
+  CanvasFileChange,
+  ExportBundleResult,
+  RecoverableSession,
+  LoadCanvasResult,
+  SaveCanvasResult,
+  ImportFileResult,
 } from "../types";
 import {
   Connection,
@@ -31,35 +37,96 @@ const initialConfig: AppConfig = {
   imageGen: {
     provider: "openrouter",
     downloadPath: "Image/",
-    openrouter: {
-      baseURL: "https://openrouter.ai/api/v1",
-      model: "sourceful/riverflow-v2-standard-preview",
-      apiKey: "",
-    },
-    openai: {
-      baseURL: "https://api.openai.com/v1",
-      model: "gpt-image-1.5",
-      apiKey: "",
-    },
-    google: {
-      model: "gemini-2.5-flash-image",
-      apiKey: "",
-    },
-    xai: {
-      model: "grok-imagine-image",
-      apiKey: "",
-    },
+    providers: {},
   },
   generation: {
     summaryMaxChars: 100,
   },
+  import: {
+    pdfSplit: "auto",
+    pdfPageImages: false,
+  },
+};
+
+// Migrations are routine; anything else means nodes or edges were dropped or are broken
+const reportCanvasWarnings = (
+  action: "load" | "save" | "merge" | "import",
+  warnings: CanvasWarning[] | null | undefined,
+) => {
+  if (!warnings || warnings.length === 0) return;
+  warnings.forEach((w) => console.warn(`Canvas ${action}: ${w.message}`));
+  const problems = warnings.filter((w) => w.code !== "migrated");
+  if (problems.length > 0) {
+    alert(
+      `Canvas ${action} warnings:\n${problems.map((w) => `- ${w.message}`).join("\n")}`,
+    );
+  }
+};
+
+// 実行時のノード・エッジを永続化モデル（Version 1.1）に変換する
+const toCanvasFile = (nodes: AppNode[], edges: AppEdge[]): CanvasFile => {
+  // Version 1.1 として保存
+  const canvasData: CanvasFile = {
+    version: "1.1",
+    nodes: nodes.map((n) => {
+      if (n.type === "customNode") {
+        return {
+          id: n.id,
+          type: "customNode" as const,
+          position: n.position,
+          data: n.data as TextNodeData,
+          width: n.width,
+          height: n.height,
+        };
+      } else if (n.type === "imageNode") {
+        return {
+          id: n.id,
+          type: "imageNode" as const,
+          position: n.position,
+          data: n.data as ImageNodeData,
+          width: n.width,
+          height: n.height,
+        };
+      }
+      // Fallback for unknown node types - treat as customNode
+      return {
+        id: n.id,
+        type: "customNode" as const,
+        position: n.position,
+        data: n.data as TextNodeData,
+        width: n.width,
+        height: n.height,
+      };
+    }),
+    edges: edges.map((e) => ({
+      id: e.id,
+      source: e.source,
+      target: e.target,
+      sourceHandle: e.sourceHandle || "right-source",
+      targetHandle: e.targetHandle || "left-target",
+      type: e.type,
+      markerEnd: e.markerEnd as any,
+    })),
+  };
+  return canvasData;
 };
 
+// バックエンドから受け取ったキャンバス（Version 1.1）を実行時のノード・エッジに変換する
+const fromCanvasFile = (data: CanvasFileV1_1) => ({
+  nodes: data.nodes as AppNode[],
+  edges: (data.edges || []).map((e) => ({
+    ...e,
+    sourceHandle: e.sourceHandle || "right-source",
+    targetHandle: e.targetHandle || "left-target",
+  })) as AppEdge[],
+});
+
 export const useAppStore = create<AppState>((set, get) => ({
   nodes: [],
   edges: [],
   isEditorOpen: false,
   isSettingsOpen: false,
+  currentFilePath: "",
   activeNodeId: null,
   config: initialConfig,
 
@@ -172,63 +239,15 @@ export const useAppStore = create<AppState>((set, get) => ({
       // This is necessary because the backend Config struct might not perfectly match the frontend AppConfig type
       // Specifically, the backend might not include all optional fields or might have different field types
 
-      // Handle backward compatibility for imageGen
-      let imageGen = (config as any).imageGen || initialConfig.imageGen;
-
-      // If the old format is used (flat structure), migrate to the new format
-      if (imageGen.baseURL || imageGen.model || imageGen.apiKey) {
-        switch (imageGen.provider) {
-          case "openrouter":
-            imageGen = {
-              provider: "openrouter",
-              downloadPath: imageGen.downloadPath,
-              openrouter: {
-                baseURL: imageGen.baseURL || "",
-                model: imageGen.model || "",
-                apiKey: imageGen.apiKey || "",
-              },
-            };
-            break;
-          case "openai":
-            imageGen = {
-              provider: "openai",
-              downloadPath: imageGen.downloadPath,
-              openai: {
-                baseURL: imageGen.baseURL || "",
-                model: imageGen.model || "",
-                apiKey: imageGen.apiKey || "",
-                size: "1024x1024",
-              },
-            };
-            break;
-          case "google":
-            imageGen = {
-              provider: "google",
-              downloadPath: imageGen.downloadPath,
-              google: {
-                model: imageGen.model || "",
-                apiKey: imageGen.apiKey || "",
-              },
-            };
-            break;
-          case "xai":
-            imageGen = {
-              provider: "xai",
-              downloadPath: imageGen.downloadPath,
-              xai: {
-                model: imageGen.model || "",
-                apiKey: imageGen.apiKey || "",
-              },
-            };
-            break;
-        }
-      }
+      // Legacy provider settings are migrated by the backend
+      const imageGen = (config as any).imageGen || initialConfig.imageGen;
 
       // Ensure the config object has all required properties
       const fullConfig: AppConfig = {
         llm: (config as any).llm || initialConfig.llm,
         generation: (config as any).generation || initialConfig.generation,
         imageGen: imageGen,
+        import: (config as any).import || initialConfig.import,
       };
       set({ config: fullConfig });
     } catch (error) {
@@ -238,49 +257,13 @@ export const useAppStore = create<AppState>((set, get) => ({
 
   saveConfig: async (config: AppConfig) => {
     try {
-      // Create a copy of the config to avoid modifying the original
-      const configToSave = { ...config };
-
-      // For backward compatibility, flatten the imageGen config if needed
-      if (configToSave.imageGen) {
-        const { provider, downloadPath, ...providerConfigs } =
-          configToSave.imageGen;
-        let flatConfig = {
-          provider,
-          downloadPath,
-        } as any;
-
-        // Add provider-specific fields to the flat structure
-        switch (provider) {
-          case "openrouter":
-            flatConfig.baseURL = providerConfigs.openrouter?.baseURL || "";
-            flatConfig.model = providerConfigs.openrouter?.model || "";
-            flatConfig.apiKey = providerConfigs.openrouter?.apiKey || "";
-            break;
-          case "openai":
-            flatConfig.baseURL = providerConfigs.openai?.baseURL || "";
-            flatConfig.model = providerConfigs.openai?.model || "";
-            flatConfig.apiKey = providerConfigs.openai?.apiKey || "";
-            break;
-          case "google":
-            flatConfig.model = providerConfigs.google?.model || "";
-            flatConfig.apiKey = providerConfigs.google?.apiKey || "";
-            break;
-          case "xai":
-            flatConfig.model = providerConfigs.xai?.model || "";
-            flatConfig.apiKey = providerConfigs.xai?.apiKey || "";
-            break;
-        }
-
-        configToSave.imageGen = flatConfig;
-      }
-
-      await AppBackend.SaveConfig(configToSave as any);
+      await AppBackend.SaveConfig(config as any);
       // Ensure the config object has all required properties
       const fullConfig: AppConfig = {
         llm: config.llm || initialConfig.llm,
         generation: config.generation || initialConfig.generation,
         imageGen: config.imageGen || initialConfig.imageGen,
+        import: config.import || initialConfig.import,
       };
       set({ config: fullConfig });
     } catch (error) {
@@ -290,55 +273,59 @@ export const useAppStore = create<AppState>((set, get) => ({
 
   saveCanvas: async () => {
     const { nodes, edges } = get();
-    // Version 1.1 として保存
-    const canvasData: CanvasFile = {
-      version: "1.1",
-      nodes: nodes.map((n) => {
-        if (n.type === "customNode") {
-          return {
-            id: n.id,
-            type: "customNode" as const,
-            position: n.position,
-            data: n.data as TextNodeData,
-            width: n.width,
-            height: n.height,
-          };
-        } else if (n.type === "imageNode") {
-          return {
-            id: n.id,
-            type: "imageNode" as const,
-            position: n.position,
-            data: n.data as ImageNodeData,
-            width: n.width,
-            height: n.height,
-          };
+    const canvasData = toCanvasFile(nodes, edges);
+
+    try {
+      // 現在のファイルに上書き保存（新規キャンバスの場合はダイアログを開く）
+      // バックエンドで検証・正規化してから保存される
+      let result = (await AppBackend.SaveCanvas(
+        canvasData as any,
+      )) as SaveCanvasResult;
+
+      // 読み込み後に外部で変更されていた場合は保存されない。マージするか上書きするかを選ぶ
+      if (result.conflict) {
+        const modifiedAt = result.conflict.modifiedAt
+          ? ` (${new Date(result.conflict.modifiedAt).toLocaleString()})`
+          : "";
+        if (
+          confirm(
+            `The canvas file was changed outside the app${modifiedAt}.\n\nOK: merge the external changes into the canvas, then review and save again.\nCancel: choose whether to overwrite the file.`,
+          )
+        ) {
+          const merged = (await AppBackend.MergeCanvasWithFile(
+            canvasData as any,
+          )) as unknown as LoadCanvasResult;
+          reportCanvasWarnings("merge", merged.warnings);
+          if (merged.canvas) set(fromCanvasFile(merged.canvas));
+          return "";
         }
-        // Fallback for unknown node types - treat as customNode
-        return {
-          id: n.id,
-          type: "customNode" as const,
-          position: n.position,
-          data: n.data as TextNodeData,
-          width: n.width,
-          height: n.height,
-        };
-      }),
-      edges: edges.map((e) => ({
-        id: e.id,
-        source: e.source,
-        target: e.target,
-        sourceHandle: e.sourceHandle || "right-source",
-        targetHandle: e.targetHandle || "left-target",
-        type: e.type,
-        markerEnd: e.markerEnd as any,
-      })),
-    };
+        if (!confirm("Overwrite the file and discard the external changes?")) {
+          return "";
+        }
+        result = (await AppBackend.OverwriteCanvas(
+          canvasData as any,
+        )) as SaveCanvasResult;
+      }
+      reportCanvasWarnings("save", result.warnings);
+      if (result.path) set({ currentFilePath: result.path });
+      return result.path;
+    } catch (error) {
+      console.error("Failed to save canvas:", error);
+      throw error;
+    }
+  },
+
+  saveCanvasAs: async () => {
+    const { nodes, edges } = get();
+    const canvasData = toCanvasFile(nodes, edges);
 
     try {
-      const filePath = await AppBackend.SaveCanvasToFile(
-        JSON.stringify(canvasData, null, 2),
-      );
-      return filePath;
+      const result = (await AppBackend.SaveCanvasAs(
+        canvasData as any,
+      )) as SaveCanvasResult;
+      reportCanvasWarnings("save", result.warnings);
+      if (result.path) set({ currentFilePath: result.path });
+      return result.path;
     } catch (error) {
       console.error("Failed to save canvas:", error);
       throw error;
@@ -347,44 +334,160 @@ export const useAppStore = create<AppState>((set, get) => ({
 
   loadCanvas: async () => {
     try {
-      const jsonString = await AppBackend.LoadCanvasFromFile();
-      if (!jsonString) return;
+      // バックエンドで現在のバージョン（1.1）に移行済みのキャンバスが返る
+      const result =
+        (await AppBackend.LoadCanvasFromFile()) as unknown as LoadCanvasResult;
+      if (!result.canvas) return;
+      reportCanvasWarnings("load", result.warnings);
+      set({ ...fromCanvasFile(result.canvas), currentFilePath: result.path });
+    } catch (error) {
+      console.error("Failed to load canvas:", error);
+      throw error;
+    }
+  },
+
+  handleExternalChange: async (change: CanvasFileChange) => {
+    const { currentFilePath, openCanvasPath } = get();
+    if (change.path !== currentFilePath) return;
+
+    if (change.removed) {
+      alert(
+        `The canvas file was removed outside the app:\n${change.path}\n\nSave the canvas to write it again.`,
+      );
+      return;
+    }
+    // 再読み込みしない場合は、次回の保存時にマージか上書きを選ぶ
+    if (
+      confirm(
+        `The canvas file was changed outside the app:\n${change.path}\n\nReload it? Unsaved changes on the canvas will be lost.`,
+      )
+    ) {
+      await openCanvasPath(change.path);
+    }
+  },
+
+  openCanvasPath: async (path: string) => {
+    try {
+      const result = (await AppBackend.OpenCanvasPath(
+        path,
+      )) as unknown as LoadCanvasResult;
+      if (!result.canvas) return;
+      reportCanvasWarnings("load", result.warnings);
+      set({ ...fromCanvasFile(result.canvas), currentFilePath: result.path });
+    } catch (error) {
+      console.error("Failed to open canvas:", error);
+      throw error;
+    }
+  },
+
+  restoreCanvasVersion: async (id: string) => {
+    const { currentFilePath } = get();
+    try {
+      // 選択したバージョンでファイルを上書きし、そのまま読み込む（置き換え前の状態も履歴に残る）
+      const result = (await AppBackend.RestoreCanvasVersion(
+        currentFilePath,
+        id,
+      )) as unknown as LoadCanvasResult;
+      if (!result.canvas) return;
+      reportCanvasWarnings("load", result.warnings);
+      set({ ...fromCanvasFile(result.canvas), currentFilePath: result.path });
+    } catch (error) {
+      console.error("Failed to restore canvas version:", error);
+      throw error;
+    }
+  },
 
-      const data = JSON.parse(jsonString) as CanvasFile;
+  newCanvas: async () => {
+    await AppBackend.NewCanvas();
+    set({ nodes: [], edges: [], activeNodeId: null, currentFilePath: "" });
+  },
 
-      let nodesToSet: AppNode[] = [];
-      if ((data as CanvasFileV1_0).version === "1.0") {
-        // Version 1.0: Only text nodes
-        nodesToSet = (data as CanvasFileV1_0).nodes.map((n: any) => ({
-          ...n,
-          type: "customNode" as const,
-        })) as AppNode[];
-      } else if ((data as CanvasFileV1_1).version === "1.1") {
-        // Version 1.1: Mixed nodes (text and image)
-        nodesToSet = (data as CanvasFileV1_1).nodes as AppNode[];
-      } else {
-        // Unknown version - try to load as v1.1, but filter out unknown types for safety
-        console.warn(`Unknown canvas file version: ${data.version}`);
-        const v1_1Data = data as CanvasFileV1_1;
-        nodesToSet = v1_1Data.nodes.filter(
-          (n: any) => n.type === "customNode" || n.type === "imageNode",
-        ) as AppNode[];
+  exportBundle: async () => {
+    const { nodes, edges } = get();
+    try {
+      const result = (await AppBackend.ExportBundle(
+        toCanvasFile(nodes, edges) as any,
+      )) as ExportBundleResult;
+      if (result.missing && result.missing.length > 0) {
+        alert(
+          `These images could not be found and were not included:\n${result.missing.join("\n")}`,
+        );
       }
+      return result.path;
+    } catch (error) {
+      console.error("Failed to export bundle:", error);
+      throw error;
+    }
+  },
 
-      set({
-        nodes: nodesToSet,
-        edges: ((data as CanvasFileV1_1).edges || []).map((e: any) => ({
-          ...e,
-          sourceHandle: e.sourceHandle || "right-source",
-          targetHandle: e.targetHandle || "left-target",
-        })) as AppEdge[],
-      });
+  importBundle: async () => {
+    try {
+      // 画像はアセットストアに展開され、src は展開先のパスに書き換え済み
+      const result =
+        (await AppBackend.ImportBundle()) as unknown as LoadCanvasResult;
+      if (!result.canvas) return;
+      reportCanvasWarnings("load", result.warnings);
+      set({ ...fromCanvasFile(result.canvas), currentFilePath: "" });
     } catch (error) {
-      console.error("Failed to load canvas:", error);
+      console.error("Failed to import bundle:", error);
+      throw error;
+    }
+  },
+
+  importMarkdownFolder: async () => {
+    try {
+      // ノートはテキストノード、リンクはエッジ、埋め込み画像は画像ノードになり、配置済みで返る
+      const result = (await AppBackend.ImportMarkdownFolder(
+        "",
+      )) as unknown as LoadCanvasResult;
+      if (!result.canvas) return;
+      reportCanvasWarnings("import", result.warnings);
+      set({ ...fromCanvasFile(result.canvas), currentFilePath: "" });
+    } catch (error) {
+      console.error("Failed to import Markdown folder:", error);
       throw error;
     }
   },
 
+  pushAutosave: async () => {
+    const { nodes, edges, currentFilePath } = get();
+    try {
+      await AppBackend.PushCanvasState(
+        toCanvasFile(nodes, edges) as any,
+        currentFilePath,
+      );
+    } catch (error) {
+      console.error("Failed to autosave canvas:", error);
+    }
+  },
+
+  recoverSession: async () => {
+    try {
+      const sessions = ((await AppBackend.GetRecoverableSessions()) ||
+        []) as RecoverableSession[];
+      if (sessions.length === 0) return;
+
+      // 最新のセッションのみ復元を提案する（古いものは保持期間が過ぎると削除される）
+      const latest = sessions[0];
+      const restore = confirm(
+        `Unsaved work from ${new Date(latest.savedAt).toLocaleString()} (${latest.nodeCount} nodes) was found. Restore it?`,
+      );
+      if (!restore) {
+        await AppBackend.DiscardSession(latest.id);
+        return;
+      }
+
+      const result = (await AppBackend.RestoreSession(
+        latest.id,
+      )) as unknown as LoadCanvasResult;
+      if (!result.canvas) return;
+      reportCanvasWarnings("load", result.warnings);
+      set({ ...fromCanvasFile(result.canvas), currentFilePath: result.path });
+    } catch (error) {
+      console.error("Failed to recover session:", error);
+    }
+  },
+
   generateText: async (prompt: string, context: string) => {
     try {
       const result = await AppBackend.GenerateText(prompt, context);
@@ -411,9 +514,16 @@ export const useAppStore = create<AppState>((set, get) => ({
     context: string,
 
     refImages: string[],
+
+    stylePreset: string,
   ) => {
     try {
-      const result = await AppBackend.GenerateImage(prompt, context, refImages);
+      const result = await AppBackend.GenerateImage(
+        prompt,
+        context,
+        refImages,
+        stylePreset,
+      );
 
       return result;
     } catch (error) {
@@ -582,13 +692,42 @@ export const useAppStore = create<AppState>((set, get) => ({
   importFile: async (filePath: string) => {
     try {
       const result = await AppBackend.ImportFile(filePath);
-      return result as any;
+      return result as unknown as ImportFileResult;
     } catch (error) {
       console.error("Failed to import file:", error);
       throw error;
     }
   },
 
+  describeImageNode: (id: string) => {
+    const { config, nodes } = get();
+    const node = nodes.find((n) => n.id === id);
+    if (!config.generation.autoDescribeImages || node?.type !== "imageNode") {
+      return;
+    }
+
+    // The node is shown right away; the description is filled in when the LLM answers
+    const src = (node.data as ImageNodeData).src;
+    AppBackend.DescribeImage(src, "brief")
+      .then((desc) => {
+        set((state) => ({
+          nodes: state.nodes.map((n) =>
+            n.id === id && (n.data as ImageNodeData).src === src
+              ? {
+                  ...n,
+                  data: {
+                    ...n.data,
+                    alt: desc.caption || (n.data as ImageNodeData).alt,
+                    description: desc.description,
+                  },
+                }
+              : n,
+          ),
+        }));
+      })
+      .catch((error) => console.error("Failed to describe image:", error));
+  },
+
   // React Flow integration actions
   onNodesChange: (changes: NodeChange<AppNode>[]) => {
     set({
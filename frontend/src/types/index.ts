@@ -30,52 +30,144 @@ export interface ImageNodeData extends Record<string, unknown> {
    * 例:
    * - "generated_001.png"
    * - "Import/photo.png"
+   * - "Assets/<sha256>.png"（コンテンツハッシュ名で保存された画像）
    */
   src: string;
-  alt?: string; // 生成プロンプト等
+  alt?: string; // 生成プロンプト、または LLM によるキャプション
+  description?: string; // LLM による画像の説明（コンテキストに含める）
 }
 
 // Node Types
 export type NodeData = TextNodeData | ImageNodeData;
 
 // Import File Result (Backend interaction)
-export interface ImportFileResult {
+// 画像生成のスタイルプリセット
+export interface StylePreset {
+  name: string;
+  promptPrefix: string;
+  promptSuffix: string;
+  negativePrompt: string;
+  size: string; // "WxH"、空ならプロバイダの既定値
+  quality: string; // "low" | "medium" | "high" 等、空ならプロバイダの既定値
+}
+
+// 画像生成の結果（プロンプト補正が有効な場合は LLM による書き換え後のプロンプトを含む）
+export interface ImageGenResult {
+  src: string; // 保存された画像の相対パス
+  originalPrompt: string; // ユーザーが入力したプロンプト
+  prompt: string; // プロバイダに送信したプロンプト
+  negativePrompt: string;
+  enhanced: boolean;
+  stylePreset: string;
+}
+
+// LLM による画像のキャプションと説明
+export interface ImageDescription {
+  caption: string;
+  description: string;
+}
+
+// インポートしたファイルから作るノード
+export interface ImportedItem {
   type: "text" | "image";
   content: string; // text: content itself, image: relative path
+  title?: string; // PDF のページや見出しなど、サマリーの候補
 }
 
-/** =========================
- *  実行時モデル（Runtime）
- *  ========================= */
+export interface ImportFileResult {
+  items: ImportedItem[]; // 通常は 1 件、PDF はページまたは見出しごと
+  chain: boolean; // 1 つの文書の連続した部分（順にエッジでつなぐ）
+  warnings: string[]; // 取り込めなかった部分
+}
 
-// LLM設定など（アプリのローカル設定）
-export interface OpenRouterConfig {
-  baseURL: string;
-  model: string;
-  apiKey?: string; // 秘匿情報（ローカル設定にのみ保存）
+// 画像アセットのスキャン結果（ScanAssets）
+export interface AssetFileInfo {
+  path: string; // ダウンロードフォルダからの相対パス
+  size: number;
+  modTime: string;
 }
 
-export interface OpenAIConfig {
-  baseURL: string;
-  model: string;
-  apiKey?: string; // 秘匿情報（ローカル設定にのみ保存）
+export interface AssetReport {
+  canvases: string[]; // 読み込めたキャンバスファイル
+  referenced: AssetFileInfo[];
+  orphaned: AssetFileInfo[]; // どのキャンバスからも参照されていない画像
+  missing: { path: string; canvas: string }[]; // 参照されているが存在しない画像
+  totalSize: number;
+  orphanedSize: number;
+  warnings: string[];
 }
 
-export interface GoogleConfig {
-  model: string;
-  apiKey?: string; // 秘匿情報（ローカル設定にのみ保存）
+// CleanupOrphans でゴミ箱に移動した画像のまとまり（復元可能）
+export interface TrashBatch {
+  id: string;
+  createdAt: string;
+  paths: string[];
+  size: number;
 }
 
-export interface XAIConfig {
-  model: string;
-  apiKey?: string; // 秘匿情報（ローカル設定にのみ保存）
+// 画像ライブラリ（ListImages）
+export interface ImageListFilter {
+  since: string; // "YYYY-MM-DD" または RFC3339
+  until: string; // "YYYY-MM-DD"（その日を含む）または RFC3339
+  provider: string;
+  query: string; // プロンプト・ファイル名の部分一致
+}
+
+export interface ImageInfo {
+  path: string; // ダウンロードフォルダからの相対パス
+  width: number;
+  height: number;
+  size: number;
+  format: string;
+  createdAt: string;
+  provider?: string; // 生成画像のみ
+  model?: string;
+  prompt?: string;
+  parent?: string; // 編集（TransformImage）で作られた画像の元画像
+}
+
+export interface ImageListResult {
+  items: ImageInfo[];
+  total: number;
+  page: number;
+  pageSize: number;
+}
+
+// TransformImage の操作（type に関係するフィールドのみ使用される）
+export interface ImageOperation {
+  type: "crop" | "rotate" | "flip" | "resize" | "convert";
+  x?: number; // crop
+  y?: number; // crop
+  width?: number; // crop / resize（resize で 0 ならアスペクト比を維持）
+  height?: number; // crop / resize
+  scale?: number; // resize: width・height が 0 の場合の倍率
+  angle?: 90 | 180 | 270; // rotate（時計回り）
+  direction?: "horizontal" | "vertical"; // flip
+  format?: "png" | "jpeg"; // convert
+  quality?: number; // convert: JPEG 品質 1-100
+}
+
+/** =========================
+ *  実行時モデル（Runtime）
+ *  ========================= */
+
+// 画像生成プロバイダの設定（キーはバックエンドのスキーマで決まる）
+export type ProviderSettings = Record<string, unknown>;
+
+// プロバイダ設定の1項目（バックエンドの設定構造体から生成される）
+export interface ProviderConfigField {
+  key: string;
+  label: string;
+  type: string; // "string" | "secret" | "number" | "boolean" | "list"
+  default?: unknown;
 }
 
-// プロバイダごとの設定を保持する型
-export type ProviderConfig =
-  | { provider: "openrouter"; config: OpenRouterConfig }
-  | { provider: "openai"; config: OpenAIConfig }
-  | { provider: "xai"; config: XAIConfig };
+// 登録済みの画像生成プロバイダ
+export interface ImageProviderInfo {
+  name: string;
+  displayName: string;
+  fields: ProviderConfigField[];
+}
 
 export interface AppConfig {
   llm: {
@@ -86,20 +178,26 @@ export interface AppConfig {
   };
   // 画像生成プロバイダの設定
   imageGen: {
-    provider: string; // "openrouter" | "stabilityai" | "dalle" | "local"
+    provider: string; // 登録名（GetImageProviders の name）
     downloadPath: string;
-    // プロバイダごとの設定（バックエンドとの互換性のために残す）
-    baseURL?: string;
-    model?: string;
-    apiKey?: string;
-    // プロバイダごとの設定
-    openrouter?: OpenRouterConfig;
-    openai?: OpenAIConfig;
-    google?: GoogleConfig;
-    xai?: XAIConfig;
+    // プロバイダごとの設定（登録名がキー）
+    providers?: Record<string, ProviderSettings>;
+    // LLM による画像プロンプトの補正
+    promptEnhancement?: {
+      enabled: boolean;
+      style: string; // 任意のスタイル指定
+    };
+    stylePresets?: StylePreset[];
   };
   generation: {
     summaryMaxChars: number; // サマリー上限文字数
+    autoDescribeImages?: boolean; // インポート・生成した画像に LLM で説明を付ける
+  };
+  recentCanvases?: string[]; // 最近使ったキャンバスファイル（バックエンドが管理）
+  // ファイルインポートの設定
+  import?: {
+    pdfSplit: string; // "auto" | "page" | "heading"
+    pdfPageImages: boolean; // テキストのないページ（スキャン）を画像として取り込む
   };
 }
 
@@ -119,6 +217,7 @@ export interface AppState {
   isSettingsOpen: boolean;
   activeNodeId: string | null;
   config: AppConfig;
+  currentFilePath: string; // 編集中のキャンバスファイル（新規の場合は空文字）
 
   // Actions
   addNode: (node: AppNode) => void;
@@ -139,16 +238,28 @@ export interface AppState {
   loadConfig: () => Promise<void>;
   saveConfig: (config: AppConfig) => Promise<void>;
   saveCanvas: () => Promise<string>;
+  saveCanvasAs: () => Promise<string>;
   loadCanvas: () => Promise<void>;
+  openCanvasPath: (path: string) => Promise<void>;
+  handleExternalChange: (change: CanvasFileChange) => Promise<void>;
+  newCanvas: () => Promise<void>;
+  restoreCanvasVersion: (id: string) => Promise<void>;
+  exportBundle: () => Promise<string>;
+  importBundle: () => Promise<void>;
+  importMarkdownFolder: () => Promise<void>;
+  pushAutosave: () => Promise<void>;
+  recoverSession: () => Promise<void>;
   generateText: (prompt: string, context: string) => Promise<string>;
   generateSummary: (text: string) => Promise<string>;
   generateImage: (
     prompt: string,
     context: string,
     refImages: string[],
-  ) => Promise<string>;
+    stylePreset: string,
+  ) => Promise<ImageGenResult>;
   getImageDataURL: (src: string) => Promise<string>;
   importFile: (filePath: string) => Promise<ImportFileResult>;
+  describeImageNode: (id: string) => void; // 自動説明が有効な場合、バックグラウンドで説明を付ける
   exportMarkdown: (content: string) => Promise<string>;
   exportNode: (nodeId: string) => Promise<string>;
   exportImage: (src: string) => Promise<string>;
@@ -225,3 +336,75 @@ export interface CanvasFileV1_1 {
 }
 
 export type CanvasFile = CanvasFileV1_0 | CanvasFileV1_1;
+
+// キャンバスの読み込み・保存時にバックエンドが返す警告
+// code: "migrated" | "duplicate_node_id" | "unknown_node_type" | "dangling_edge" など
+export interface CanvasWarning {
+  code: string;
+  message: string;
+  nodeId?: string;
+  edgeId?: string;
+}
+
+export interface LoadCanvasResult {
+  path: string;
+  canvas: CanvasFileV1_1 | null; // キャンセル時は null
+  warnings: CanvasWarning[];
+}
+
+// 編集中のキャンバスファイルがアプリ外で変更・削除されたことを表す
+export interface CanvasFileChange {
+  path: string;
+  modifiedAt?: string;
+  removed: boolean;
+}
+
+export interface SaveCanvasResult {
+  path: string; // キャンセル時・競合時は空文字
+  warnings: CanvasWarning[];
+  conflict?: CanvasFileChange; // 読み込み後にアプリ外で変更されていた（保存されていない）
+}
+
+// キャンバスファイルの保存履歴（保存のたびに記録されるスナップショット）
+export interface CanvasVersion {
+  id: string;
+  savedAt: string;
+  nodeCount: number;
+  edgeCount: number;
+  size: number;
+}
+
+// 2 つのバージョン間でのノードの変更
+export interface NodeChange {
+  nodeId: string;
+  type: string;
+  from?: { x: number; y: number }; // 移動したノードのみ
+  to?: { x: number; y: number };
+  field?: string; // 変更されたデータ項目（content, summary, src など）
+  oldValue?: string;
+  newValue?: string;
+}
+
+export interface CanvasDiff {
+  added: NodeChange[];
+  removed: NodeChange[];
+  moved: NodeChange[];
+  changed: NodeChange[]; // ノードの変更された項目ごとに 1 件
+  edgesAdded: PersistedEdge[];
+  edgesRemoved: PersistedEdge[];
+}
+
+// 前回の実行で保存されずに残った作業（自動保存のスナップショット）
+export interface RecoverableSession {
+  id: string;
+  savedAt: string;
+  sourcePath: string; // 編集していたキャンバスファイル（新規の場合は空）
+  nodeCount: number;
+}
+
+// .fmcanvas バンドルの書き出し結果
+export interface ExportBundleResult {
+  path: string; // キャンセル時は空文字
+  images: number; // バンドルに含めた画像の数
+  missing: string[]; // 見つからず含められなかった画像
+}
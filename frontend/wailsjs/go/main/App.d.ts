@@ -1,12 +1,23 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 import {backend} from '../models';
+import {canvas} from '../models';
+
+export function CleanupOrphans(arg1:Array<string>):Promise<backend.TrashBatch>;
+
+export function DescribeImage(arg1:string,arg2:string):Promise<backend.ImageDescription>;
+
+export function DiffCanvasVersions(arg1:string,arg2:string,arg3:string):Promise<canvas.Diff>;
+
+export function DiscardSession(arg1:string):Promise<void>;
+
+export function ExportBundle(arg1:canvas.Canvas):Promise<backend.ExportBundleResult>;
 
 export function ExportImage(arg1:string):Promise<string>;
 
 export function ExportMarkdown(arg1:string):Promise<string>;
 
-export function GenerateImage(arg1:string,arg2:string,arg3:Array<string>):Promise<string>;
+export function GenerateImage(arg1:string,arg2:string,arg3:Array<string>,arg4:string):Promise<backend.ImageGenResult>;
 
 export function GenerateSummary(arg1:string):Promise<string>;
 
@@ -14,18 +25,76 @@ export function GenerateText(arg1:string,arg2:string):Promise<string>;
 
 export function GenerateTextWithImages(arg1:string,arg2:string,arg3:Array<string>):Promise<string>;
 
+export function GetCanvasVersion(arg1:string,arg2:string):Promise<backend.LoadCanvasResult>;
+
 export function GetConfig():Promise<backend.Config>;
 
+export function GetConfigWarnings():Promise<Array<string>>;
+
+export function GetCurrentCanvasPath():Promise<string>;
+
+export function GetImageAssetURL(arg1:string):Promise<string>;
+
 export function GetImageDataURL(arg1:string):Promise<string>;
 
 export function GetImageFileURL(arg1:string):Promise<string>;
 
+export function GetImageProvenance(arg1:string):Promise<backend.ImageProvenance>;
+
+export function GetImageProviderCapabilities(arg1:string):Promise<backend.ImageGenCapabilities>;
+
+export function GetImageProviders():Promise<Array<backend.ImageProviderInfo>>;
+
+export function GetImageThumbnail(arg1:string,arg2:number):Promise<string>;
+
+export function GetRecentCanvases():Promise<Array<string>>;
+
+export function GetRecoverableSessions():Promise<Array<backend.RecoverableSession>>;
+
 export function Greet(arg1:string):Promise<string>;
 
+export function ImportBundle():Promise<backend.LoadCanvasResult>;
+
 export function ImportFile(arg1:string):Promise<backend.ImportFileResult>;
 
-export function LoadCanvasFromFile():Promise<string>;
+export function ImportMarkdownFolder(arg1:string):Promise<backend.LoadCanvasResult>;
+
+export function ListCanvasVersions(arg1:string):Promise<Array<backend.CanvasVersion>>;
 
-export function SaveCanvasToFile(arg1:string):Promise<string>;
+export function ListImages(arg1:backend.ImageListFilter,arg2:backend.ImageListPage):Promise<backend.ImageListResult>;
+
+export function ListTrash():Promise<Array<backend.TrashBatch>>;
+
+export function LoadCanvasFromFile():Promise<backend.LoadCanvasResult>;
+
+export function MergeCanvasWithFile(arg1:canvas.Canvas):Promise<backend.LoadCanvasResult>;
+
+export function NewCanvas():Promise<void>;
+
+export function OpenCanvasPath(arg1:string):Promise<backend.LoadCanvasResult>;
+
+export function OverwriteCanvas(arg1:canvas.Canvas):Promise<backend.SaveCanvasResult>;
+
+export function PurgeTrash(arg1:string):Promise<void>;
+
+export function PushCanvasState(arg1:canvas.Canvas,arg2:string):Promise<void>;
+
+export function RegenerateImage(arg1:string):Promise<backend.ImageGenResult>;
+
+export function RestoreCanvasVersion(arg1:string,arg2:string):Promise<backend.LoadCanvasResult>;
+
+export function RestoreSession(arg1:string):Promise<backend.LoadCanvasResult>;
+
+export function RestoreTrash(arg1:string):Promise<void>;
+
+export function SaveCanvas(arg1:canvas.Canvas):Promise<backend.SaveCanvasResult>;
+
+export function SaveCanvasAs(arg1:canvas.Canvas):Promise<backend.SaveCanvasResult>;
 
 export function SaveConfig(arg1:backend.Config):Promise<void>;
+
+export function ScanAssets(arg1:Array<string>,arg2:boolean):Promise<backend.AssetReport>;
+
+export function SelectCanvasFiles():Promise<Array<string>>;
+
+export function TransformImage(arg1:string,arg2:Array<backend.ImageOperation>):Promise<string>;
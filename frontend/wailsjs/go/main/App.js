@@ -2,6 +2,26 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function CleanupOrphans(arg1) {
+  return window['go']['main']['App']['CleanupOrphans'](arg1);
+}
+
+export function DescribeImage(arg1, arg2) {
+  return window['go']['main']['App']['DescribeImage'](arg1, arg2);
+}
+
+export function DiffCanvasVersions(arg1, arg2, arg3) {
+  return window['go']['main']['App']['DiffCanvasVersions'](arg1, arg2, arg3);
+}
+
+export function DiscardSession(arg1) {
+  return window['go']['main']['App']['DiscardSession'](arg1);
+}
+
+export function ExportBundle(arg1) {
+  return window['go']['main']['App']['ExportBundle'](arg1);
+}
+
 export function ExportImage(arg1) {
   return window['go']['main']['App']['ExportImage'](arg1);
 }
@@ -10,8 +30,8 @@ export function ExportMarkdown(arg1) {
   return window['go']['main']['App']['ExportMarkdown'](arg1);
 }
 
-export function GenerateImage(arg1, arg2, arg3) {
-  return window['go']['main']['App']['GenerateImage'](arg1, arg2, arg3);
+export function GenerateImage(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['GenerateImage'](arg1, arg2, arg3, arg4);
 }
 
 export function GenerateSummary(arg1) {
@@ -26,10 +46,26 @@ export function GenerateTextWithImages(arg1, arg2, arg3) {
   return window['go']['main']['App']['GenerateTextWithImages'](arg1, arg2, arg3);
 }
 
+export function GetCanvasVersion(arg1, arg2) {
+  return window['go']['main']['App']['GetCanvasVersion'](arg1, arg2);
+}
+
 export function GetConfig() {
   return window['go']['main']['App']['GetConfig']();
 }
 
+export function GetConfigWarnings() {
+  return window['go']['main']['App']['GetConfigWarnings']();
+}
+
+export function GetCurrentCanvasPath() {
+  return window['go']['main']['App']['GetCurrentCanvasPath']();
+}
+
+export function GetImageAssetURL(arg1) {
+  return window['go']['main']['App']['GetImageAssetURL'](arg1);
+}
+
 export function GetImageDataURL(arg1) {
   return window['go']['main']['App']['GetImageDataURL'](arg1);
 }
@@ -38,22 +74,122 @@ export function GetImageFileURL(arg1) {
   return window['go']['main']['App']['GetImageFileURL'](arg1);
 }
 
+export function GetImageProvenance(arg1) {
+  return window['go']['main']['App']['GetImageProvenance'](arg1);
+}
+
+export function GetImageProviderCapabilities(arg1) {
+  return window['go']['main']['App']['GetImageProviderCapabilities'](arg1);
+}
+
+export function GetImageProviders() {
+  return window['go']['main']['App']['GetImageProviders']();
+}
+
+export function GetImageThumbnail(arg1, arg2) {
+  return window['go']['main']['App']['GetImageThumbnail'](arg1, arg2);
+}
+
+export function GetRecentCanvases() {
+  return window['go']['main']['App']['GetRecentCanvases']();
+}
+
+export function GetRecoverableSessions() {
+  return window['go']['main']['App']['GetRecoverableSessions']();
+}
+
 export function Greet(arg1) {
   return window['go']['main']['App']['Greet'](arg1);
 }
 
+export function ImportBundle() {
+  return window['go']['main']['App']['ImportBundle']();
+}
+
 export function ImportFile(arg1) {
   return window['go']['main']['App']['ImportFile'](arg1);
 }
 
+export function ImportMarkdownFolder(arg1) {
+  return window['go']['main']['App']['ImportMarkdownFolder'](arg1);
+}
+
+export function ListCanvasVersions(arg1) {
+  return window['go']['main']['App']['ListCanvasVersions'](arg1);
+}
+
+export function ListImages(arg1, arg2) {
+  return window['go']['main']['App']['ListImages'](arg1, arg2);
+}
+
+export function ListTrash() {
+  return window['go']['main']['App']['ListTrash']();
+}
+
 export function LoadCanvasFromFile() {
   return window['go']['main']['App']['LoadCanvasFromFile']();
 }
 
-export function SaveCanvasToFile(arg1) {
-  return window['go']['main']['App']['SaveCanvasToFile'](arg1);
+export function MergeCanvasWithFile(arg1) {
+  return window['go']['main']['App']['MergeCanvasWithFile'](arg1);
+}
+
+export function NewCanvas() {
+  return window['go']['main']['App']['NewCanvas']();
+}
+
+export function OpenCanvasPath(arg1) {
+  return window['go']['main']['App']['OpenCanvasPath'](arg1);
+}
+
+export function OverwriteCanvas(arg1) {
+  return window['go']['main']['App']['OverwriteCanvas'](arg1);
+}
+
+export function PurgeTrash(arg1) {
+  return window['go']['main']['App']['PurgeTrash'](arg1);
+}
+
+export function PushCanvasState(arg1, arg2) {
+  return window['go']['main']['App']['PushCanvasState'](arg1, arg2);
+}
+
+export function RegenerateImage(arg1) {
+  return window['go']['main']['App']['RegenerateImage'](arg1);
+}
+
+export function RestoreCanvasVersion(arg1, arg2) {
+  return window['go']['main']['App']['RestoreCanvasVersion'](arg1, arg2);
+}
+
+export function RestoreSession(arg1) {
+  return window['go']['main']['App']['RestoreSession'](arg1);
+}
+
+export function RestoreTrash(arg1) {
+  return window['go']['main']['App']['RestoreTrash'](arg1);
+}
+
+export function SaveCanvas(arg1) {
+  return window['go']['main']['App']['SaveCanvas'](arg1);
+}
+
+export function SaveCanvasAs(arg1) {
+  return window['go']['main']['App']['SaveCanvasAs'](arg1);
 }
 
 export function SaveConfig(arg1) {
   return window['go']['main']['App']['SaveConfig'](arg1);
 }
+
+export function ScanAssets(arg1, arg2) {
+  return window['go']['main']['App']['ScanAssets'](arg1, arg2);
+}
+
+export function SelectCanvasFiles() {
+  return window['go']['main']['App']['SelectCanvasFiles']();
+}
+
+export function TransformImage(arg1, arg2) {
+  return window['go']['main']['App']['TransformImage'](arg1, arg2);
+}
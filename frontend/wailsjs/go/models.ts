@@ -1,72 +1,169 @@
 export namespace backend {
 	
-	export class XAIConfig {
-	    apiKey: string;
-	    model: string;
+	export class AssetFileInfo {
+	    path: string;
+	    size: number;
+	    modTime: string;
 	
 	    static createFrom(source: any = {}) {
-	        return new XAIConfig(source);
+	        return new AssetFileInfo(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.apiKey = source["apiKey"];
-	        this.model = source["model"];
+	        this.path = source["path"];
+	        this.size = source["size"];
+	        this.modTime = source["modTime"];
 	    }
 	}
-	export class GoogleConfig {
-	    model: string;
-	    apiKey: string;
+	export class MissingAsset {
+	    path: string;
+	    canvas: string;
 	
 	    static createFrom(source: any = {}) {
-	        return new GoogleConfig(source);
+	        return new MissingAsset(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.model = source["model"];
-	        this.apiKey = source["apiKey"];
+	        this.path = source["path"];
+	        this.canvas = source["canvas"];
 	    }
 	}
-	export class OpenAIConfig {
-	    baseURL: string;
-	    model: string;
-	    apiKey: string;
+	export class AssetReport {
+	    canvases: string[];
+	    referenced: AssetFileInfo[];
+	    orphaned: AssetFileInfo[];
+	    missing: MissingAsset[];
+	    totalSize: number;
+	    orphanedSize: number;
+	    warnings: string[];
 	
 	    static createFrom(source: any = {}) {
-	        return new OpenAIConfig(source);
+	        return new AssetReport(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.baseURL = source["baseURL"];
-	        this.model = source["model"];
-	        this.apiKey = source["apiKey"];
+	        this.canvases = source["canvases"];
+	        this.referenced = this.convertValues(source["referenced"], AssetFileInfo);
+	        this.orphaned = this.convertValues(source["orphaned"], AssetFileInfo);
+	        this.missing = this.convertValues(source["missing"], MissingAsset);
+	        this.totalSize = source["totalSize"];
+	        this.orphanedSize = source["orphanedSize"];
+	        this.warnings = source["warnings"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CanvasFileChange {
+	    path: string;
+	    modifiedAt?: string;
+	    removed: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new CanvasFileChange(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.modifiedAt = source["modifiedAt"];
+	        this.removed = source["removed"];
 	    }
 	}
-	export class OpenRouterConfig {
-	    baseURL: string;
-	    model: string;
-	    apiKey: string;
+	export class CanvasVersion {
+	    id: string;
+	    savedAt: string;
+	    nodeCount: number;
+	    edgeCount: number;
+	    size: number;
 	
 	    static createFrom(source: any = {}) {
-	        return new OpenRouterConfig(source);
+	        return new CanvasVersion(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.baseURL = source["baseURL"];
-	        this.model = source["model"];
-	        this.apiKey = source["apiKey"];
+	        this.id = source["id"];
+	        this.savedAt = source["savedAt"];
+	        this.nodeCount = source["nodeCount"];
+	        this.edgeCount = source["edgeCount"];
+	        this.size = source["size"];
+	    }
+	}
+	export class ImportConfig {
+	    pdfSplit: string;
+	    pdfPageImages: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImportConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.pdfSplit = source["pdfSplit"];
+	        this.pdfPageImages = source["pdfPageImages"];
+	    }
+	}
+	export class StylePreset {
+	    name: string;
+	    promptPrefix: string;
+	    promptSuffix: string;
+	    negativePrompt: string;
+	    size: string;
+	    quality: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new StylePreset(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.promptPrefix = source["promptPrefix"];
+	        this.promptSuffix = source["promptSuffix"];
+	        this.negativePrompt = source["negativePrompt"];
+	        this.size = source["size"];
+	        this.quality = source["quality"];
+	    }
+	}
+	export class PromptEnhancementConfig {
+	    enabled: boolean;
+	    style: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PromptEnhancementConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.style = source["style"];
 	    }
 	}
 	export class ImageGenConfig {
 	    provider: string;
 	    downloadPath: string;
-	    openrouter?: OpenRouterConfig;
-	    openai?: OpenAIConfig;
-	    google?: GoogleConfig;
-	    xai?: XAIConfig;
+	    providers: Record<string, any>;
+	    promptEnhancement: PromptEnhancementConfig;
+	    stylePresets: StylePreset[];
 	    baseURL?: string;
 	    model?: string;
 	    apiKey?: string;
@@ -79,10 +176,9 @@ export namespace backend {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.provider = source["provider"];
 	        this.downloadPath = source["downloadPath"];
-	        this.openrouter = this.convertValues(source["openrouter"], OpenRouterConfig);
-	        this.openai = this.convertValues(source["openai"], OpenAIConfig);
-	        this.google = this.convertValues(source["google"], GoogleConfig);
-	        this.xai = this.convertValues(source["xai"], XAIConfig);
+	        this.providers = source["providers"];
+	        this.promptEnhancement = this.convertValues(source["promptEnhancement"], PromptEnhancementConfig);
+	        this.stylePresets = this.convertValues(source["stylePresets"], StylePreset);
 	        this.baseURL = source["baseURL"];
 	        this.model = source["model"];
 	        this.apiKey = source["apiKey"];
@@ -108,6 +204,7 @@ export namespace backend {
 	}
 	export class GenerationConfig {
 	    summaryMaxChars: number;
+	    autoDescribeImages: boolean;
 	
 	    static createFrom(source: any = {}) {
 	        return new GenerationConfig(source);
@@ -116,6 +213,7 @@ export namespace backend {
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.summaryMaxChars = source["summaryMaxChars"];
+	        this.autoDescribeImages = source["autoDescribeImages"];
 	    }
 	}
 	export class LLMConfig {
@@ -140,6 +238,8 @@ export namespace backend {
 	    llm: LLMConfig;
 	    generation: GenerationConfig;
 	    imageGen: ImageGenConfig;
+	    import: ImportConfig;
+	    recentCanvases: string[];
 	
 	    static createFrom(source: any = {}) {
 	        return new Config(source);
@@ -150,6 +250,8 @@ export namespace backend {
 	        this.llm = this.convertValues(source["llm"], LLMConfig);
 	        this.generation = this.convertValues(source["generation"], GenerationConfig);
 	        this.imageGen = this.convertValues(source["imageGen"], ImageGenConfig);
+	        this.import = this.convertValues(source["import"], ImportConfig);
+	        this.recentCanvases = source["recentCanvases"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -170,26 +272,786 @@ export namespace backend {
 		    return a;
 		}
 	}
+	export class ExportBundleResult {
+	    path: string;
+	    images: number;
+	    missing: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ExportBundleResult(source);
+	    }
 	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.images = source["images"];
+	        this.missing = source["missing"];
+	    }
+	}
 	
+	export class ImageDescription {
+	    caption: string;
+	    description: string;
 	
-	export class ImportFileResult {
+	    static createFrom(source: any = {}) {
+	        return new ImageDescription(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.caption = source["caption"];
+	        this.description = source["description"];
+	    }
+	}
+	export class ImageGenCapabilities {
+	    maxReferenceImages: number;
+	    sizes: string[];
+	    qualities: string[];
+	    formats: string[];
+	    supportsEdit: boolean;
+	    supportsMask: boolean;
+	    supportsMultiple: boolean;
+	    supportsNegativePrompt: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImageGenCapabilities(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.maxReferenceImages = source["maxReferenceImages"];
+	        this.sizes = source["sizes"];
+	        this.qualities = source["qualities"];
+	        this.formats = source["formats"];
+	        this.supportsEdit = source["supportsEdit"];
+	        this.supportsMask = source["supportsMask"];
+	        this.supportsMultiple = source["supportsMultiple"];
+	        this.supportsNegativePrompt = source["supportsNegativePrompt"];
+	    }
+	}
+	
+	export class ImageGenResult {
+	    src: string;
+	    originalPrompt: string;
+	    prompt: string;
+	    negativePrompt: string;
+	    enhanced: boolean;
+	    stylePreset: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImageGenResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.src = source["src"];
+	        this.originalPrompt = source["originalPrompt"];
+	        this.prompt = source["prompt"];
+	        this.negativePrompt = source["negativePrompt"];
+	        this.enhanced = source["enhanced"];
+	        this.stylePreset = source["stylePreset"];
+	    }
+	}
+	export class ImageInfo {
+	    path: string;
+	    width: number;
+	    height: number;
+	    size: number;
+	    format: string;
+	    createdAt: string;
+	    provider?: string;
+	    model?: string;
+	    prompt?: string;
+	    parent?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImageInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.size = source["size"];
+	        this.format = source["format"];
+	        this.createdAt = source["createdAt"];
+	        this.provider = source["provider"];
+	        this.model = source["model"];
+	        this.prompt = source["prompt"];
+	        this.parent = source["parent"];
+	    }
+	}
+	export class ImageListFilter {
+	    since: string;
+	    until: string;
+	    provider: string;
+	    query: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImageListFilter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.since = source["since"];
+	        this.until = source["until"];
+	        this.provider = source["provider"];
+	        this.query = source["query"];
+	    }
+	}
+	export class ImageListPage {
+	    page: number;
+	    pageSize: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImageListPage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.page = source["page"];
+	        this.pageSize = source["pageSize"];
+	    }
+	}
+	export class ImageListResult {
+	    items: ImageInfo[];
+	    total: number;
+	    page: number;
+	    pageSize: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImageListResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.items = this.convertValues(source["items"], ImageInfo);
+	        this.total = source["total"];
+	        this.page = source["page"];
+	        this.pageSize = source["pageSize"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ImageOperation {
+	    type: string;
+	    x?: number;
+	    y?: number;
+	    width?: number;
+	    height?: number;
+	    scale?: number;
+	    angle?: number;
+	    direction?: string;
+	    format?: string;
+	    quality?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImageOperation(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.type = source["type"];
+	        this.x = source["x"];
+	        this.y = source["y"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.scale = source["scale"];
+	        this.angle = source["angle"];
+	        this.direction = source["direction"];
+	        this.format = source["format"];
+	        this.quality = source["quality"];
+	    }
+	}
+	export class ImageProvenance {
+	    image: string;
+	    createdAt: string;
+	    provider: string;
+	    model: string;
+	    prompt: string;
+	    context: string;
+	    enhancedPrompt?: string;
+	    negativePrompt?: string;
+	    refImages: string[];
+	    parameters?: Record<string, any>;
+	    regeneratedFrom?: string;
+	    parent?: string;
+	    operations?: ImageOperation[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ImageProvenance(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.image = source["image"];
+	        this.createdAt = source["createdAt"];
+	        this.provider = source["provider"];
+	        this.model = source["model"];
+	        this.prompt = source["prompt"];
+	        this.context = source["context"];
+	        this.enhancedPrompt = source["enhancedPrompt"];
+	        this.negativePrompt = source["negativePrompt"];
+	        this.refImages = source["refImages"];
+	        this.parameters = source["parameters"];
+	        this.regeneratedFrom = source["regeneratedFrom"];
+	        this.parent = source["parent"];
+	        this.operations = this.convertValues(source["operations"], ImageOperation);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ProviderConfigField {
+	    key: string;
+	    label: string;
+	    type: string;
+	    default?: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProviderConfigField(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.key = source["key"];
+	        this.label = source["label"];
+	        this.type = source["type"];
+	        this.default = source["default"];
+	    }
+	}
+	export class ImageProviderInfo {
+	    name: string;
+	    displayName: string;
+	    fields: ProviderConfigField[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ImageProviderInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.displayName = source["displayName"];
+	        this.fields = this.convertValues(source["fields"], ProviderConfigField);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class ImportedItem {
 	    type: string;
 	    content: string;
+	    title?: string;
 	
 	    static createFrom(source: any = {}) {
-	        return new ImportFileResult(source);
+	        return new ImportedItem(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.type = source["type"];
 	        this.content = source["content"];
+	        this.title = source["title"];
 	    }
 	}
+	export class ImportFileResult {
+	    items: ImportedItem[];
+	    chain: boolean;
+	    warnings: string[];
 	
+	    static createFrom(source: any = {}) {
+	        return new ImportFileResult(source);
+	    }
 	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.items = this.convertValues(source["items"], ImportedItem);
+	        this.chain = source["chain"];
+	        this.warnings = source["warnings"];
+	    }
 	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
+	export class LoadCanvasResult {
+	    path: string;
+	    canvas?: canvas.Canvas;
+	    warnings: canvas.Warning[];
+	
+	    static createFrom(source: any = {}) {
+	        return new LoadCanvasResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.canvas = this.convertValues(source["canvas"], canvas.Canvas);
+	        this.warnings = this.convertValues(source["warnings"], canvas.Warning);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
+	
+	export class RecoverableSession {
+	    id: string;
+	    savedAt: string;
+	    sourcePath: string;
+	    nodeCount: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new RecoverableSession(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.savedAt = source["savedAt"];
+	        this.sourcePath = source["sourcePath"];
+	        this.nodeCount = source["nodeCount"];
+	    }
+	}
+	export class SaveCanvasResult {
+	    path: string;
+	    warnings: canvas.Warning[];
+	    conflict?: CanvasFileChange;
+	
+	    static createFrom(source: any = {}) {
+	        return new SaveCanvasResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.warnings = this.convertValues(source["warnings"], canvas.Warning);
+	        this.conflict = this.convertValues(source["conflict"], CanvasFileChange);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class TrashBatch {
+	    id: string;
+	    createdAt: string;
+	    paths: string[];
+	    size: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TrashBatch(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.createdAt = source["createdAt"];
+	        this.paths = source["paths"];
+	        this.size = source["size"];
+	    }
+	}
+
+}
+
+export namespace canvas {
+	
+	export class MarkerEnd {
+	    type: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new MarkerEnd(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.type = source["type"];
+	    }
+	}
+	export class Edge {
+	    id: string;
+	    source: string;
+	    target: string;
+	    sourceHandle?: string;
+	    targetHandle?: string;
+	    type?: string;
+	    markerEnd?: MarkerEnd;
+	
+	    static createFrom(source: any = {}) {
+	        return new Edge(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.source = source["source"];
+	        this.target = source["target"];
+	        this.sourceHandle = source["sourceHandle"];
+	        this.targetHandle = source["targetHandle"];
+	        this.type = source["type"];
+	        this.markerEnd = this.convertValues(source["markerEnd"], MarkerEnd);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class NodeData {
+	    content?: string;
+	    summary?: string;
+	    src?: string;
+	    alt?: string;
+	    description?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new NodeData(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.content = source["content"];
+	        this.summary = source["summary"];
+	        this.src = source["src"];
+	        this.alt = source["alt"];
+	        this.description = source["description"];
+	    }
+	}
+	export class Position {
+	    x: number;
+	    y: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Position(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.x = source["x"];
+	        this.y = source["y"];
+	    }
+	}
+	export class Node {
+	    id: string;
+	    type: string;
+	    position: Position;
+	    data: NodeData;
+	    width?: number;
+	    height?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Node(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.type = source["type"];
+	        this.position = this.convertValues(source["position"], Position);
+	        this.data = this.convertValues(source["data"], NodeData);
+	        this.width = source["width"];
+	        this.height = source["height"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class LLMInfo {
+	    baseURL?: string;
+	    model?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new LLMInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.baseURL = source["baseURL"];
+	        this.model = source["model"];
+	    }
+	}
+	export class Metadata {
+	    lastOpened?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Metadata(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.lastOpened = source["lastOpened"];
+	    }
+	}
+	export class Canvas {
+	    version: string;
+	    metadata?: Metadata;
+	    llm?: LLMInfo;
+	    nodes: Node[];
+	    edges: Edge[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Canvas(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
+	        this.metadata = this.convertValues(source["metadata"], Metadata);
+	        this.llm = this.convertValues(source["llm"], LLMInfo);
+	        this.nodes = this.convertValues(source["nodes"], Node);
+	        this.edges = this.convertValues(source["edges"], Edge);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class NodeChange {
+	    nodeId: string;
+	    type: string;
+	    from?: Position;
+	    to?: Position;
+	    field?: string;
+	    oldValue?: string;
+	    newValue?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new NodeChange(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.nodeId = source["nodeId"];
+	        this.type = source["type"];
+	        this.from = this.convertValues(source["from"], Position);
+	        this.to = this.convertValues(source["to"], Position);
+	        this.field = source["field"];
+	        this.oldValue = source["oldValue"];
+	        this.newValue = source["newValue"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Diff {
+	    added: NodeChange[];
+	    removed: NodeChange[];
+	    moved: NodeChange[];
+	    changed: NodeChange[];
+	    edgesAdded: Edge[];
+	    edgesRemoved: Edge[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Diff(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.added = this.convertValues(source["added"], NodeChange);
+	        this.removed = this.convertValues(source["removed"], NodeChange);
+	        this.moved = this.convertValues(source["moved"], NodeChange);
+	        this.changed = this.convertValues(source["changed"], NodeChange);
+	        this.edgesAdded = this.convertValues(source["edgesAdded"], Edge);
+	        this.edgesRemoved = this.convertValues(source["edgesRemoved"], Edge);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
+	
+	
+	
+	
+	
+	
+	export class Warning {
+	    code: string;
+	    message: string;
+	    nodeId?: string;
+	    edgeId?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Warning(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.code = source["code"];
+	        this.message = source["message"];
+	        this.nodeId = source["nodeId"];
+	        this.edgeId = source["edgeId"];
+	    }
+	}
 
 }
 
@@ -2,7 +2,14 @@ module fm-doc-canvas
 
 go 1.24.4
 
-require github.com/wailsapp/wails/v2 v2.11.0
+require (
+	github.com/fsnotify/fsnotify v1.9.0
+	github.com/ledongthuc/pdf v0.0.0-20250511090121-5959a4027728
+	github.com/wailsapp/wails/v2 v2.11.0
+	golang.org/x/image v0.25.0
+	golang.org/x/net v0.43.0
+	golang.org/x/text v0.28.0
+)
 
 require (
 	github.com/bep/debounce v1.2.1 // indirect
@@ -31,9 +38,7 @@ require (
 	github.com/wailsapp/go-webview2 v1.0.22 // indirect
 	github.com/wailsapp/mimetype v1.4.1 // indirect
 	golang.org/x/crypto v0.41.0 // indirect
-	golang.org/x/net v0.43.0 // indirect
 	golang.org/x/sys v0.35.0 // indirect
-	golang.org/x/text v0.28.0 // indirect
 )
 
 // replace github.com/wailsapp/wails/v2 v2.11.0 => C:\Users\rero2\go\pkg\mod